@@ -15,6 +15,7 @@ const (
 	constantsClust = "constants"
 	inputsClust    = "inputs"
 	gradClust      = "gradients"
+	tangentClust   = "tangents"
 	strayClust     = "undifferentiated nodes"
 
 	// subgraphs to rank the same
@@ -56,6 +57,10 @@ const (
 	subFail             = "Failed to carry Sub()"
 	addFail             = "Failed to carry Add()"
 	signFail            = "Failed to carry Sign()"
+	sqrtFail            = "Failed to carry Sqrt()"
+	divFail             = "Failed to carry Div()"
+	absFail             = "Failed to carry Abs()"
+	gtFail              = "Failed to carry Gt()"
 	softplusFail        = "Failed to carry Softplus()"
 	incrErr             = "increment couldn't be done. Safe op was performed instead"
 	bindFail            = "Failed to bind"
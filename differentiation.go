@@ -1,6 +1,9 @@
 package gorgonia
 
-import "github.com/pkg/errors"
+import (
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
 
 /*
 This file holds code for symbolic differentiation.
@@ -322,3 +325,152 @@ func Backpropagate(outputs, gradOutputs, wrt Nodes) (retVal Nodes, err error) {
 	}
 	return
 }
+
+// ForwardPropagate performs forward-mode symbolic differentiation. Given the seed tangents of the wrt nodes, it creates the nodes that
+// compute the tangents (directional derivatives) of the outputs, starting from the inputs and working its way towards the outputs.
+// The tangent nodes are added to the graph, so compiling the graph afterwards emits the tangent-propagation instructions alongside the primal ones.
+//
+// This is the rough algorithm:
+//		1. Forwards analysis, where a list of nodes affecting the output is added to consideration
+//		2. Backwards analysis, where a list of nodes affected by the wrts are added to the consideration
+//		3. Traverse the graph from input towards output. On each visit, push the tangents of the children through the op
+//
+// Forward mode is cheaper than reverse mode when there are few inputs and many outputs.
+// For most cases, JVP() should be used instead of ForwardPropagate(), as JVP() performs several checks before calling ForwardPropagate()
+//
+// The tangents of the outputs are usually intermediate nodes, whose values the tape machine reuses for other nodes. To read the tangents
+// after running the graph, either run it on a tape machine created with TraceExec(), or keep the values alive with Read().
+func ForwardPropagate(wrt, seeds, outputs Nodes) (retVal Nodes, err error) {
+	symdiffLogf("FORWARDPROP START")
+	symdiffLogf("WRT: %d", wrt)
+	symdiffLogf("Seeds: %d", seeds)
+	symdiffLogf("Outputs: %d", outputs)
+
+	enterLoggingContext()
+	defer leaveLoggingContext()
+
+	g := outputs[0].g
+
+	var sortedNodes Nodes
+	if sortedNodes, err = Sort(g); err != nil {
+		return nil, errors.Wrap(err, sortFail)
+	}
+
+	var affectsOutput NodeSet
+	var affectedByWRT NodeSet
+	if affectsOutput, err = forwardDiffAnalysis(outputs, sortedNodes); err != nil {
+		return nil, errors.Wrap(err, "Failed during forward differentiation analysis")
+	}
+
+	if affectedByWRT, err = backwardDiffAnalysis(wrt, sortedNodes); err != nil {
+		return nil, errors.Wrap(err, "Failed during backward differentiation analysis")
+	}
+
+	outputSet := outputs.mapSet()
+	badOutputs := outputSet.Difference(affectedByWRT)
+	if len(badOutputs) > 0 {
+		return nil, errors.Errorf("Non differentiable outputs: %v", badOutputs)
+	}
+
+	// map a node to its tangent.
+	tangents := make(map[*Node]*Node)
+	for i, n := range wrt {
+		tangents[n] = seeds[i]
+	}
+
+	activeNodes := affectsOutput.Intersect(affectedByWRT)
+	symdiffLogf("Active: %d", activeNodes)
+
+	// sortedNodes is in reverse topological order, so we walk it backwards
+	for i := len(sortedNodes) - 1; i >= 0; i-- {
+		node := sortedNodes[i]
+		if _, ok := activeNodes[node]; !ok || node.isInput() {
+			continue
+		}
+
+		diffs := node.diffWRT()
+		childTangents := make(Nodes, len(node.children))
+		var hasTangent bool
+		for j, child := range node.children {
+			if diffs[j] {
+				childTangents[j] = tangents[child]
+				hasTangent = hasTangent || childTangents[j] != nil
+			}
+		}
+
+		if !hasTangent {
+			continue
+		}
+
+		var op FwdDiffOp
+		var ok bool
+		if op, ok = node.op.(FwdDiffOp); !ok {
+			return nil, errors.Errorf(nyiFail, "Forward differentiation", node.op)
+		}
+
+		symdiffLogf("pushing tangents through %x (%v)", node.ID(), node.op)
+		var tangent *Node
+		if tangent, err = op.FwdDiff(node.children, childTangents, node); err != nil {
+			return nil, errors.Wrapf(err, "FwdDiff for %v. OpType: %v. Node Type: %v. Children: %#v", node.op, node.op.Type(), node.t, node.children)
+		}
+		// linear ops may pass the tangent of a child (such as a seed) through unchanged, so only the tangents FwdDiff created are grouped
+		if !childTangents.Contains(tangent) {
+			tangent.setGroup(tangentClust)
+		}
+		tangents[node] = tangent
+	}
+
+	for _, n := range outputs {
+		retVal = append(retVal, tangents[n])
+	}
+	return
+}
+
+// linearFwdDiff is the forward differentiation of a linear unary op: the tangent is simply the op applied to the tangent of the input.
+func linearFwdDiff(op Op, inputs, tangents Nodes) (retVal *Node, err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+
+	if retVal, err = ApplyOp(op, tangents[0]); err != nil {
+		return nil, errors.Wrap(err, applyOpFail)
+	}
+	return
+}
+
+// regroupTangent moves the nodes of a tangent that were grouped with the gradients into the tangent group. This is needed when the tangent
+// is built by one of the reverse mode expressions. The walk stops at the nodes the tangent was built from.
+func regroupTangent(tangent *Node, from ...*Node) {
+	stop := Nodes(from).mapSet()
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, child := range n.children {
+			if _, ok := stop[child]; ok || child.group != gradClust {
+				continue
+			}
+			child.setGroup(tangentClust)
+			walk(child)
+		}
+	}
+	if _, ok := stop[tangent]; !ok {
+		walk(tangent)
+	}
+}
+
+// broadcastTangent broadcasts a scalar tangent to the shape of its (non-scalar) output.
+func broadcastTangent(tangent, output *Node) (retVal *Node, err error) {
+	if !tangent.IsScalar() || output.IsScalar() {
+		return tangent, nil
+	}
+
+	var dt tensor.Dtype
+	if dt, err = dtypeOf(output.t); err != nil {
+		return nil, errors.Wrap(err, dtypeOfFail)
+	}
+
+	ones := output.g.AddNode(NewConstant(tensor.Ones(dt, output.Shape()...)))
+	if retVal, err = HadamardProd(ones, tangent); err != nil {
+		return nil, errors.Wrap(err, hadamardProdFail)
+	}
+	return
+}
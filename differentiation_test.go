@@ -1,8 +1,10 @@
 package gorgonia
 
 import (
+	"math"
 	"testing"
 
+	"github.com/chewxy/gorgonia/tensor"
 	"github.com/gonum/graph/topo"
 	"github.com/stretchr/testify/assert"
 )
@@ -162,3 +164,89 @@ func TestCompoundOpDiff(t *testing.T) {
 	}
 
 }
+
+func TestJVP(t *testing.T) {
+	g := NewGraph()
+	W := NewMatrix(g, Float64, WithName("W"), WithShape(3, 3), WithValue(tensor.New(tensor.WithShape(3, 3), tensor.WithBacking([]float64{0.1, 0.2, 0.3, -0.4, 0.5, 0.6, 0.7, -0.8, 0.9}))))
+	x := NewVector(g, Float64, WithName("x"), WithShape(3), WithValue(tensor.New(tensor.WithBacking([]float64{1, 2, 3}))))
+	s := NewScalar(g, Float64, WithName("s"), WithValue(2.0))
+
+	// cost = Σ tanh(W×x) ⊙ x ÷ (x + s) + s^x
+	wx := Must(Mul(W, x))
+	th := Must(Tanh(wx))
+	num := Must(HadamardProd(th, x))
+	den := Must(Add(x, s))
+	frac := Must(HadamardDiv(num, den))
+	pow := Must(Pow(s, x))
+	cost := Must(Sum(Must(Add(frac, pow))))
+
+	// seeds
+	dx := NewVector(g, Float64, WithName("dx"), WithShape(3), WithValue(tensor.New(tensor.WithBacking([]float64{0.5, -1, 2}))))
+	ds := NewScalar(g, Float64, WithName("ds"), WithValue(3.0))
+
+	primal := g.AllNodes().mapSet()
+	tangents, err := JVP(Nodes{cost, th}, Nodes{x, s}, Nodes{dx, ds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range g.AllNodes() {
+		if _, ok := primal[n]; !ok && !n.isConstant() && n.group != tangentClust {
+			t.Errorf("Expected %v to be grouped with the tangents. Got group %q", n, n.group)
+		}
+	}
+
+	// reverse mode for comparison
+	grads, err := Grad(cost, x, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tanh(W×x)'s tangent is an intermediate value, so trace the execution to stop it from being clobbered
+	m := NewTapeMachine(g, TraceExec())
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	gx := extractF64s(grads[0].Value())
+	gs := extractF64(grads[1].Value())
+	expected := gx[0]*0.5 - gx[1] + gx[2]*2 + gs*3
+	if got := extractF64(tangents[0].Value()); !closeF64(expected, got) {
+		t.Errorf("Expected the tangent of the cost to be %v. Got %v instead", expected, got)
+	}
+
+	// d tanh(W×x) = (1 - tanh²(W×x)) ⊙ (W×dx)
+	wxv := extractF64s(wx.Value())
+	Wv := extractF64s(W.Value())
+	dxv := []float64{0.5, -1, 2}
+	thTangents := extractF64s(tangents[1].Value())
+	for i := 0; i < 3; i++ {
+		var wdx float64
+		for j := 0; j < 3; j++ {
+			wdx += Wv[i*3+j] * dxv[j]
+		}
+		th := math.Tanh(wxv[i])
+		if expected := (1 - th*th) * wdx; !closeF64(expected, thTangents[i]) {
+			t.Errorf("Expected tangent %d of tanh(W×x) to be %v. Got %v instead", i, expected, thTangents[i])
+		}
+	}
+
+	// the tangent of a + b with respect to a is the seed itself, which stays in its own group
+	g2 := NewGraph()
+	a := NewVector(g2, Float64, WithName("a"), WithShape(2))
+	b := NewVector(g2, Float64, WithName("b"), WithShape(2))
+	da := NewVector(g2, Float64, WithName("da"), WithShape(2), WithGroupName("seeds"))
+	if tangents, err = JVP(Nodes{Must(Add(a, b))}, Nodes{a}, Nodes{da}); err != nil {
+		t.Fatal(err)
+	}
+	if tangents[0] != da || da.group != "seeds" {
+		t.Errorf("Expected the seed to be the tangent, in the group %q. Got %v in the group %q", "seeds", tangents[0], da.group)
+	}
+
+	// errors
+	if _, err = JVP(Nodes{cost}, Nodes{x}, Nodes{ds}); err == nil {
+		t.Error("Expected an error when the seed does not match the WRT")
+	}
+	if _, err = JVP(Nodes{cost}, Nodes{x, s}, Nodes{dx}); err == nil {
+		t.Error("Expected an error when the number of seeds does not match the number of WRTs")
+	}
+}
@@ -0,0 +1,173 @@
+package gorgonia
+
+import (
+	"math"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+// EMA maintains an exponential moving average of the values of a model. Call Update after every Step of the solver:
+//		shadow = decay * shadow + (1 - decay) * w
+//
+// For evaluation, Apply swaps the averaged values into the nodes of the model, and Restore swaps the trained values back.
+//
+// With bias correction, the shadow values start at zero and the average is divided by (1 - decayᵗ) after t updates, so that the early averages
+// are not biased towards zero. Without it, the shadow values start with the values of the model at the first Update.
+type EMA struct {
+	decay          float64
+	biasCorrection bool
+
+	model  Nodes
+	shadow []Value
+	backup []Value // the trained values, while the averaged values are applied
+	steps  int
+}
+
+// NewEMA creates an EMA of the values of the model.
+func NewEMA(model Nodes, decay float64, biasCorrection bool) *EMA {
+	return &EMA{
+		decay:          decay,
+		biasCorrection: biasCorrection,
+		model:          model,
+	}
+}
+
+// Update updates the moving averages with the current values of the model.
+func (e *EMA) Update() (err error) {
+	if e.backup != nil {
+		return errors.New("Cannot update the EMA while the averaged values are applied. Call Restore first")
+	}
+
+	if e.shadow == nil {
+		e.shadow = make([]Value, len(e.model))
+		for i, n := range e.model {
+			v := weightsOf(n)
+			if v == nil {
+				return errors.Errorf("Node %v has no value", n)
+			}
+			if e.shadow[i], err = CloneValue(v); err != nil {
+				return errors.Wrap(err, cloneFail)
+			}
+			if e.biasCorrection {
+				e.shadow[i] = ZeroValue(e.shadow[i])
+			}
+		}
+		if !e.biasCorrection {
+			e.steps++
+			return nil
+		}
+	}
+
+	e.steps++
+	for i, n := range e.model {
+		if e.shadow[i], err = emaLerp(e.shadow[i], weightsOf(n), e.decay, 1); err != nil {
+			return errors.Wrapf(err, "Failed to update the average of %v", n)
+		}
+	}
+	return nil
+}
+
+// Apply swaps the averaged values into the nodes of the model. The values of the model are kept until Restore is called.
+func (e *EMA) Apply() (err error) {
+	if e.shadow == nil {
+		return errors.New("Cannot apply an EMA that has not been updated")
+	}
+	if e.backup != nil {
+		return errors.New("The averaged values are already applied")
+	}
+
+	correction := 1.0
+	if e.biasCorrection {
+		correction = 1 - math.Pow(e.decay, float64(e.steps))
+	}
+
+	backup := make([]Value, len(e.model))
+	for i, n := range e.model {
+		var avg Value
+		if avg, err = CloneValue(e.shadow[i]); err != nil {
+			return errors.Wrap(err, cloneFail)
+		}
+		if avg, err = emaLerp(avg, avg, 0, 1/correction); err != nil {
+			return errors.Wrapf(err, "Failed to correct the average of %v", n)
+		}
+
+		backup[i] = weightsOf(n)
+		setWeightsOf(n, avg)
+	}
+	e.backup = backup
+	return nil
+}
+
+// Restore swaps the values of the model back after Apply.
+func (e *EMA) Restore() error {
+	if e.backup == nil {
+		return errors.New("The averaged values are not applied")
+	}
+	for i, n := range e.model {
+		setWeightsOf(n, e.backup[i])
+	}
+	e.backup = nil
+	return nil
+}
+
+// weightsOf returns the value of a node of a model, without its derivative
+func weightsOf(n *Node) Value {
+	if dv, ok := n.boundTo.(*dualValue); ok {
+		return dv.Value
+	}
+	return n.boundTo
+}
+
+func setWeightsOf(n *Node, v Value) {
+	if dv, ok := n.boundTo.(*dualValue); ok {
+		dv.Value = v
+		return
+	}
+	n.boundTo = v
+}
+
+// emaLerp computes scale * (decay * a + (1 - decay) * b), in place for tensors
+func emaLerp(a, b Value, decay, scale float64) (Value, error) {
+	switch at := a.(type) {
+	case *tensor.Dense:
+		bt, ok := b.(*tensor.Dense)
+		if !ok || !at.Shape().Eq(bt.Shape()) {
+			return nil, errors.Errorf("Expected a tensor of shape %v. Got %v instead", at.Shape(), b)
+		}
+		switch data := at.Data().(type) {
+		case []float64:
+			other, ok := bt.Data().([]float64)
+			if !ok {
+				return nil, errors.Errorf(nyiTypeFail, "EMA", b)
+			}
+			for i := range data {
+				data[i] = scale * (decay*data[i] + (1-decay)*other[i])
+			}
+		case []float32:
+			other, ok := bt.Data().([]float32)
+			if !ok {
+				return nil, errors.Errorf(nyiTypeFail, "EMA", b)
+			}
+			for i := range data {
+				data[i] = float32(scale * (decay*float64(data[i]) + (1-decay)*float64(other[i])))
+			}
+		default:
+			return nil, errors.Errorf(nyiFail, "EMA", at.Dtype())
+		}
+		return at, nil
+	case *F64:
+		bt, ok := b.(*F64)
+		if !ok {
+			return nil, errors.Errorf(nyiTypeFail, "EMA", b)
+		}
+		return newF64(scale * (decay*at.any() + (1-decay)*bt.any())), nil
+	case *F32:
+		bt, ok := b.(*F32)
+		if !ok {
+			return nil, errors.Errorf(nyiTypeFail, "EMA", b)
+		}
+		return newF32(float32(scale * (decay*float64(at.any()) + (1-decay)*float64(bt.any())))), nil
+	}
+	return nil, errors.Errorf(nyiTypeFail, "EMA", a)
+}
@@ -0,0 +1,92 @@
+package gorgonia
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestEMA(t *testing.T) {
+	g := NewGraph()
+	w := NewVector(g, Float64, WithName("w"), WithShape(2), WithValue(tensor.New(tensor.WithBacking([]float64{1, 2}))))
+	b := NewScalar(g, Float32, WithName("b"), WithValue(float32(4)))
+	model := Nodes{w, b}
+
+	for _, biasCorrection := range []bool{true, false} {
+		Let(w, tensor.New(tensor.WithBacking([]float64{1, 2})))
+		Let(b, float32(4))
+
+		ema := NewEMA(model, 0.5, biasCorrection)
+		if err := ema.Apply(); err == nil {
+			t.Error("Expected an error when applying an EMA that has not been updated")
+		}
+		if err := ema.Update(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the "training" doubles the weights
+		Let(w, tensor.New(tensor.WithBacking([]float64{2, 4})))
+		Let(b, float32(8))
+		if err := ema.Update(); err != nil {
+			t.Fatal(err)
+		}
+
+		// with bias correction: shadow = 0.5*(0.5*0 + 0.5*1) + 0.5*2 = 1.25. Corrected by 1 - 0.5² → 1.6667
+		// without: shadow = 0.5*1 + 0.5*2 = 1.5
+		expected := 1.5
+		if biasCorrection {
+			expected = 1.25 / 0.75
+		}
+
+		if err := ema.Apply(); err != nil {
+			t.Fatal(err)
+		}
+		got := w.Value().Data().([]float64)
+		if math.Abs(got[0]-expected) > 1e-10 || math.Abs(got[1]-2*expected) > 1e-10 {
+			t.Errorf("Bias correction %t: Expected the averaged weights to be [%v %v]. Got %v instead", biasCorrection, expected, 2*expected, got)
+		}
+		if gotB := b.Value().Data().(float32); math.Abs(float64(gotB)-4*expected) > 1e-5 {
+			t.Errorf("Bias correction %t: Expected the averaged bias to be %v. Got %v instead", biasCorrection, 4*expected, gotB)
+		}
+		if err := ema.Update(); err == nil {
+			t.Error("Expected an error when updating while the averages are applied")
+		}
+
+		if err := ema.Restore(); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Value().Data().([]float64); !floatsEqual64([]float64{2, 4}, got) {
+			t.Errorf("Expected the trained weights to be restored. Got %v", got)
+		}
+		if gotB := b.Value().Data().(float32); gotB != 8 {
+			t.Errorf("Expected the trained bias to be restored. Got %v", gotB)
+		}
+	}
+}
+
+func TestEMALerpTypes(t *testing.T) {
+	f64s := tensor.New(tensor.WithBacking([]float64{1, 2}))
+	f32s := tensor.New(tensor.WithBacking([]float32{1, 2}))
+	bad := []struct {
+		a, b Value
+	}{
+		{f64s, f32s},
+		{f32s, f64s},
+		{newF64(1), newF32(1)},
+		{newF32(1), newF64(1)},
+	}
+	for _, c := range bad {
+		if _, err := emaLerp(c.a, c.b, 0.5, 1); err == nil {
+			t.Errorf("Expected an error averaging %v with %v", TypeOf(c.a), TypeOf(c.b))
+		}
+	}
+
+	retVal, err := emaLerp(newF32(1), newF32(3), 0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := retVal.Data().(float32); v != 2 {
+		t.Errorf("Expected 2. Got %v instead", v)
+	}
+}
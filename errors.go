@@ -1,8 +1,10 @@
 package gorgonia
 
 import (
+	"bytes"
 	"fmt"
 
+	"github.com/chewxy/gorgonia/tensor"
 	"github.com/pkg/errors"
 )
 
@@ -62,6 +64,117 @@ func (err vmContextualError) Node() *Node        { return err.node }
 func (err vmContextualError) Value() Value       { return err.node.Value() }
 func (err vmContextualError) InstructionID() int { return err.instr }
 
+// CanceledError is returned by RunAllContext when the context is done before the VM finishes running.
+type CanceledError struct {
+	Node     *Node // the node that would have been executed next. It may be nil for instructions that are not tied to a node
+	Instr    int   // the instruction (*tapeMachine) or the position of the node in the sorted graph (*lispMachine) that would have been executed next
+	Backward bool  // true if the *lispMachine was backpropagating
+	Err      error // the error of the context
+}
+
+func (err *CanceledError) Error() string {
+	dir := "forwards"
+	if err.Backward {
+		dir = "backwards"
+	}
+	if err.Node == nil {
+		return fmt.Sprintf("%v. Stopped before instruction %d", err.Err, err.Instr)
+	}
+	return fmt.Sprintf("%v. Stopped %s before node %v (instruction %d)", err.Err, dir, err.Node, err.Instr)
+}
+
+// Cause returns the error of the context, so errors.Cause(err) == context.Canceled (or context.DeadlineExceeded).
+func (err *CanceledError) Cause() error { return err.Err }
+
+// Unwrap returns the error of the context.
+func (err *CanceledError) Unwrap() error { return err.Err }
+
+// BreakpointError is returned by RunAll and RunAllContext when a breakpoint stops the VM. Calling RunAll again continues the run from
+// where it stopped.
+type BreakpointError struct {
+	Breakpoint Breakpoint // the breakpoint that stopped the VM
+	Node       *Node      // the node whose value (or gradient, if Backward) was computed
+	Value      Value      // the value (or gradient) of the node
+	Instr      int        // the instruction (*tapeMachine), the position of the node in the sorted graph or of the differentiation instruction (*lispMachine) that was executed
+	Backward   bool       // true if the *lispMachine was backpropagating
+}
+
+func (err *BreakpointError) Error() string {
+	if err.Backward {
+		return fmt.Sprintf("Stopped at %v after computing the gradient of %v (instruction %d)", err.Breakpoint, err.Node, err.Instr)
+	}
+	return fmt.Sprintf("Stopped at %v after computing %v (instruction %d)", err.Breakpoint, err.Node, err.Instr)
+}
+
+// ExecError is returned when a VM fails to execute the op of a node, and by ApplyOp when the shape of the result of an op cannot be
+// inferred. Use errors.Cause to get it from the errors returned by the VMs.
+type ExecError struct {
+	Node        *Node          // nil if the node could not be created
+	Op          Op             // the op of the node, or the ADOp being differentiated if Backward
+	InputShapes []tensor.Shape // the shapes of the values of the inputs, or of the input nodes if they have no values yet
+	InputDtypes []tensor.Dtype // the dtypes of the values of the inputs, or of the input nodes if they have no values yet
+	Instr       int            // the instruction (*tapeMachine), the position of the node in the sorted graph or of the differentiation instruction (*lispMachine). -1 when building the graph
+	Backward    bool           // true if the *lispMachine was backpropagating
+	Provenance  *Provenance    // where the node was created (or where ApplyOp was called). nil unless the graph was created WithProvenance
+	Err         error
+}
+
+// newExecError describes the inputs of a failed op. The values of the inputs are used if they are known, and the input nodes otherwise.
+func newExecError(n *Node, op Op, children Nodes, inputs []Value, instr int, backward bool, err error) *ExecError {
+	l := len(inputs)
+	if len(children) > l {
+		l = len(children)
+	}
+	retVal := &ExecError{
+		Node:        n,
+		Op:          op,
+		InputShapes: make([]tensor.Shape, l),
+		InputDtypes: make([]tensor.Dtype, l),
+		Instr:       instr,
+		Backward:    backward,
+		Err:         err,
+	}
+	for i := 0; i < l; i++ {
+		if i < len(inputs) && inputs[i] != nil {
+			retVal.InputShapes[i] = inputs[i].Shape()
+			retVal.InputDtypes[i] = inputs[i].Dtype()
+			continue
+		}
+		if i < len(children) {
+			retVal.InputShapes[i] = children[i].shape
+			retVal.InputDtypes[i], _ = dtypeOf(children[i].t)
+		}
+	}
+	if n != nil {
+		retVal.Provenance = n.provenance
+	}
+	return retVal
+}
+
+func (err *ExecError) Error() string {
+	var buf bytes.Buffer
+	switch {
+	case err.Node == nil:
+		fmt.Fprintf(&buf, "Failed to apply %v", err.Op)
+	case err.Backward:
+		fmt.Fprintf(&buf, "Failed to differentiate %v of %v", err.Op, err.Node)
+	default:
+		fmt.Fprintf(&buf, "Failed to execute %v of %v", err.Op, err.Node)
+	}
+	if err.Instr >= 0 {
+		fmt.Fprintf(&buf, " (instruction %d)", err.Instr)
+	}
+	fmt.Fprintf(&buf, " with inputs of shapes %v and dtypes %v", err.InputShapes, err.InputDtypes)
+	if err.Provenance != nil {
+		fmt.Fprintf(&buf, ", created at %v", err.Provenance)
+	}
+	fmt.Fprintf(&buf, ": %v", err.Err)
+	return buf.String()
+}
+
+// Unwrap returns the error of the op.
+func (err *ExecError) Unwrap() error { return err.Err }
+
 func nyi(what string, implFor interface{}) error {
 	return errors.Errorf(nyiFail, what, implFor)
 }
@@ -0,0 +1,57 @@
+package gorgonia
+
+import "github.com/pkg/errors"
+
+// Func is a function defined from a subgraph. It is compiled once, and may be called any number of times with Call().
+type Func struct {
+	body *ctrlBody
+}
+
+// DefineFunc defines a function from a subgraph: the inputs are the parameters of the function and the outputs are its results.
+// The subgraph should be built in a graph of its own, as its nodes are not shared with the graphs the function is called in.
+//
+// All the calls of the function share the same compiled instructions. Likewise, the gradient of the function is derived only once,
+// when a call of the function is first differentiated. This keeps repeated blocks (residual units, for example) from bloating the graph.
+//
+// DefineFunc returns an error if any of the inputs is not an input node, or if the inputs and outputs are not all in the same graph.
+func DefineFunc(inputs, outputs Nodes) (*Func, error) {
+	if len(outputs) == 0 {
+		return nil, errors.New("DefineFunc requires at least one output")
+	}
+
+	for i, n := range inputs {
+		if !n.isInput() {
+			return nil, errors.Errorf("Can only define a function with input nodes as inputs. %dth Node %v isn't an input", i, n)
+		}
+	}
+
+	all := append(append(Nodes{}, inputs...), outputs...)
+	if !all.AllSameGraph() {
+		return nil, errors.New("The inputs and outputs of a function must all be in the same graph")
+	}
+
+	g := outputs[0].g
+	body, err := makeCtrlBody(g.name, g, inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	return &Func{body: body}, nil
+}
+
+// Call calls the function with the given arguments, and returns the nodes holding the results.
+// The arguments must have the same types and shapes as the inputs of the function.
+func Call(f *Func, args ...*Node) (retVal Nodes, err error) {
+	if len(args) != len(f.body.inputs) {
+		return nil, errors.Errorf("Expected %d arguments. Got %d instead", len(f.body.inputs), len(args))
+	}
+
+	for i, a := range args {
+		in := f.body.inputs[i]
+		if !a.t.Eq(in.t) || !a.Shape().Eq(in.Shape()) {
+			return nil, errors.Errorf("Expected argument %d to be %v of shape %v. Got %v of shape %v instead", i, in.t, in.Shape(), a.t, a.Shape())
+		}
+	}
+
+	def := newCtrlFlowDef(callKind, []*ctrlBody{f.body}, args, f.body.outputs)
+	return def.apply(args)
+}
@@ -0,0 +1,109 @@
+package gorgonia
+
+import (
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+// residual unit: x + tanh(W×x)
+func residualUnit(x, w *Node) (*Node, error) {
+	wx, err := Mul(w, x)
+	if err != nil {
+		return nil, err
+	}
+	th, err := Tanh(wx)
+	if err != nil {
+		return nil, err
+	}
+	return Add(x, th)
+}
+
+func TestCall(t *testing.T) {
+	xBack := []float64{0.5, -1, 2}
+	w1Back := []float64{0.1, 0.2, 0.3, -0.4, 0.5, 0.6, 0.7, -0.8, 0.9}
+	w2Back := []float64{-0.3, 0.1, 0.2, 0.5, -0.5, 0.1, 0.2, 0.4, -0.6}
+
+	// the function
+	fg := NewGraph()
+	fx := NewVector(fg, Float64, WithName("x"), WithShape(3))
+	fw := NewMatrix(fg, Float64, WithName("W"), WithShape(3, 3))
+	fy, err := residualUnit(fx, fw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := DefineFunc(Nodes{fx, fw}, Nodes{fy})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// called twice
+	g := NewGraph()
+	x := NewVector(g, Float64, WithName("x"), WithShape(3), WithValue(tensor.New(tensor.WithBacking(xBack))))
+	w1 := NewMatrix(g, Float64, WithName("W1"), WithShape(3, 3), WithValue(tensor.New(tensor.WithShape(3, 3), tensor.WithBacking(w1Back))))
+	w2 := NewMatrix(g, Float64, WithName("W2"), WithShape(3, 3), WithValue(tensor.New(tensor.WithShape(3, 3), tensor.WithBacking(w2Back))))
+
+	y1, err := Call(f, x, w1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y2, err := Call(f, y1[0], w2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost := Must(Sum(y2[0]))
+	grads, err := Grad(cost, x, w1, w2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g)
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// inlined
+	g2 := NewGraph()
+	x2 := NewVector(g2, Float64, WithName("x"), WithShape(3), WithValue(tensor.New(tensor.WithBacking(xBack))))
+	w12 := NewMatrix(g2, Float64, WithName("W1"), WithShape(3, 3), WithValue(tensor.New(tensor.WithShape(3, 3), tensor.WithBacking(w1Back))))
+	w22 := NewMatrix(g2, Float64, WithName("W2"), WithShape(3, 3), WithValue(tensor.New(tensor.WithShape(3, 3), tensor.WithBacking(w2Back))))
+	h := Must(residualUnit(x2, w12))
+	h = Must(residualUnit(h, w22))
+	cost2 := Must(Sum(h))
+	grads2, err := Grad(cost2, x2, w12, w22)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m2 := NewTapeMachine(g2)
+	if err = m2.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !closeF64(extractF64(cost2.Value()), extractF64(cost.Value())) {
+		t.Errorf("Expected cost to be %v. Got %v instead", cost2.Value(), cost.Value())
+	}
+	for i := range grads {
+		if !floatsEqual64(extractF64s(grads2[i].Value()), extractF64s(grads[i].Value())) {
+			t.Errorf("Expected gradient %d to be %v. Got %v instead", i, grads2[i].Value(), grads[i].Value())
+		}
+	}
+
+	// both calls share the same body
+	if y1[0].op.(*ctrlFlowOp).bodies[0] != y2[0].op.(*ctrlFlowOp).bodies[0] {
+		t.Error("Expected the calls to share the body of the function")
+	}
+
+	if _, err = Call(f, x); err == nil {
+		t.Error("Expected an error when calling with the wrong number of arguments")
+	}
+	if _, err = Call(f, w1, x); err == nil {
+		t.Error("Expected an error when calling with the wrong types of arguments")
+	}
+	if _, err = DefineFunc(Nodes{fy}, Nodes{fy}); err == nil {
+		t.Error("Expected an error when defining a function with inputs that are not input nodes")
+	}
+	if _, err = DefineFunc(Nodes{fx}, Nodes{cost}); err == nil {
+		t.Error("Expected an error when defining a function across graphs")
+	}
+}
@@ -226,6 +226,42 @@ func Grad(cost *Node, WRTs ...*Node) (retVal Nodes, err error) {
 	return Backpropagate(Nodes{cost}, Nodes{gradOut}, Nodes(WRTs))
 }
 
+// JVP takes a list of outputs, a list of with-regards-to and a seed tangent for each of the with-regards-to, and returns the tangents of the outputs
+// (the Jacobian-vector products). The seeds are typically input nodes, so that their values can be set with Let() before running the graph.
+//
+// Unlike Grad(), which is done in reverse mode, JVP() differentiates in forward mode. This is much cheaper when there are few inputs and many outputs.
+// See ForwardPropagate() for how to read the values of the tangents after running the graph.
+func JVP(outputs, WRTs, seeds Nodes) (retVal Nodes, err error) {
+	if len(outputs) == 0 {
+		return nil, errors.New("Expected at least one output")
+	}
+
+	if len(WRTs) != len(seeds) {
+		return nil, errors.Errorf("Expected a seed for each of the %d WRTs. Got %d seeds instead", len(WRTs), len(seeds))
+	}
+
+	for i, n := range WRTs {
+		if !n.isInput() {
+			err = errors.Errorf("Can only differentiate with regards to input nodes. %dth Node %v isn't an input", i, n)
+			return nil, err
+		}
+
+		if !n.t.Eq(seeds[i].t) || !n.Shape().Eq(seeds[i].Shape()) {
+			return nil, errors.Errorf("Expected the %dth seed to have the type %v and shape %v. Got %v and %v instead", i, n.t, n.Shape(), seeds[i].t, seeds[i].Shape())
+		}
+	}
+
+	all := make(Nodes, 0, len(outputs)+len(WRTs)+len(seeds))
+	all = append(all, outputs...)
+	all = append(all, WRTs...)
+	all = append(all, seeds...)
+	if !all.AllSameGraph() {
+		return nil, errors.New("The supplied outputs, WRTs and seeds are not all in the same graph")
+	}
+
+	return ForwardPropagate(WRTs, seeds, outputs)
+}
+
 // Let binds a Value to a node that is a variable. A variable is represented as a *Node with no Op.
 // It is equivalent to :
 //		x = 2
@@ -0,0 +1,140 @@
+package gorgonia
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+// GradStat holds the statistics of the gradient of a node.
+type GradStat struct {
+	Node   *Node
+	Norm   float64 // L2 norm
+	MaxAbs float64 // largest absolute value, ignoring NaNs
+	NaNs   int     // number of NaNs
+	Infs   int     // number of infinities
+}
+
+func (s GradStat) String() string {
+	return fmt.Sprintf("%v: norm %v, max abs %v, NaNs %d, Infs %d", s.Node, s.Norm, s.MaxAbs, s.NaNs, s.Infs)
+}
+
+// GradStats computes the statistics of the gradients of the nodes in the model. The gradients are read from the *dualValues bound to the nodes,
+// so it should be called after the VM has been run, but before the solver steps (which clears the gradients).
+func GradStats(model Nodes) (retVal []GradStat, err error) {
+	retVal = make([]GradStat, len(model))
+	for i, n := range model {
+		stat := GradStat{Node: n}
+		var sumSq float64
+		err = eachGrad(n, func(g float64) {
+			switch {
+			case math.IsNaN(g):
+				stat.NaNs++
+				return
+			case math.IsInf(g, 0):
+				stat.Infs++
+			}
+			sumSq += g * g
+			stat.MaxAbs = math.Max(stat.MaxAbs, math.Abs(g))
+		})
+		if err != nil {
+			return nil, err
+		}
+		stat.Norm = math.Sqrt(sumSq)
+		if stat.NaNs > 0 {
+			stat.Norm = math.NaN()
+		}
+		retVal[i] = stat
+	}
+	return
+}
+
+// ClipGradsByGlobalNorm scales the gradients of all the nodes in the model so that the L2 norm of all the gradients taken together is at most
+// maxNorm. Unlike WithClip, which clips each element independently, this keeps the direction of the gradients.
+// It returns the global norm of the gradients before clipping. If the norm is NaN or infinite the gradients cannot be scaled, so they are
+// left untouched and an error is returned along with the norm.
+func ClipGradsByGlobalNorm(model Nodes, maxNorm float64) (norm float64, err error) {
+	var sumSq float64
+	for _, n := range model {
+		if err = eachGrad(n, func(g float64) { sumSq += g * g }); err != nil {
+			return
+		}
+	}
+	norm = math.Sqrt(sumSq)
+
+	if math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return norm, errors.Errorf("Cannot clip the gradients by their global norm: the norm is %v", norm)
+	}
+	if norm <= maxNorm {
+		return
+	}
+
+	scale := maxNorm / norm
+	for _, n := range model {
+		dv := n.boundTo.(*dualValue)
+		switch d := dv.d.(type) {
+		case *tensor.Dense:
+			var s interface{}
+			switch d.Dtype() {
+			case tensor.Float64:
+				s = scale
+			case tensor.Float32:
+				s = float32(scale)
+			}
+			if _, err = tensor.Mul(d, s, tensor.UseUnsafe()); err != nil {
+				return norm, errors.Wrap(err, pointWiseMulFail)
+			}
+		case *RowSparse:
+			d.scale(scale)
+		case *F64:
+			dv.d, _ = anyToScalar(d.any() * scale)
+		case *F32:
+			dv.d, _ = anyToScalar(d.any() * float32(scale))
+		}
+	}
+	return
+}
+
+// eachGrad calls fn on every element of the gradient of n
+func eachGrad(n *Node, fn func(g float64)) error {
+	dv, ok := n.boundTo.(*dualValue)
+	if !ok {
+		return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+	}
+
+	switch d := dv.d.(type) {
+	case *tensor.Dense:
+		switch data := d.Data().(type) {
+		case []float64:
+			for _, g := range data {
+				fn(g)
+			}
+		case []float32:
+			for _, g := range data {
+				fn(float64(g))
+			}
+		default:
+			return errors.Errorf(nyiFail, "gradient statistics", d.Dtype())
+		}
+	case *RowSparse:
+		switch data := d.Data().(type) {
+		case []float64:
+			for _, g := range data {
+				fn(g)
+			}
+		case []float32:
+			for _, g := range data {
+				fn(float64(g))
+			}
+		}
+	case *F64:
+		fn(d.any())
+	case *F32:
+		fn(float64(d.any()))
+	default:
+		return errors.Errorf(nyiTypeFail, "gradient statistics", dv.d)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package gorgonia
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func gradNodes() Nodes {
+	a := new(Node)
+	dva := dvUnit0(tensor.New(tensor.WithShape(2), tensor.WithBacking([]float64{1, 1})))
+	dva.d = tensor.New(tensor.WithShape(2), tensor.WithBacking([]float64{3, -4}))
+	a.boundTo = dva
+
+	b := new(Node)
+	dvb := dvUnit0(newF32(1))
+	dvb.d = newF32(12)
+	b.boundTo = dvb
+	return Nodes{a, b}
+}
+
+func TestClipGradsByGlobalNorm(t *testing.T) {
+	model := gradNodes()
+
+	// global norm: √(9 + 16 + 144) = 13
+	norm, err := ClipGradsByGlobalNorm(model, 26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if norm != 13 {
+		t.Errorf("Expected the global norm to be 13. Got %v instead", norm)
+	}
+	if got := model[0].boundTo.(*dualValue).d.Data().([]float64); !floatsEqual64([]float64{3, -4}, got) {
+		t.Errorf("Expected the gradients to be untouched. Got %v", got)
+	}
+
+	if _, err = ClipGradsByGlobalNorm(model, 6.5); err != nil {
+		t.Fatal(err)
+	}
+	if got := model[0].boundTo.(*dualValue).d.Data().([]float64); !floatsEqual64([]float64{1.5, -2}, got) {
+		t.Errorf("Expected the gradients to be halved. Got %v", got)
+	}
+	if got := model[1].boundTo.(*dualValue).d.Data().(float32); got != 6 {
+		t.Errorf("Expected the gradients to be halved. Got %v", got)
+	}
+
+	// solver option
+	model = gradNodes()
+	s := NewVanillaSolver(WithLearnRate(1), WithGlobalNormClip(1.3))
+	if err = s.Step(model); err != nil {
+		t.Fatal(err)
+	}
+	if got := model[0].Value().Data().([]float64); !floatsEqual64([]float64{0.7, 1.4}, got) {
+		t.Errorf("Expected the step to use the clipped gradients. Got %v", got)
+	}
+
+	// non-finite norms
+	for _, g := range []float64{math.Inf(1), math.NaN()} {
+		model = gradNodes()
+		model[0].boundTo.(*dualValue).d.Data().([]float64)[0] = g
+		if norm, err = ClipGradsByGlobalNorm(model, 1); err == nil {
+			t.Errorf("Expected an error clipping gradients with a norm of %v", norm)
+		}
+		if got := model[1].boundTo.(*dualValue).d.Data().(float32); got != 12 {
+			t.Errorf("Expected the gradients to be untouched. Got %v", got)
+		}
+
+		s = NewVanillaSolver(WithLearnRate(1), WithGlobalNormClip(1.3))
+		if err = s.Step(model); err == nil {
+			t.Errorf("Expected the solver to return an error when the global norm is %v", g)
+		}
+		if got := model[0].Value().Data().([]float64); !floatsEqual64([]float64{1, 1}, got) {
+			t.Errorf("Expected the model to be untouched. Got %v", got)
+		}
+	}
+}
+
+func TestGradStats(t *testing.T) {
+	model := gradNodes()
+	model[0].boundTo.(*dualValue).d = tensor.New(tensor.WithShape(4), tensor.WithBacking([]float64{3, -4, math.NaN(), math.Inf(-1)}))
+
+	stats, err := GradStats(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := stats[0]; s.NaNs != 1 || s.Infs != 1 || !math.IsNaN(s.Norm) || !math.IsInf(s.MaxAbs, 1) {
+		t.Errorf("Unexpected statistics %v", s)
+	}
+	if s := stats[1]; s.Norm != 12 || s.MaxAbs != 12 || s.NaNs != 0 || s.Infs != 0 {
+		t.Errorf("Unexpected statistics %v", s)
+	}
+
+	model = Nodes{new(Node)}
+	if _, err = GradStats(model); err == nil {
+		t.Error("Expected an error for a node without a gradient")
+	}
+}
@@ -3,6 +3,7 @@ package gorgonia
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
 
 	"github.com/awalterschulze/gographviz"
 	"github.com/gonum/graph"
@@ -23,6 +24,11 @@ type ExprGraph struct {
 	constants Nodes
 	roots     Nodes
 	counter   uint
+
+	rng *rand.Rand // used by WithRandInit
+
+	provenance      bool // record where the nodes are created
+	provenanceStack bool // and the stacks
 }
 
 type graphconopt func(g *ExprGraph)
@@ -35,6 +41,27 @@ func WithGraphName(name string) graphconopt {
 	return f
 }
 
+// WithRNG is a ExprGraph construction option that provides the source of random numbers for the initialization of the values of the nodes with
+// WithRandInit. Initializing a model in a graph with a seeded RNG gives the same values every time, without having to call rand.Seed.
+// The RNG is only used while the nodes are created, so it must not be shared with graphs that are built concurrently.
+func WithRNG(r *rand.Rand) graphconopt {
+	f := func(g *ExprGraph) {
+		g.rng = r
+	}
+	return f
+}
+
+// WithProvenance is a ExprGraph construction option that records where every node of the graph is created: the file and line of the
+// first caller outside of this package, and, if stack is true, the whole stack. The errors returned when executing the nodes then say
+// where the nodes were created - see ExecError. Recording the stacks makes building graphs slower.
+func WithProvenance(stack bool) graphconopt {
+	f := func(g *ExprGraph) {
+		g.provenance = true
+		g.provenanceStack = stack
+	}
+	return f
+}
+
 // NewGraph creates a new graph. Duh
 func NewGraph(opts ...graphconopt) *ExprGraph {
 	g := &ExprGraph{
@@ -57,6 +84,8 @@ func NewGraph(opts ...graphconopt) *ExprGraph {
 func (g *ExprGraph) Clone() interface{} {
 	g2 := new(ExprGraph)
 	g2.name = g.name
+	g2.provenance = g.provenance
+	g2.provenanceStack = g.provenanceStack
 
 	mapping := make(map[*Node]*Node) // a map of old nodes to new nodes
 	g2.all = make(Nodes, len(g.all))
@@ -178,6 +207,9 @@ func (g *ExprGraph) addToAll(n *Node) {
 	if n == nil {
 		panic("HELP! trying to add nil")
 	}
+	if g.provenance && n.provenance == nil {
+		n.provenance = newProvenance(g.provenanceStack)
+	}
 	g.all = append(g.all, n)
 	n.id = int(g.counter)
 	g.counter++
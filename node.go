@@ -7,6 +7,7 @@ import (
 	"hash"
 	"hash/fnv"
 	"log"
+	"math/rand"
 
 	"github.com/awalterschulze/gographviz"
 	"github.com/chewxy/gorgonia/tensor"
@@ -29,7 +30,8 @@ type Node struct {
 	name  string
 	group string
 
-	g *ExprGraph // this node belongs in this graph
+	g          *ExprGraph  // this node belongs in this graph
+	provenance *Provenance // where the node was created, if the graph records it
 
 	// value bondage
 	// inputs are bound to values directly
@@ -160,6 +162,27 @@ func WithInit(fn InitWFn) NodeConsOpt {
 	return f
 }
 
+// WithRandInit is a node construction option to initialize a *Node with the RandInitWFn provided. The random numbers are drawn from
+// the RNG of the graph if it was created WithRNG.
+func WithRandInit(fn RandInitWFn) NodeConsOpt {
+	f := func(n *Node) {
+		dt, err := dtypeOf(n.t)
+		if err != nil {
+			panic(err)
+		}
+
+		var r *rand.Rand
+		if n.g != nil {
+			r = n.g.rng
+		}
+
+		var v Value
+		v = tensor.New(tensor.WithShape(n.shape...), tensor.WithBacking(fn(r, dt, n.shape...)))
+		WithValue(v)(n)
+	}
+	return f
+}
+
 // WithShape is a node construction option to initialize a *Node with a particular shape.
 // This function panics if the shape's dimensions do not match the specified dimensions of the *Node.
 func WithShape(shp ...int) NodeConsOpt {
@@ -253,7 +276,7 @@ func (n *Node) IsVector() bool {
 func (n *Node) IsColVec() bool {
 	if _, ok := n.t.(TensorType); ok {
 		if n.shape != nil {
-			return n.shape.IsColVec()
+			return isColVec(n.shape)
 		}
 	}
 	return false
@@ -263,7 +286,7 @@ func (n *Node) IsColVec() bool {
 func (n *Node) IsRowVec() bool {
 	if _, ok := n.t.(TensorType); ok {
 		if n.shape != nil {
-			return n.shape.IsRowVec()
+			return isRowVec(n.shape)
 		}
 	}
 	return false
@@ -320,6 +343,7 @@ func (n *Node) Clone() (retVal interface{}) {
 	n2.group = n.group
 	n2.dataOn = n.dataOn
 	n2.hash = n.hash
+	n2.provenance = n.provenance
 
 	n2.hashed = n.hashed
 	n2.inferredShape = n.inferredShape
@@ -329,6 +353,9 @@ func (n *Node) Clone() (retVal interface{}) {
 	return n2
 }
 
+// Provenance returns where the node was created. It returns nil unless the graph was created WithProvenance.
+func (n *Node) Provenance() *Provenance { return n.provenance }
+
 // Value returns the valuse bound to the node. May return nil
 func (n *Node) Value() Value {
 	if n.isConstant() {
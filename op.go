@@ -128,6 +128,15 @@ type SDOp interface {
 	SymDiff(inputs Nodes, output, grad *Node) (retVal Nodes, err error)
 }
 
+// A FwdDiffOp is an Op that supports forward-mode symbolic differentiation
+type FwdDiffOp interface {
+	Op
+
+	// FwdDiff symbolically computes the tangent of the output, given the tangents of the inputs.
+	// A nil tangent indicates that the input is not affected by the seeds, and is treated as zero.
+	FwdDiff(inputs, tangents Nodes, output *Node) (retVal *Node, err error)
+}
+
 // ReductionOp changes the shape of the node
 type ReductionOp interface {
 	Op
@@ -206,7 +215,11 @@ func ApplyOp(op Op, children ...*Node) (retVal *Node, err error) {
 		shapeLogf("inferred shape %v", s)
 		retVal = NewUniqueNode(WithType(retType), WithOp(op), WithChildren(children), In(g), WithShape(s...))
 	} else {
-		err = errors.Wrapf(err, "Failed to infer shape. Op: %v", op)
+		ee := newExecError(nil, op, children, nil, -1, false, errors.Wrap(err, "Failed to infer shape"))
+		if g.provenance {
+			ee.Provenance = newProvenance(g.provenanceStack)
+		}
+		err = ee
 		// retVal = newUniqueNode(withType(retType), withOp(op), withChildren(children), withGraph(g))
 	}
 	returnDimSizers(ds)
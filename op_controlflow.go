@@ -0,0 +1,856 @@
+package gorgonia
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"sync"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/chewxy/hm"
+	"github.com/pkg/errors"
+)
+
+/*
+This file holds the ops for control flow: Cond, While and Scan, as well as calls of Funcs.
+
+The bodies of the control flow ops are subgraphs, each in its own *ExprGraph, and compiled for a *tapeMachine.
+Because an op may only return one Value, a control flow op with n results is represented by n nodes. The first node executes the
+control flow; the rest take the first node as an extra child, and simply return their result of that execution.
+
+The forwards execution stores the inputs of each call of a body. The backwards pass walks back through these states,
+calling the (symbolically differentiated) backwards subgraph of the body for each.
+
+The bodies are run by replicas of their compiled machines (see Replica), so a body may be run by several machines at once. Likewise,
+a *tapeMachine keeps the states of the control flow ops of its program, so the machines that share a program may run concurrently.
+*/
+
+// BodyFn builds the body of a control flow op. It is called once, when the control flow op is created, with placeholder nodes
+// that live in a new graph. The returned nodes must be in the same graph as the placeholders.
+type BodyFn func(args Nodes) (Nodes, error)
+
+type ctrlFlowKind byte
+
+const (
+	condKind ctrlFlowKind = iota
+	whileKind
+	scanKind
+	callKind
+)
+
+var ctrlFlowNames = [...]string{"Cond", "While", "Scan", "Call"}
+
+func (k ctrlFlowKind) String() string { return ctrlFlowNames[k] }
+
+// ctrlBody is a function body of a control flow op.
+type ctrlBody struct {
+	g       *ExprGraph
+	inputs  Nodes // placeholders
+	outputs Nodes
+	fwd     *bodyMachine
+
+	// backwards - lazily built
+	sync.Mutex
+	built     bool
+	gradOuts  Nodes // placeholders for the gradients of the outputs. nil if the output is not differentiable
+	grads     Nodes // gradients of the inputs. nil if the input is not differentiable
+	bwdInputs Nodes
+	bwd       *bodyMachine
+}
+
+func newCtrlBody(name string, args Nodes, fn BodyFn) (retVal *ctrlBody, err error) {
+	g := NewGraph(WithGraphName(name))
+	inputs := make(Nodes, len(args))
+	for i, a := range args {
+		inputs[i] = NewUniqueNode(WithType(a.t), WithShape(a.Shape()...), In(g), WithName(fmt.Sprintf("%s_arg%d", name, i)))
+	}
+
+	var outputs Nodes
+	if outputs, err = fn(inputs); err != nil {
+		return nil, errors.Wrapf(err, "Failed to build the body of %s", name)
+	}
+	return makeCtrlBody(name, g, inputs, outputs)
+}
+
+// makeCtrlBody creates a body from the inputs and outputs in g, and compiles it.
+func makeCtrlBody(name string, g *ExprGraph, inputs, outputs Nodes) (retVal *ctrlBody, err error) {
+	if len(outputs) == 0 {
+		return nil, errors.Errorf("The body of %s has no outputs", name)
+	}
+
+	for i, o := range outputs {
+		if o == nil || o.g != g {
+			return nil, errors.Errorf("Output %d of the body of %s is not in the body's graph", i, name)
+		}
+	}
+
+	var fwd *bodyMachine
+	if fwd, err = compileBody(g, inputs, outputs); err != nil {
+		return nil, errors.Wrapf(err, "Failed to compile the body of %s", name)
+	}
+
+	return &ctrlBody{
+		g:       g,
+		inputs:  inputs,
+		outputs: outputs,
+		fwd:     fwd,
+	}, nil
+}
+
+// bodyMachine runs a compiled body. Every run uses a replica of the compiled machine, so the body may be run by several machines at
+// once - all the calls of a Func share one body, for example.
+type bodyMachine struct {
+	m        *tapeMachine
+	replicas sync.Pool
+}
+
+// compileBody compiles the part of g that computes the outputs.
+func compileBody(g *ExprGraph, inputs, outputs Nodes) (retVal *bodyMachine, err error) {
+	sub := g.SubgraphRoots(outputs...)
+	var used Nodes
+	for _, in := range inputs {
+		if sub.all.Contains(in) {
+			used = append(used, in)
+		}
+	}
+
+	var prog *program
+	var locMap map[*Node]register
+	if prog, locMap, err = CompileFunction(g, used, outputs); err != nil {
+		return nil, err
+	}
+	return &bodyMachine{m: NewTapeMachine(g, WithPrecompiled(prog, locMap))}, nil
+}
+
+// run runs the body with copies of the values of the inputs, and returns copies of the values of the outputs. nil outputs are skipped.
+func (bm *bodyMachine) run(inputs Nodes, vals []Value, outputs Nodes) (retVal []Value, err error) {
+	r, _ := bm.replicas.Get().(*tapeMachine)
+	if r == nil {
+		if r, err = bm.m.Replica(); err != nil {
+			return nil, err
+		}
+	}
+	defer bm.replicas.Put(r)
+
+	feeds := make(map[*Node]Value, len(inputs))
+	for i, n := range inputs {
+		if feeds[n], err = CloneValue(vals[i]); err != nil {
+			return nil, errors.Wrap(err, cloneFail)
+		}
+	}
+
+	var fetches Nodes
+	for _, n := range outputs {
+		if n != nil {
+			fetches = append(fetches, n)
+		}
+	}
+
+	var fetched []Value
+	if fetched, err = r.Run(feeds, fetches...); err != nil {
+		return nil, err
+	}
+
+	retVal = make([]Value, len(outputs))
+	for i, n := range outputs {
+		if n != nil {
+			retVal[i], fetched = fetched[0], fetched[1:]
+		}
+	}
+	return
+}
+
+func (b *ctrlBody) call(vals []Value) ([]Value, error) {
+	return b.fwd.run(b.inputs, vals, b.outputs)
+}
+
+// buildBackward symbolically differentiates the body, and compiles the result.
+func (b *ctrlBody) buildBackward() (err error) {
+	b.Lock()
+	defer b.Unlock()
+	if b.built {
+		return nil
+	}
+
+	var sorted Nodes
+	if sorted, err = Sort(b.g); err != nil {
+		return errors.Wrap(err, sortFail)
+	}
+
+	var wrt Nodes
+	for _, in := range b.inputs {
+		if isFloatNode(in) {
+			wrt = append(wrt, in)
+		}
+	}
+
+	var affectedByInputs NodeSet
+	if affectedByInputs, err = backwardDiffAnalysis(wrt, sorted); err != nil {
+		return errors.Wrap(err, "Failed during backward differentiation analysis")
+	}
+
+	b.gradOuts = make(Nodes, len(b.outputs))
+	var outputs, gradOuts Nodes
+	for i, o := range b.outputs {
+		if !isFloatNode(o) || !affectedByInputs.Contains(o) {
+			continue
+		}
+		b.gradOuts[i] = NewUniqueNode(WithType(o.t), WithShape(o.Shape()...), In(b.g), WithName(fmt.Sprintf("%s_gradOut%d", b.g.name, i)))
+		outputs = append(outputs, o)
+		gradOuts = append(gradOuts, b.gradOuts[i])
+	}
+
+	var affectsOutputs NodeSet
+	if affectsOutputs, err = forwardDiffAnalysis(outputs, sorted); err != nil {
+		return errors.Wrap(err, "Failed during forward differentiation analysis")
+	}
+
+	b.grads = make(Nodes, len(b.inputs))
+	var diffWRT Nodes
+	for _, in := range wrt {
+		if affectsOutputs.Contains(in) {
+			diffWRT = append(diffWRT, in)
+		}
+	}
+
+	b.built = true
+	if len(outputs) == 0 || len(diffWRT) == 0 {
+		return nil
+	}
+
+	var grads Nodes
+	if grads, err = Backpropagate(outputs, gradOuts, diffWRT); err != nil {
+		return errors.Wrapf(err, "Failed to differentiate the body %s", b.g.name)
+	}
+
+	for i, in := range diffWRT {
+		b.grads[b.inputs.index(in)] = grads[i]
+	}
+
+	b.bwdInputs = append(append(Nodes{}, b.inputs...), gradOuts...)
+	if b.bwd, err = compileBody(b.g, b.bwdInputs, grads); err != nil {
+		return errors.Wrapf(err, "Failed to compile the backwards of %s", b.g.name)
+	}
+	return nil
+}
+
+// vjp computes the gradients of the inputs, given the values of the inputs and the gradients of the outputs.
+// The inputs that are not differentiable have a gradient of zero.
+func (b *ctrlBody) vjp(vals, gradOuts []Value) (retVal []Value, err error) {
+	if err = b.buildBackward(); err != nil {
+		return
+	}
+
+	retVal = make([]Value, len(b.inputs))
+	if b.bwd != nil {
+		bwdVals := append([]Value{}, vals...)
+		for i, g := range b.gradOuts {
+			if g != nil {
+				bwdVals = append(bwdVals, gradOuts[i])
+			}
+		}
+
+		if retVal, err = b.bwd.run(b.bwdInputs, bwdVals, b.grads); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, g := range retVal {
+		if g == nil {
+			if retVal[i], err = zeroLike(vals[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return
+}
+
+// ctrlFlowDef is the definition of a control flow construct, shared by all the nodes representing its results.
+type ctrlFlowDef struct {
+	kind   ctrlFlowKind
+	bodies []*ctrlBody // Cond: then, else. While: cond, body. Scan, Call: body
+
+	argTypes  []hm.Type
+	argShapes []tensor.Shape
+	diffs     []bool // which of the arguments are differentiable
+	retTypes  []hm.Type
+	retShapes []tensor.Shape
+
+	state *ctrlFlowState // the state of the executions by a *lispMachine, or by calling the ops directly
+}
+
+// ctrlFlowState is the state of the last execution of a control flow construct, used for the backwards pass.
+type ctrlFlowState struct {
+	states  [][]Value // the inputs of each call of the body
+	branch  int
+	xsShape tensor.Shape
+	results []Value
+	grads   [][]Value // gradients of the arguments, by result
+}
+
+func newCtrlFlowState(def *ctrlFlowDef) *ctrlFlowState {
+	return &ctrlFlowState{grads: make([][]Value, len(def.retTypes))}
+}
+
+// ctrlFlowExecer is an op of a control flow construct, executed with the state of the machine that executes it.
+type ctrlFlowExecer interface {
+	Op
+	definition() *ctrlFlowDef
+	doState(st *ctrlFlowState, vals ...Value) (Value, error)
+}
+
+// newCtrlFlowStates creates the states of the control flow constructs executed by the program.
+func newCtrlFlowStates(p *program) map[*ctrlFlowDef]*ctrlFlowState {
+	var retVal map[*ctrlFlowDef]*ctrlFlowState
+	for _, instr := range p.instructions {
+		op, ok := instr.(*execOp)
+		if !ok {
+			continue
+		}
+		cf, ok := op.op.(ctrlFlowExecer)
+		if !ok {
+			continue
+		}
+		if retVal == nil {
+			retVal = make(map[*ctrlFlowDef]*ctrlFlowState)
+		}
+		if def := cf.definition(); retVal[def] == nil {
+			retVal[def] = newCtrlFlowState(def)
+		}
+	}
+	return retVal
+}
+
+func newCtrlFlowDef(kind ctrlFlowKind, bodies []*ctrlBody, args Nodes, rets Nodes) *ctrlFlowDef {
+	def := &ctrlFlowDef{
+		kind:   kind,
+		bodies: bodies,
+	}
+
+	for i, a := range args {
+		def.argTypes = append(def.argTypes, a.t)
+		def.argShapes = append(def.argShapes, a.Shape().Clone())
+		def.diffs = append(def.diffs, isFloatNode(a) && !(kind == condKind && i == 0))
+	}
+
+	for _, r := range rets {
+		def.retTypes = append(def.retTypes, r.t)
+		def.retShapes = append(def.retShapes, r.Shape().Clone())
+	}
+	def.state = newCtrlFlowState(def)
+	return def
+}
+
+func (def *ctrlFlowDef) definition() *ctrlFlowDef { return def }
+
+// apply creates the nodes representing the results.
+func (def *ctrlFlowDef) apply(args Nodes) (retVal Nodes, err error) {
+	for i := range def.retTypes {
+		children := args
+		if i > 0 {
+			children = append(append(Nodes{}, args...), retVal[0])
+		}
+
+		var n *Node
+		if n, err = ApplyOp(&ctrlFlowOp{def, i}, children...); err != nil {
+			return nil, errors.Wrap(err, applyOpFail)
+		}
+		retVal = append(retVal, n)
+	}
+	return
+}
+
+// differentiated returns the bodies that the backwards pass goes through.
+func (def *ctrlFlowDef) differentiated() []*ctrlBody {
+	if def.kind == whileKind {
+		return def.bodies[1:]
+	}
+	return def.bodies
+}
+
+func (def *ctrlFlowDef) forward(st *ctrlFlowState, vals []Value) (err error) {
+	var args []Value
+	if args, err = cloneValues(vals); err != nil {
+		return
+	}
+
+	switch def.kind {
+	case condKind:
+		var pred bool
+		if pred, err = truthy(args[0]); err != nil {
+			return
+		}
+
+		st.branch = 1
+		if pred {
+			st.branch = 0
+		}
+
+		st.states = [][]Value{args[1:]}
+		st.results, err = def.bodies[st.branch].call(args[1:])
+	case whileKind:
+		st.states = st.states[:0]
+		state := args
+		for {
+			var c []Value
+			if c, err = def.bodies[0].call(state); err != nil {
+				return
+			}
+
+			var ok bool
+			if ok, err = truthy(c[0]); err != nil || !ok {
+				break
+			}
+
+			st.states = append(st.states, state)
+			if state, err = def.bodies[1].call(state); err != nil {
+				return
+			}
+		}
+		st.results = state
+	case scanKind:
+		st.states = st.states[:0]
+		carry, xs, operands := args[0], args[1], args[2:]
+		if T, ok := xs.(tensor.Tensor); ok {
+			xs = materialized(T)
+		}
+		st.xsShape = xs.Shape().Clone()
+
+		cs := append(tensor.Shape{st.xsShape[0]}, carry.Shape()...)
+		carries := tensor.New(tensor.Of(carry.Dtype()), tensor.WithShape(cs...))
+		for t := 0; t < st.xsShape[0]; t++ {
+			var x Value
+			if x, err = scanSlice(xs, t); err != nil {
+				return
+			}
+
+			state := append([]Value{carry, x}, operands...)
+			st.states = append(st.states, state)
+
+			var c []Value
+			if c, err = def.bodies[0].call(state); err != nil {
+				return
+			}
+			carry = c[0]
+			if err = scanSet(carries, t, carry); err != nil {
+				return
+			}
+		}
+		st.results = []Value{carry, carries}
+	case callKind:
+		st.states = [][]Value{args}
+		st.results, err = def.bodies[0].call(args)
+	}
+	return
+}
+
+// backward computes the gradients of the arguments given the gradient of the out-th result.
+func (def *ctrlFlowDef) backward(st *ctrlFlowState, out int, grad Value) (retVal []Value, err error) {
+	gradOuts := make([]Value, len(st.results))
+	for i, r := range st.results {
+		if i == out {
+			gradOuts[i] = grad
+			continue
+		}
+		if gradOuts[i], err = zeroLike(r); err != nil {
+			return
+		}
+	}
+
+	switch def.kind {
+	case condKind:
+		var grads []Value
+		if grads, err = def.bodies[st.branch].vjp(st.states[0], gradOuts); err != nil {
+			return
+		}
+		retVal = append([]Value{nil}, grads...)
+	case whileKind:
+		retVal = gradOuts
+		for t := len(st.states) - 1; t >= 0; t-- {
+			if retVal, err = def.bodies[1].vjp(st.states[t], retVal); err != nil {
+				return
+			}
+		}
+	case scanKind:
+		var dt tensor.Dtype
+		if dt, err = dtypeOf(def.argTypes[1]); err != nil {
+			return nil, errors.Wrap(err, dtypeOfFail)
+		}
+
+		// the gradient of the carry after step t is the gradient of the t-th stacked carry, plus the gradient through step t+1
+		gradCarry := gradOuts[0]
+		gradXs := tensor.New(tensor.Of(dt), tensor.WithShape(st.xsShape...))
+		gradOps := make([]Value, len(def.argTypes)-2)
+		for t := len(st.states) - 1; t >= 0; t-- {
+			if out == 1 {
+				var g Value
+				if g, err = scanSlice(grad, t); err != nil {
+					return
+				}
+				if gradCarry, err = addValues(gradCarry, g); err != nil {
+					return
+				}
+			}
+
+			var grads []Value
+			if grads, err = def.bodies[0].vjp(st.states[t], []Value{gradCarry}); err != nil {
+				return
+			}
+			gradCarry = grads[0]
+			if err = scanSet(gradXs, t, grads[1]); err != nil {
+				return
+			}
+			for i, g := range grads[2:] {
+				if gradOps[i] == nil {
+					gradOps[i] = g
+				} else if gradOps[i], err = addValues(gradOps[i], g); err != nil {
+					return
+				}
+			}
+		}
+		retVal = append([]Value{gradCarry, gradXs}, gradOps...)
+	case callKind:
+		retVal, err = def.bodies[0].vjp(st.states[0], gradOuts)
+	}
+	return
+}
+
+// ctrlFlowOp represents the out-th result of a control flow construct.
+type ctrlFlowOp struct {
+	*ctrlFlowDef
+	out int
+}
+
+func (op *ctrlFlowOp) Arity() int {
+	if op.out > 0 {
+		return len(op.argTypes) + 1
+	}
+	return len(op.argTypes)
+}
+
+// The type of a control flow op is the types of its arguments, followed by the type of the first result (for all but the first result)
+//		ctrlFlowOp :: a → b → ... → r
+func (op *ctrlFlowOp) Type() hm.Type {
+	ts := append([]hm.Type{}, op.argTypes...)
+	if op.out > 0 {
+		ts = append(ts, op.retTypes[0])
+	}
+	ts = append(ts, op.retTypes[op.out])
+	return hm.NewFnType(ts...)
+}
+
+func (op *ctrlFlowOp) InferShape(...DimSizer) (tensor.Shape, error) {
+	return op.retShapes[op.out].Clone(), nil
+}
+
+func (op *ctrlFlowOp) Do(vals ...Value) (Value, error) { return op.doState(op.state, vals...) }
+
+func (op *ctrlFlowOp) doState(st *ctrlFlowState, vals ...Value) (retVal Value, err error) {
+	if err = checkArity(op, len(vals)); err != nil {
+		return
+	}
+
+	if op.out == 0 {
+		if err = op.forward(st, vals); err != nil {
+			return nil, errors.Wrapf(err, "Failed to execute %v", op.kind)
+		}
+	}
+	return st.results[op.out], nil
+}
+
+func (op *ctrlFlowOp) ReturnsPtr() bool     { return false }
+func (op *ctrlFlowOp) CallsExtern() bool    { return false }
+func (op *ctrlFlowOp) OverwritesInput() int { return -1 }
+
+func (op *ctrlFlowOp) WriteHash(h hash.Hash) {
+	fmt.Fprintf(h, "%v %p %d", op.kind, op.ctrlFlowDef, op.out)
+}
+
+func (op *ctrlFlowOp) Hashcode() uint32 {
+	h := fnv.New32a()
+	op.WriteHash(h)
+	return h.Sum32()
+}
+
+func (op *ctrlFlowOp) String() string { return fmt.Sprintf("%v[%d]", op.kind, op.out) }
+
+func (op *ctrlFlowOp) DiffWRT(inputs int) []bool {
+	retVal := make([]bool, inputs)
+	copy(retVal, op.diffs)
+	return retVal
+}
+
+func (op *ctrlFlowOp) SymDiff(inputs Nodes, output, grad *Node) (retVal Nodes, err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+
+	for _, b := range op.differentiated() {
+		if err = b.buildBackward(); err != nil {
+			return
+		}
+	}
+
+	args := inputs[:len(op.argTypes)]
+	retVal = make(Nodes, len(inputs))
+
+	// the gradients of all the arguments are computed by the first gradient node.
+	first := -1
+	for i := range args {
+		if !op.diffs[i] {
+			continue
+		}
+
+		children := append(append(Nodes{}, args...), output, grad)
+		if first < 0 {
+			first = i
+		} else {
+			children = append(children, retVal[first])
+		}
+
+		gop := &ctrlFlowGradOp{ctrlFlowDef: op.ctrlFlowDef, out: op.out, wrt: i, first: first}
+		if retVal[i], err = ApplyOp(gop, children...); err != nil {
+			return nil, errors.Wrap(err, applyOpFail)
+		}
+		retVal[i].setGroup(gradClust)
+	}
+	return
+}
+
+func (op *ctrlFlowOp) DoDiff(ctx ExecutionContext, inputs Nodes, output *Node) (err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+
+	var grad Value
+	if grad, err = output.Grad(); err != nil {
+		return
+	}
+
+	var grads []Value
+	if grads, err = op.backward(op.state, op.out, grad); err != nil {
+		return errors.Wrapf(err, autodiffFail, op)
+	}
+
+	for i, in := range inputs[:len(op.argTypes)] {
+		if !op.diffs[i] {
+			continue
+		}
+
+		dv := in.boundTo.(*dualValue)
+		add := newEBOByType(addOpType, TypeOf(dv.d), TypeOf(grads[i]))
+		var d Value
+		if d, err = add.UnsafeDo(dv.d, grads[i]); err != nil {
+			return errors.Wrapf(err, unsafeDoFail, add)
+		}
+		dv.SetDeriv(d) // ignore errors on purpose
+	}
+	return nil
+}
+
+// ctrlFlowGradOp computes the gradient of the wrt-th argument of a control flow construct, given the gradient of the out-th result.
+// The children are the arguments, the result, its gradient and, if wrt is not first, the node of the first gradient.
+type ctrlFlowGradOp struct {
+	*ctrlFlowDef
+	out, wrt, first int
+}
+
+func (op *ctrlFlowGradOp) Arity() int {
+	if op.wrt != op.first {
+		return len(op.argTypes) + 3
+	}
+	return len(op.argTypes) + 2
+}
+
+func (op *ctrlFlowGradOp) Type() hm.Type {
+	ts := append([]hm.Type{}, op.argTypes...)
+	ts = append(ts, op.retTypes[op.out], op.retTypes[op.out])
+	if op.wrt != op.first {
+		ts = append(ts, op.argTypes[op.first])
+	}
+	ts = append(ts, op.argTypes[op.wrt])
+	return hm.NewFnType(ts...)
+}
+
+func (op *ctrlFlowGradOp) InferShape(...DimSizer) (tensor.Shape, error) {
+	return op.argShapes[op.wrt].Clone(), nil
+}
+
+func (op *ctrlFlowGradOp) Do(vals ...Value) (Value, error) { return op.doState(op.state, vals...) }
+
+func (op *ctrlFlowGradOp) doState(st *ctrlFlowState, vals ...Value) (retVal Value, err error) {
+	if err = checkArity(op, len(vals)); err != nil {
+		return
+	}
+
+	if op.wrt == op.first {
+		grad := vals[len(op.argTypes)+1]
+		if st.grads[op.out], err = op.backward(st, op.out, grad); err != nil {
+			return nil, errors.Wrapf(err, autodiffFail, op)
+		}
+	}
+	return st.grads[op.out][op.wrt], nil
+}
+
+func (op *ctrlFlowGradOp) ReturnsPtr() bool     { return false }
+func (op *ctrlFlowGradOp) CallsExtern() bool    { return false }
+func (op *ctrlFlowGradOp) OverwritesInput() int { return -1 }
+
+func (op *ctrlFlowGradOp) WriteHash(h hash.Hash) {
+	fmt.Fprintf(h, "∂%v %p %d %d", op.kind, op.ctrlFlowDef, op.out, op.wrt)
+}
+
+func (op *ctrlFlowGradOp) Hashcode() uint32 {
+	h := fnv.New32a()
+	op.WriteHash(h)
+	return h.Sum32()
+}
+
+func (op *ctrlFlowGradOp) String() string {
+	return fmt.Sprintf("∂%v[%d]/∂%d", op.kind, op.out, op.wrt)
+}
+
+/* UTILITY FUNCTIONS */
+
+// checkBodyOutputs checks that the outputs of a body have the expected types and shapes.
+func checkBodyOutputs(name string, outputs, expected Nodes) error {
+	if len(outputs) != len(expected) {
+		return errors.Errorf("Expected the body of %s to return %d results. Got %d instead", name, len(expected), len(outputs))
+	}
+
+	for i, o := range outputs {
+		if !o.t.Eq(expected[i].t) || !o.Shape().Eq(expected[i].Shape()) {
+			return errors.Errorf("Expected result %d of the body of %s to be %v of shape %v. Got %v of shape %v instead", i, name, expected[i].t, expected[i].Shape(), o.t, o.Shape())
+		}
+	}
+	return nil
+}
+
+func isFloatNode(n *Node) bool {
+	dt, err := dtypeOf(n.t)
+	if err != nil {
+		return false
+	}
+	return dt == Float64 || dt == Float32
+}
+
+func cloneValues(vals []Value) (retVal []Value, err error) {
+	retVal = make([]Value, len(vals))
+	for i, v := range vals {
+		if retVal[i], err = CloneValue(v); err != nil {
+			return nil, errors.Wrap(err, cloneFail)
+		}
+	}
+	return
+}
+
+// addValues returns a + b, without changing either.
+func addValues(a, b Value) (Value, error) {
+	add := newEBOByType(addOpType, TypeOf(a), TypeOf(b))
+	retVal, err := add.Do(a, b)
+	if err != nil {
+		return nil, errors.Wrapf(err, doFail, add)
+	}
+	return retVal, nil
+}
+
+func zeroLike(v Value) (retVal Value, err error) {
+	if retVal, err = CloneValue(v); err != nil {
+		return nil, errors.Wrap(err, cloneFail)
+	}
+	return ZeroValue(retVal), nil
+}
+
+// truthy returns the truth value of a predicate: a Bool, or a number which is true if it's not zero.
+func truthy(v Value) (bool, error) {
+	if t, ok := v.(tensor.Tensor); ok && t.IsScalar() {
+		var err error
+		if v, _, _, err = anyToValue(t.ScalarValue()); err != nil {
+			return false, errors.Wrapf(err, anyToValueFail, t, t)
+		}
+	}
+
+	switch vt := v.(type) {
+	case *B:
+		return bool(*vt), nil
+	case *F64:
+		return *vt != 0, nil
+	case *F32:
+		return *vt != 0, nil
+	case *I:
+		return *vt != 0, nil
+	case *I64:
+		return *vt != 0, nil
+	case *I32:
+		return *vt != 0, nil
+	}
+	return false, errors.Errorf("Expected a scalar predicate. Got %v of %T instead", v, v)
+}
+
+// scanSlice returns a copy of the t-th slice of xs along the first axis. Views (such as transposed tensors) are materialized first,
+// as slicing a view does not account for its transposes. Scan materializes xs once, before the first slice.
+func scanSlice(xs Value, t int) (retVal Value, err error) {
+	T, ok := xs.(tensor.Tensor)
+	if !ok {
+		return nil, errors.Errorf(nyiTypeFail, "scanSlice", xs)
+	}
+	T = materialized(T)
+
+	if T.Dims() == 1 {
+		var v interface{}
+		if v, err = T.At(t); err != nil {
+			return nil, err
+		}
+		if retVal, _, _, err = anyToValue(v); err != nil {
+			return nil, errors.Wrapf(err, anyToValueFail, v, v)
+		}
+		return
+	}
+
+	var view tensor.View
+	if view, err = T.Slice(S(t)); err != nil {
+		return nil, errors.Wrapf(err, sliceFail, t)
+	}
+	return view.Materialize(), nil
+}
+
+// materialized returns a copy of T with its own contiguous data if it is a view, and T itself otherwise.
+func materialized(T tensor.Tensor) tensor.Tensor {
+	if v, ok := T.(tensor.View); ok && v.IsMaterializable() {
+		return v.Materialize()
+	}
+	return T
+}
+
+// scanSet sets the t-th slice of xs along the first axis to v, which must have the same dtype and shape as the slice.
+func scanSet(xs tensor.Tensor, t int, v Value) (err error) {
+	if v.Dtype() != xs.Dtype() {
+		return errors.Errorf("Expected slice %d of %v to be set to a %v value. Got %v instead", t, xs.Shape(), xs.Dtype(), v.Dtype())
+	}
+
+	if xs.Dims() == 1 {
+		switch vt := v.(type) {
+		case Scalar:
+			return xs.SetAt(vt.Data(), t)
+		case tensor.Tensor:
+			if vt.IsScalar() {
+				return xs.SetAt(vt.ScalarValue(), t)
+			}
+		}
+		return errors.Errorf("Expected slice %d of %v to be set to a scalar. Got %v instead", t, xs.Shape(), v.Shape())
+	}
+
+	vt, ok := v.(tensor.Tensor)
+	if !ok {
+		return errors.Errorf(nyiTypeFail, "scanSet", v)
+	}
+
+	var view tensor.View
+	if view, err = xs.Slice(S(t)); err != nil {
+		return errors.Wrapf(err, sliceFail, t)
+	}
+	if !view.Shape().Eq(vt.Shape()) {
+		return errors.Errorf("Expected slice %d of %v to be set to a value of shape %v. Got %v instead", t, xs.Shape(), view.Shape(), vt.Shape())
+	}
+	return tensor.Copy(view, vt)
+}
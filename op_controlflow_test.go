@@ -0,0 +1,332 @@
+package gorgonia
+
+import (
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+// x, i = x*2, i+1 while i < 5
+func whileEqn() (g *ExprGraph, x, i, cost *Node, err error) {
+	g = NewGraph()
+	x = NewScalar(g, Float64, WithName("x"), WithValue(1.5))
+	i = NewScalar(g, Float64, WithName("i"), WithValue(0.0))
+
+	cond := func(vars Nodes) (*Node, error) {
+		return Gt(NewConstant(5.0), vars[1], false)
+	}
+	body := func(vars Nodes) (Nodes, error) {
+		x2, err := HadamardProd(vars[0], NewConstant(2.0))
+		if err != nil {
+			return nil, err
+		}
+		i2, err := Add(vars[1], NewConstant(1.0))
+		if err != nil {
+			return nil, err
+		}
+		return Nodes{x2, i2}, nil
+	}
+
+	var rets Nodes
+	if rets, err = While(cond, body, x, i); err != nil {
+		return
+	}
+	cost, err = Add(rets[0], rets[1])
+	return
+}
+
+func TestWhile(t *testing.T) {
+	g, x, i, cost, err := whileEqn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grads, err := Grad(cost, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g)
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := extractF64(cost.Value()); got != 1.5*32+5 {
+		t.Errorf("Expected cost to be %v. Got %v instead", 1.5*32+5, got)
+	}
+	if got := extractF64(grads[0].Value()); got != 32 {
+		t.Errorf("Expected dcost/dx to be 32. Got %v instead", got)
+	}
+
+	// different number of iterations, same program
+	Let(x, 2.0)
+	Let(i, 3.0)
+	m.Reset()
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := extractF64(cost.Value()); got != 2*4+5 {
+		t.Errorf("Expected cost to be %v. Got %v instead", 2*4+5, got)
+	}
+	if got := extractF64(grads[0].Value()); got != 4 {
+		t.Errorf("Expected dcost/dx to be 4. Got %v instead", got)
+	}
+
+	// lispMachine
+	g, x, _, cost, err = whileEqn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lm := NewLispMachine(g)
+	if err = lm.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := extractF64(cost.Value()); got != 1.5*32+5 {
+		t.Errorf("lispMachine: Expected cost to be %v. Got %v instead", 1.5*32+5, got)
+	}
+
+	xgrad, err := x.Grad()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extractF64(xgrad); got != 32 {
+		t.Errorf("lispMachine: Expected dcost/dx to be 32. Got %v instead", got)
+	}
+}
+
+func TestCond(t *testing.T) {
+	g := NewGraph()
+	p := NewScalar(g, Float64, WithName("p"), WithValue(1.0))
+	x := NewVector(g, Float64, WithName("x"), WithShape(3), WithValue(tensor.New(tensor.WithBacking([]float64{1, 2, 3}))))
+
+	square := func(args Nodes) (Nodes, error) {
+		n, err := HadamardProd(args[0], args[0])
+		return Nodes{n}, err
+	}
+	neg := func(args Nodes) (Nodes, error) {
+		n, err := Neg(args[0])
+		return Nodes{n}, err
+	}
+
+	rets, err := Cond(p, square, neg, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost := Must(Sum(rets[0]))
+
+	grads, err := Grad(cost, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g)
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := extractF64(cost.Value()); got != 14 {
+		t.Errorf("Expected cost to be 14. Got %v instead", got)
+	}
+	if got := extractF64s(grads[0].Value()); !floatsEqual64([]float64{2, 4, 6}, got) {
+		t.Errorf("Expected dcost/dx to be [2 4 6]. Got %v instead", got)
+	}
+
+	Let(p, 0.0)
+	m.Reset()
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := extractF64(cost.Value()); got != -6 {
+		t.Errorf("Expected cost to be -6. Got %v instead", got)
+	}
+	if got := extractF64s(grads[0].Value()); !floatsEqual64([]float64{-1, -1, -1}, got) {
+		t.Errorf("Expected dcost/dx to be [-1 -1 -1]. Got %v instead", got)
+	}
+
+	// mismatched branches
+	vec := func(args Nodes) (Nodes, error) { return Nodes{args[0]}, nil }
+	scalar := func(args Nodes) (Nodes, error) {
+		n, err := Sum(args[0])
+		return Nodes{n}, err
+	}
+	if _, err = Cond(p, vec, scalar, x); err == nil {
+		t.Error("Expected an error when the branches return different shapes")
+	}
+}
+
+func TestScan(t *testing.T) {
+	g := NewGraph()
+	h := NewScalar(g, Float64, WithName("h"), WithValue(2.0))
+	xs := NewVector(g, Float64, WithName("xs"), WithShape(4), WithValue(tensor.New(tensor.WithBacking([]float64{1, 2, 3, 4}))))
+
+	// carry = carry * x + x
+	fn := func(carry, x *Node, _ Nodes) (*Node, error) {
+		cx, err := HadamardProd(carry, x)
+		if err != nil {
+			return nil, err
+		}
+		return Add(cx, x)
+	}
+
+	rets, err := Scan(fn, h, xs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := rets[0]
+
+	grads, err := Grad(ret, h, xs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g)
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// forwards: ((((2*1+1)*2+2)*3+3)*4+4) = 112
+	// backwards:
+	//		dh = 1*2*3*4 = 24
+	//		dx_t = (c_t + 1) * Π_{s > t} x_s, where c_t is the carry before step t
+	if got := extractF64(ret.Value()); got != 112 {
+		t.Errorf("Expected the result to be 112. Got %v instead", got)
+	}
+	if got := extractF64(grads[0].Value()); got != 24 {
+		t.Errorf("Expected the gradient of h to be 24. Got %v instead", got)
+	}
+	expected := []float64{3 * 24, 4 * 12, 9 * 4, 28}
+	if got := extractF64s(grads[1].Value()); !floatsEqual64(expected, got) {
+		t.Errorf("Expected the gradient of xs to be %v. Got %v instead", expected, got)
+	}
+
+	// a shorter sequence, without recompiling
+	Let(xs, tensor.New(tensor.WithBacking([]float64{3, 2})))
+	m.Reset()
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// forwards: (2*3+3)*2+2 = 20
+	if got := extractF64(ret.Value()); got != 20 {
+		t.Errorf("Expected the result to be 20. Got %v instead", got)
+	}
+	if got := extractF64s(grads[1].Value()); !floatsEqual64([]float64{3 * 2, 10}, got) {
+		t.Errorf("Expected the gradient of xs to be [6 10]. Got %v instead", got)
+	}
+
+	// xs may be a view, such as a transposed tensor
+	g = NewGraph()
+	m32 := NewMatrix(g, Float64, WithName("m"), WithShape(3, 2), WithValue(tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6}))))
+	v := NewVector(g, Float64, WithName("v"), WithShape(3), WithValue(tensor.New(tensor.WithBacking([]float64{0, 0, 0}))))
+	add := func(carry, x *Node, _ Nodes) (*Node, error) { return Add(carry, x) }
+	if rets, err = Scan(add, v, Must(Transpose(m32))); err != nil {
+		t.Fatal(err)
+	}
+	ret = rets[0]
+	if err = NewTapeMachine(g).RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := extractF64s(ret.Value()); !floatsEqual64([]float64{3, 7, 11}, got) {
+		t.Errorf("Expected the sum of the rows of the transposed matrix to be [3 7 11]. Got %v instead", got)
+	}
+
+	// the slices of the gradient must match its dtype
+	if err = scanSet(tensor.New(tensor.Of(tensor.Float64), tensor.WithShape(2, 2)), 0, tensor.New(tensor.WithBacking([]float32{1, 2}))); err == nil {
+		t.Error("Expected an error when setting a slice to a value of another dtype")
+	}
+}
+
+func TestScanRNN(t *testing.T) {
+	Wv := []float64{0.5, -0.3, 0.8, 0.1}
+	h0v := []float64{0.1, -0.2}
+	seqs := [][]float64{
+		{1, 2, -1, 0.5, 0.3, -0.7},
+		{-0.4, 0.9, 0.2, -1.5},
+	}
+
+	// h = tanh(W×h + x). The cost is the sum of the hidden states after every step
+	cell := func(W, h, x *Node) (*Node, error) {
+		wh, err := Mul(W, h)
+		if err != nil {
+			return nil, err
+		}
+		return Tanh(Must(Add(wh, x)))
+	}
+	newParams := func(g *ExprGraph) (W, h0 *Node) {
+		W = NewMatrix(g, Float64, WithName("W"), WithShape(2, 2), WithValue(tensor.New(tensor.WithShape(2, 2), tensor.WithBacking(append([]float64{}, Wv...)))))
+		h0 = NewVector(g, Float64, WithName("h0"), WithShape(2), WithValue(tensor.New(tensor.WithBacking(append([]float64{}, h0v...)))))
+		return
+	}
+
+	g := NewGraph()
+	W, h0 := newParams(g)
+	xs := NewMatrix(g, Float64, WithName("xs"), WithShape(3, 2), WithValue(tensor.New(tensor.WithShape(3, 2), tensor.WithBacking(seqs[0]))))
+	fn := func(h, x *Node, operands Nodes) (*Node, error) { return cell(operands[0], h, x) }
+	rets, err := Scan(fn, h0, xs, W)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rets[1].Shape().Eq(tensor.Shape{3, 2}) {
+		t.Errorf("Expected the stacked carries to have shape (3, 2). Got %v instead", rets[1].Shape())
+	}
+	cost := Must(Sum(rets[1]))
+	grads, err := Grad(cost, W)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewTapeMachine(g)
+
+	for _, seq := range seqs {
+		steps := len(seq) / 2
+
+		// the same RNN, unrolled
+		ug := NewGraph()
+		uW, uh := newParams(ug)
+		uxs := NewMatrix(ug, Float64, WithName("xs"), WithShape(steps, 2), WithValue(tensor.New(tensor.WithShape(steps, 2), tensor.WithBacking(seq))))
+		var hs Nodes
+		for i := 0; i < steps; i++ {
+			if uh, err = cell(uW, uh, Must(Slice(uxs, S(i)))); err != nil {
+				t.Fatal(err)
+			}
+			hs = append(hs, uh)
+		}
+		ucost := Must(Sum(Must(Concat(0, hs...))))
+		ugrads, err := Grad(ucost, uW)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the hidden states are intermediate values, so trace the execution to stop them from being clobbered
+		if err = NewTapeMachine(ug, TraceExec()).RunAll(); err != nil {
+			t.Fatal(err)
+		}
+
+		Let(xs, tensor.New(tensor.WithShape(steps, 2), tensor.WithBacking(seq)))
+		m.Reset()
+		if err = m.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !ValueClose(hs[steps-1].Value(), rets[0].Value()) {
+			t.Errorf("%d steps: Expected the final carry to be %v. Got %v instead", steps, hs[steps-1].Value(), rets[0].Value())
+		}
+		for i, h := range hs {
+			got, err := scanSlice(rets[1].Value(), i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ValueClose(h.Value(), got) {
+				t.Errorf("%d steps: Expected carry %d to be %v. Got %v instead", steps, i, h.Value(), got)
+			}
+		}
+		if !ValueClose(ucost.Value(), cost.Value()) {
+			t.Errorf("%d steps: Expected the cost to be %v. Got %v instead", steps, ucost.Value(), cost.Value())
+		}
+		if !ValueClose(ugrads[0].Value(), grads[0].Value()) {
+			t.Errorf("%d steps: Expected the gradient of W to be %v. Got %v instead", steps, ugrads[0].Value(), grads[0].Value())
+		}
+	}
+}
@@ -229,6 +229,20 @@ func (op elemBinOp) SymDiff(inputs Nodes, output, gradNode *Node) (retVal Nodes,
 	return
 }
 
+func (op elemBinOp) FwdDiff(inputs, tangents Nodes, output *Node) (retVal *Node, err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+
+	b := op.ʘBinaryOperator.binOpType()
+	if retVal, err = ʘBinOpFwdDiffExprs[b](inputs[0], inputs[1], output, tangents[0], tangents[1]); err != nil {
+		return
+	}
+
+	// the tangent of a scalar input is a scalar. If it was broadcast, so must the tangent be
+	return broadcastTangent(retVal, output)
+}
+
 func (op elemBinOp) Do(values ...Value) (Value, error) {
 	return op.ʘBinaryOperator.Do(op.retSame, values...)
 }
@@ -436,6 +450,21 @@ func (op elemUnaryOp) SymDiff(inputs Nodes, output, gradNode *Node) (retVal Node
 	return
 }
 
+// FwdDiff reuses the symbolic differentiation expressions: because the op is applied pointwise, the Jacobian is diagonal,
+// so the tangent of the output is the same expression as the gradient of the input.
+func (op elemUnaryOp) FwdDiff(inputs, tangents Nodes, output *Node) (retVal *Node, err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+
+	u := op.ʘUnaryOperator.unaryOpType()
+	if retVal, err = ʘUnaryOpDiffExprs[u](inputs[0], output, tangents[0]); err != nil {
+		return
+	}
+	regroupTangent(retVal, inputs[0], output, tangents[0])
+	return
+}
+
 func (op elemUnaryOp) DoDiff(ctx ExecutionContext, inputs Nodes, output *Node) (err error) {
 	if err = checkArity(op, len(inputs)); err != nil {
 		return
@@ -612,6 +641,29 @@ func (op linAlgBinOp) SymDiff(inputs Nodes, output, gradNode *Node) (retVal Node
 	return
 }
 
+// FwdDiff uses the fact that all the linear algebra operations are bilinear:
+//		d(A × B) = dA × B + A × dB
+func (op linAlgBinOp) FwdDiff(inputs, tangents Nodes, output *Node) (retVal *Node, err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+
+	var da, db *Node
+	if tangents[0] != nil {
+		if da, err = ApplyOp(op, tangents[0], inputs[1]); err != nil {
+			return nil, errors.Wrap(err, applyOpFail)
+		}
+		da.setGroup(tangentClust)
+	}
+	if tangents[1] != nil {
+		if db, err = ApplyOp(op, inputs[0], tangents[1]); err != nil {
+			return nil, errors.Wrap(err, applyOpFail)
+		}
+		db.setGroup(tangentClust)
+	}
+	return addFwdDiffExpr(inputs[0], inputs[1], output, da, db)
+}
+
 func (op linAlgBinOp) DoDiff(ctx ExecutionContext, inputs Nodes, output *Node) (err error) {
 	if err = checkArity(op, len(inputs)); err != nil {
 		return
@@ -721,6 +773,14 @@ func (op linAlgBinOp) IsBinary() bool { return true }
 
 /* PRIVATE METHODS */
 
+// transposableDense returns a *Dense that shares no access pattern with d, so that it may be transposed without changing d.
+func transposableDense(d *tensor.Dense) tensor.Tensor {
+	if d.IsMaterializable() {
+		return d.Materialize()
+	}
+	return d.ShallowClone()
+}
+
 func (op linAlgBinOp) do(inputs []Value, opts ...tensor.FuncOpt) (retVal Value, err error) {
 	if err = checkArity(op, len(inputs)); err != nil {
 		return
@@ -728,20 +788,34 @@ func (op linAlgBinOp) do(inputs []Value, opts ...tensor.FuncOpt) (retVal Value,
 
 	a, b := inputs[0].(tensor.Tensor), inputs[1].(tensor.Tensor)
 
+	// dense inputs are transposed through views, so that the inputs (which may be shared by replicas of a machine) are not changed.
+	// A shallow clone does not carry over a previous transpose or slice, so such inputs are materialized instead.
 	if op.transA {
+		d, isDense := a.(*tensor.Dense)
+		if isDense {
+			a = transposableDense(d)
+		}
 		if err = a.T(); err != nil {
 			return nil, errors.Wrap(err, tFail)
 		}
-		// untranspose
-		defer a.T()
+		if !isDense {
+			// untranspose
+			defer a.T()
+		}
 	}
 
 	if op.transB {
+		d, isDense := b.(*tensor.Dense)
+		if isDense {
+			b = transposableDense(d)
+		}
 		if err = b.T(); err != nil {
 			return nil, errors.Wrap(err, tFail)
 		}
-		// untranspose
-		defer b.T()
+		if !isDense {
+			// untranspose
+			defer b.T()
+		}
 	}
 
 	switch op.āBinaryOperator {
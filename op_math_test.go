@@ -446,3 +446,37 @@ func TestBasicArithmetic(t *testing.T) {
 		runtime.GC()
 	}
 }
+
+func TestMulTransposedView(t *testing.T) {
+	// the gradient of w is computed from the transposed view of w, which is transposed again by the matrix product
+	g := NewGraph()
+	x := NewMatrix(g, Float64, WithName("x"), WithShape(2, 3), WithValue(tensor.New(tensor.WithShape(2, 3), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6}))))
+	w := NewMatrix(g, Float64, WithName("w"), WithShape(2, 3), WithValue(tensor.New(tensor.WithShape(2, 3), tensor.WithBacking([]float64{1, 0, 0, 0, 1, 1}))))
+	xw := Must(Mul(x, Must(Transpose(w))))
+	cost := Must(Sum(Must(Square(xw))))
+	grads, err := Grad(cost, x, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g)
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// xw = [[1 5] [4 11]]
+	correct := []Value{
+		tensor.New(tensor.WithShape(2, 3), tensor.WithBacking([]float64{2, 10, 10, 8, 22, 22})),
+		tensor.New(tensor.WithShape(2, 3), tensor.WithBacking([]float64{34, 44, 54, 98, 130, 162})),
+	}
+	for i, grad := range grads {
+		// the gradient of w is itself a transposed view
+		got := grad.Value().(*tensor.Dense).Materialize()
+		if !ValueClose(correct[i], got) {
+			t.Errorf("Gradient %d: expected %v. Got %v instead", i, correct[i], got)
+		}
+	}
+	if !ValueClose(tensor.New(tensor.WithShape(2, 3), tensor.WithBacking([]float64{1, 0, 0, 0, 1, 1})), w.Value()) {
+		t.Errorf("Expected w to be unchanged. Got %v", w.Value())
+	}
+}
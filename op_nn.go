@@ -69,6 +69,9 @@ func (op randomOp) Type() hm.Type {
 func (op randomOp) InferShape(...DimSizer) (tensor.Shape, error) { return op.shape, nil }
 
 func (op randomOp) Do(...Value) (retVal Value, err error) {
+	if hasDynDims(op.shape) {
+		return nil, errors.Errorf("Unable to generate random values of shape %v. Symbolic dimensions are not supported", op.shape)
+	}
 	if op.shape.IsScalar() {
 		var v interface{}
 		switch op.dt {
@@ -142,7 +142,7 @@ func (op sumOp) Type() hm.Type {
 	a := hm.TypeVariable('a')
 	t := newTensorType(op.d, a)
 
-	if op.inputShape.IsVector() {
+	if isVector(op.inputShape) {
 		return hm.NewFnType(t, a)
 	}
 
@@ -160,7 +160,7 @@ func (op sumOp) InferShape(inputs ...DimSizer) (shape tensor.Shape, err error) {
 	switch {
 	case in.IsScalar():
 		shape = scalarShape
-	case in.IsVector() && !in.IsRowVec() && !in.IsColVec():
+	case isVector(in) && !isRowVec(in) && !isColVec(in):
 		if len(op.along) > 1 || (len(op.along) == 1 && op.along[0] != 0) {
 			return nil, errors.Errorf("Shape mismatch: along is %v. Shape is %v", op.along, in)
 		}
@@ -186,9 +186,9 @@ func (op sumOp) InferShape(inputs ...DimSizer) (shape tensor.Shape, err error) {
 
 		switch {
 
-		case shape.IsColVec():
+		case isColVec(shape):
 			shape = shape[:1]
-		case shape.IsRowVec():
+		case isRowVec(shape):
 			shape = shape[1:]
 		}
 
@@ -198,6 +198,11 @@ func (op sumOp) InferShape(inputs ...DimSizer) (shape tensor.Shape, err error) {
 
 func (op sumOp) DiffWRT(i int) []bool { return []bool{true} }
 
+// FwdDiff applies the op to the tangent, as summing is linear
+func (op sumOp) FwdDiff(inputs, tangents Nodes, output *Node) (*Node, error) {
+	return linearFwdDiff(op, inputs, tangents)
+}
+
 func (op sumOp) SymDiff(inputs Nodes, output, gradNode *Node) (retVal Nodes, err error) {
 	if err = checkArity(op, len(inputs)); err != nil {
 		return
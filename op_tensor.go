@@ -102,6 +102,9 @@ func (op sizeOp) CallsExtern() bool                            { return false }
 func (op sizeOp) InferShape(...DimSizer) (tensor.Shape, error) { return scalarShape, nil } // TODO: return error
 func (op sizeOp) DiffWRT(i int) []bool                         { return []bool{false} }
 func (op sizeOp) String() string {
+	if isDynDim(op.val) {
+		return fmt.Sprintf("SizeOf=%s", dynDimName(op.val))
+	}
 	if op.val != 0 {
 		return fmt.Sprintf("SizeOf=%d", op.val)
 	}
@@ -272,6 +275,24 @@ func (op repeatOp) InferShape(inputs ...DimSizer) (retVal tensor.Shape, err erro
 		if rep == 1 || rep == 0 { // 0 means unknown
 			continue
 		}
+		if isDynDim(rep) {
+			// only a dimension of size 1 can be repeated a symbolic number of times
+			if retVal[axis] != 1 {
+				return nil, errors.Errorf("Unable to repeat axis %d of %v by %s times", axis, retVal, dynDimName(rep))
+			}
+			retVal = retVal.Clone()
+			retVal[axis] = rep
+			continue
+		}
+		if hasDynDims(retVal) {
+			switch {
+			case len(retVal) == 1 && axis == 1:
+				// like any vector, a vector of a symbolic size is repeated along axis 1 as a column vector
+				retVal = tensor.Shape{retVal[0], 1}
+			case axis < len(retVal) && isDynDim(retVal[axis]):
+				return nil, errors.Errorf("Unable to repeat the symbolic dimension %q of %v", dynDimName(retVal[axis]), retVal)
+			}
+		}
 		if retVal, _, _, err = retVal.Repeat(axis, rep); err != nil {
 			return
 		}
@@ -545,6 +566,9 @@ func (op *sliceOp) InferShape(inputs ...DimSizer) (s tensor.Shape, err error) {
 	slices := make([]tensor.Slice, op.along+1)
 	slices[op.along] = op.Slice
 
+	if hasDynDims(input) {
+		return sliceDynShape(input, slices)
+	}
 	return input.S(slices...)
 
 	// return input.S(op.Slice)
@@ -560,6 +584,11 @@ func (op *sliceOp) DiffWRT(i int) []bool {
 	return []bool{true}
 }
 
+// FwdDiff applies the op to the tangent, as slicing is linear
+func (op *sliceOp) FwdDiff(inputs, tangents Nodes, output *Node) (*Node, error) {
+	return linearFwdDiff(op, inputs, tangents)
+}
+
 func (op *sliceOp) SymDiff(inputs Nodes, outputNode, gradNode *Node) (retVal Nodes, err error) {
 	if err = checkArity(op, len(inputs)); err != nil {
 		return
@@ -924,6 +953,11 @@ func (op transposeOp) DiffWRT(i int) []bool {
 	return []bool{true}
 }
 
+// FwdDiff applies the op to the tangent, as transposing is linear
+func (op transposeOp) FwdDiff(inputs, tangents Nodes, output *Node) (*Node, error) {
+	return linearFwdDiff(op, inputs, tangents)
+}
+
 func (op transposeOp) SymDiff(inputs Nodes, outputNode, gradNode *Node) (retVal Nodes, err error) {
 	newPattern := make([]int, len(op.pattern))
 	for i, p := range op.pattern {
@@ -1053,6 +1087,11 @@ func (op concatOp) InferShape(ds ...DimSizer) (tensor.Shape, error) {
 		return nil, err
 	}
 
+	for _, s := range shapes {
+		if op.axis < len(s) && isDynDim(s[op.axis]) {
+			return nil, errors.Errorf("Unable to concatenate %v along the symbolic dimension %q", shapes, dynDimName(s[op.axis]))
+		}
+	}
 	return shapes[0].Concat(op.axis, shapes[1:]...)
 }
 
@@ -1285,13 +1324,19 @@ func (op reshapeOp) Do(vals ...Value) (Value, error) {
 	if val, err = CloneValue(vals[0]); err != nil {
 		return nil, errors.Wrapf(err, cloneFail, vals[0])
 	}
-	if !val.Shape().Eq(op.from) {
+	to := op.to
+	switch {
+	case hasDynDims(op.from) || hasDynDims(op.to):
+		if to, err = op.resolve(val.Shape()); err != nil {
+			return nil, err
+		}
+	case !val.Shape().Eq(op.from):
 		return nil, errors.Errorf("Shape mismatch. Input shape is %v. Expected %v", val.Shape(), op.from)
 	}
 
 	switch v := val.(type) {
 	case tensor.Tensor:
-		if err := v.Reshape(op.to...); err != nil {
+		if err := v.Reshape(to...); err != nil {
 			return nil, err
 		}
 		return v, nil
@@ -1302,6 +1347,41 @@ func (op reshapeOp) Do(vals ...Value) (Value, error) {
 	panic("Unreachable")
 }
 
+// resolve resolves the symbolic dimensions of the target shape, given the actual shape of the input.
+// Symbolic dimensions shared with the input shape take the input's sizes. At most one symbolic dimension that
+// only appears in the target shape is allowed - its size is whatever is left over.
+func (op reshapeOp) resolve(actual tensor.Shape) (retVal tensor.Shape, err error) {
+	dims := make(map[int]int)
+	if err = unifyDims(op.from, actual, dims); err != nil {
+		return nil, err
+	}
+
+	retVal = op.to.Clone()
+	known, unknown := 1, -1
+	for i, d := range retVal {
+		if size, ok := dims[d]; ok {
+			retVal[i] = size
+		}
+		switch {
+		case !isDynDim(retVal[i]):
+			known *= retVal[i]
+		case unknown >= 0:
+			return nil, errors.Errorf("Unable to reshape %v to %v: too many unresolved symbolic dimensions", actual, op.to)
+		default:
+			unknown = i
+		}
+	}
+
+	size := actual.TotalSize()
+	if unknown >= 0 && known > 0 {
+		retVal[unknown] = size / known
+	}
+	if retVal.TotalSize() != size {
+		return nil, errors.Errorf("Unable to reshape %v to %v", actual, op.to)
+	}
+	return
+}
+
 func (op reshapeOp) ReturnsPtr() bool     { return true }
 func (op reshapeOp) CallsExtern() bool    { return false }
 func (op reshapeOp) OverwritesInput() int { return 0 }
@@ -1322,6 +1402,11 @@ func (op reshapeOp) String() string {
 
 func (op reshapeOp) DiffWRT(i int) []bool { return []bool{true} }
 
+// FwdDiff applies the op to the tangent, as reshaping is linear
+func (op reshapeOp) FwdDiff(inputs, tangents Nodes, output *Node) (*Node, error) {
+	return linearFwdDiff(op, inputs, tangents)
+}
+
 func (op reshapeOp) SymDiff(inputs Nodes, output *Node, grad *Node) (retVal Nodes, err error) {
 	var ret *Node
 	if ret, err = Reshape(grad, op.from); err != nil {
@@ -731,6 +731,123 @@ func Tensordot(aAxes []int, bAxes []int, a, b *Node) (retVal *Node, err error) {
 	return ApplyOp(op, a, b)
 }
 
+/* Control flow */
+
+// Cond creates nodes that hold the results of thenFn(operands...) if pred is true, and elseFn(operands...) otherwise.
+// pred is a scalar; if it's not a Bool, it is true when it is not zero.
+//
+// The bodies are built in their own graphs, so they cannot refer to the nodes of pred's graph - pass those in as operands.
+// Both bodies must return the same number of results, with the same types and shapes.
+func Cond(pred *Node, thenFn, elseFn BodyFn, operands ...*Node) (retVal Nodes, err error) {
+	if !pred.IsScalar() {
+		return nil, errors.Errorf("Expected pred to be a scalar. Got %v instead", pred.Shape())
+	}
+
+	var thenBody, elseBody *ctrlBody
+	if thenBody, err = newCtrlBody("then", operands, thenFn); err != nil {
+		return nil, err
+	}
+	if elseBody, err = newCtrlBody("else", operands, elseFn); err != nil {
+		return nil, err
+	}
+	if err = checkBodyOutputs("Cond", elseBody.outputs, thenBody.outputs); err != nil {
+		return nil, err
+	}
+
+	args := append(Nodes{pred}, operands...)
+	def := newCtrlFlowDef(condKind, []*ctrlBody{thenBody, elseBody}, args, thenBody.outputs)
+	return def.apply(args)
+}
+
+// While creates nodes that hold the values of the loop variables after repeatedly applying bodyFn to them while condFn returns true.
+// condFn must return a scalar. If it's not a Bool, it is true when it is not zero. bodyFn must return the new values of all the loop variables.
+//
+// The bodies are built in their own graphs, so they cannot refer to the nodes of the loop variables' graph - make them loop variables instead.
+// The number of iterations is only known at runtime, so there is no need to recompile the graph when it changes.
+func While(condFn func(loopVars Nodes) (*Node, error), bodyFn BodyFn, loopVars ...*Node) (retVal Nodes, err error) {
+	if len(loopVars) == 0 {
+		return nil, errors.New("While requires at least one loop variable")
+	}
+
+	condBodyFn := func(args Nodes) (Nodes, error) {
+		c, err := condFn(args)
+		if err != nil {
+			return nil, err
+		}
+		return Nodes{c}, nil
+	}
+
+	var condBody, body *ctrlBody
+	if condBody, err = newCtrlBody("cond", loopVars, condBodyFn); err != nil {
+		return nil, err
+	}
+	if !condBody.outputs[0].IsScalar() {
+		return nil, errors.Errorf("Expected the condition of While to be a scalar. Got %v instead", condBody.outputs[0].Shape())
+	}
+	if body, err = newCtrlBody("body", loopVars, bodyFn); err != nil {
+		return nil, err
+	}
+	if err = checkBodyOutputs("While", body.outputs, loopVars); err != nil {
+		return nil, err
+	}
+
+	def := newCtrlFlowDef(whileKind, []*ctrlBody{condBody, body}, loopVars, loopVars)
+	return def.apply(loopVars)
+}
+
+// Scan folds fn over the first axis of xs, starting with init:
+//		carry = fn(carry, xs[t], operands)
+// fn must return a result of the same type and shape as init. Scan returns two nodes: the final carry, and the carries after every step,
+// stacked along a new first axis (so the t-th slice of the second node is the carry after xs[t]).
+//
+// The body is built in its own graph, so it cannot refer to the nodes of init's graph - pass those in as operands. For example, the
+// weights of an RNN cell are operands, and their gradients are summed over all the steps.
+// The length of xs is only read at runtime, so sequences of different lengths can be used without recompiling the graph.
+func Scan(fn func(carry, x *Node, operands Nodes) (*Node, error), init, xs *Node, operands ...*Node) (retVal Nodes, err error) {
+	if xs.IsScalar() {
+		return nil, errors.New("Expected xs to be a Tensor")
+	}
+
+	var dt, carryDt tensor.Dtype
+	if dt, err = dtypeOf(xs.t); err != nil {
+		return nil, errors.Wrap(err, dtypeOfFail)
+	}
+	if carryDt, err = dtypeOf(init.t); err != nil {
+		return nil, errors.Wrap(err, dtypeOfFail)
+	}
+
+	// x and carries are placeholders, so they're only used for their types and shapes
+	s := xs.Shape()[1:].Clone()
+	var x *Node
+	if len(s) == 0 {
+		x = NewScalar(NewGraph(), dt)
+	} else {
+		x = NewTensor(NewGraph(), dt, len(s), WithShape(s...))
+	}
+	cs := append(tensor.Shape{xs.Shape()[0]}, init.Shape()...)
+	carries := NewTensor(NewGraph(), carryDt, len(cs), WithShape(cs...))
+
+	bodyFn := func(args Nodes) (Nodes, error) {
+		c, err := fn(args[0], args[1], args[2:])
+		if err != nil {
+			return nil, err
+		}
+		return Nodes{c}, nil
+	}
+
+	var body *ctrlBody
+	if body, err = newCtrlBody("scan", append(Nodes{init, x}, operands...), bodyFn); err != nil {
+		return nil, err
+	}
+	if err = checkBodyOutputs("Scan", body.outputs, Nodes{init}); err != nil {
+		return nil, err
+	}
+
+	args := append(Nodes{init, xs}, operands...)
+	def := newCtrlFlowDef(scanKind, []*ctrlBody{body}, args, Nodes{init, carries})
+	return def.apply(args)
+}
+
 // Private functions
 
 func containsDublicate(slice []int) bool {
@@ -1010,3 +1010,140 @@ func nondiffBinOpExpr(x, y, z, grad *Node) (retVal Nodes, err error) {
 func nondiffBinOp(ctx ExecutionContext, x, y, z *Node) (err error) {
 	return AutoDiffError{}
 }
+
+/* FORWARD MODE */
+
+// type binFwdDiffFn func(x, y, z, tx, ty *Node) (*Node, error)
+//
+// tx and ty are the tangents of x and y. A nil tangent is treated as zero.
+
+func addFwdDiffExpr(x, y, z, tx, ty *Node) (retVal *Node, err error) {
+	switch {
+	case tx == nil:
+		return ty, nil
+	case ty == nil:
+		return tx, nil
+	}
+	if retVal, err = Add(tx, ty); err != nil {
+		return nil, errors.Wrap(err, addFail)
+	}
+	return
+}
+
+func subFwdDiffExpr(x, y, z, tx, ty *Node) (retVal *Node, err error) {
+	switch {
+	case ty == nil:
+		return tx, nil
+	case tx == nil:
+		if retVal, err = Neg(ty); err != nil {
+			return nil, errors.Wrap(err, negFail)
+		}
+		return
+	}
+	if retVal, err = Sub(tx, ty); err != nil {
+		return nil, errors.Wrap(err, subFail)
+	}
+	return
+}
+
+// dz = tx ⊙ y + x ⊙ ty
+func hadamardProdFwdDiffExpr(x, y, z, tx, ty *Node) (retVal *Node, err error) {
+	var dx, dy *Node
+	if tx != nil {
+		if dx, err = HadamardProd(tx, y); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		dx.setGroup(tangentClust)
+	}
+	if ty != nil {
+		if dy, err = HadamardProd(x, ty); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		dy.setGroup(tangentClust)
+	}
+	return addFwdDiffExpr(x, y, z, dx, dy)
+}
+
+// dz = (tx - z ⊙ ty) ÷ y
+func hadamardDivFwdDiffExpr(x, y, z, tx, ty *Node) (retVal *Node, err error) {
+	var zty *Node
+	if ty != nil {
+		if zty, err = HadamardProd(z, ty); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		zty.setGroup(tangentClust)
+	}
+
+	var num *Node
+	if num, err = subFwdDiffExpr(x, y, z, tx, zty); err != nil {
+		return
+	}
+	if num != tx {
+		num.setGroup(tangentClust)
+	}
+
+	if retVal, err = HadamardDiv(num, y); err != nil {
+		return nil, errors.Wrap(err, hadamardDivFail)
+	}
+	return
+}
+
+// dz = y ⊙ x^(y-1) ⊙ tx + z ⊙ ln(x) ⊙ ty
+func hadamardPowFwdDiffExpr(x, y, z, tx, ty *Node) (retVal *Node, err error) {
+	var dx, dy *Node
+	if tx != nil {
+		var one *Node
+		var dt tensor.Dtype
+		if dt, err = dtypeOf(y.t); err != nil {
+			return nil, errors.Wrapf(err, dtypeExtractionFail, y.t)
+		}
+
+		switch dt {
+		case Float32:
+			one = onef32
+		case Float64:
+			one = onef64
+		default:
+			return nil, errors.Errorf(nyiTypeFail, "Hadamard Power FwdDiff", y.t)
+		}
+
+		var ym1, pow, txy *Node
+		if ym1, err = Sub(y, one); err != nil {
+			return nil, errors.Wrap(err, subFail)
+		}
+		ym1.setGroup(tangentClust)
+		if pow, err = Pow(x, ym1); err != nil {
+			return
+		}
+		pow.setGroup(tangentClust)
+		if txy, err = HadamardProd(tx, y); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		txy.setGroup(tangentClust)
+		if dx, err = HadamardProd(txy, pow); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		dx.setGroup(tangentClust)
+	}
+
+	if ty != nil {
+		var logx, tyz *Node
+		if logx, err = Log(x); err != nil {
+			return
+		}
+		logx.setGroup(tangentClust)
+		if tyz, err = HadamardProd(ty, z); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		tyz.setGroup(tangentClust)
+		if dy, err = HadamardProd(tyz, logx); err != nil {
+			return nil, errors.Wrap(err, hadamardProdFail)
+		}
+		dy.setGroup(tangentClust)
+	}
+	return addFwdDiffExpr(x, y, z, dx, dy)
+}
+
+func nondiffBinOpFwdExpr(x, y, z, tx, ty *Node) (retVal *Node, err error) {
+	return nil, errors.New("Nondifferentiable")
+}
@@ -99,6 +99,11 @@ var ʘBinOpDiffExprs = [maxʘBinaryOpType]func(x, y, z, gradZ *Node) (Nodes, err
 	nondiffBinOpExpr, nondiffBinOpExpr, nondiffBinOpExpr, nondiffBinOpExpr, nondiffBinOpExpr, nondiffBinOpExpr,
 }
 
+var ʘBinOpFwdDiffExprs = [maxʘBinaryOpType]func(x, y, z, tx, ty *Node) (*Node, error){
+	addFwdDiffExpr, subFwdDiffExpr, hadamardProdFwdDiffExpr, hadamardDivFwdDiffExpr, hadamardPowFwdDiffExpr,
+	nondiffBinOpFwdExpr, nondiffBinOpFwdExpr, nondiffBinOpFwdExpr, nondiffBinOpFwdExpr, nondiffBinOpFwdExpr, nondiffBinOpFwdExpr,
+}
+
 var ʘBinOpDiffFns = [maxʘBinaryOpType]func(ctx ExecutionContext, x, y, z *Node) error{
 	addDiff, subDiff, hadamardProdDiff, hadamardDivDiff, hadamardPowDiff,
 	nondiffBinOp, nondiffBinOp, nondiffBinOp, nondiffBinOp, nondiffBinOp, nondiffBinOp,
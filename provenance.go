@@ -0,0 +1,58 @@
+package gorgonia
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Provenance is where a node was created. It is recorded for the nodes of graphs created with WithProvenance.
+type Provenance struct {
+	Function string
+	File     string
+	Line     int
+	Stack    []runtime.Frame // the stack, starting at the call site. It's nil unless the graph records the stacks
+}
+
+// pkgPrefix is the prefix of the names of the functions of this package
+var pkgPrefix = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf(NewGraph).Pointer()).Name()
+	return name[:strings.LastIndex(name, ".")+1]
+}()
+
+// newProvenance finds the call site of the first function outside of this package (tests excepted) on the stack
+func newProvenance(stack bool) *Provenance {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var retVal *Provenance
+	for {
+		f, more := frames.Next()
+		if retVal == nil && (!strings.HasPrefix(f.Function, pkgPrefix) || strings.HasSuffix(f.File, "_test.go")) {
+			retVal = &Provenance{Function: f.Function, File: f.File, Line: f.Line}
+			if !stack {
+				return retVal
+			}
+		}
+		if retVal != nil {
+			retVal.Stack = append(retVal.Stack, f)
+		}
+		if !more {
+			return retVal
+		}
+	}
+}
+
+func (p *Provenance) String() string { return fmt.Sprintf("%s:%d", p.File, p.Line) }
+
+// StackTrace formats the recorded stack like a panic would.
+func (p *Provenance) StackTrace() string {
+	var buf bytes.Buffer
+	for _, f := range p.Stack {
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+	return buf.String()
+}
@@ -0,0 +1,59 @@
+package gorgonia
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+func TestExecError(t *testing.T) {
+	g := NewGraph(WithProvenance(true))
+	x := NewMatrix(g, Float64, WithShape(2, 3), WithName("x"), WithInit(RangedFrom(0)))
+	y := NewMatrix(g, Float64, WithShape(3, 2), WithName("y"), WithInit(RangedFrom(0)))
+	xy := Must(Mul(x, y))
+	_, file, line, _ := runtime.Caller(0)
+	line--
+
+	p := xy.Provenance()
+	if p == nil || p.File != file || p.Line != line {
+		t.Fatalf("Expected %v to be created at %s:%d. Got %v instead", xy, file, line, p)
+	}
+	if len(p.Stack) == 0 || p.Stack[0].Line != line || !strings.Contains(p.StackTrace(), "TestExecError") {
+		t.Errorf("Expected the stack to start at the call site. Got\n%s", p.StackTrace())
+	}
+
+	// shape errors when building the graph
+	_, err := Add(x, y)
+	_, _, applyLine, _ := runtime.Caller(0)
+	ee, ok := errors.Cause(err).(*ExecError)
+	if !ok {
+		t.Fatalf("Expected an *ExecError. Got %v instead", err)
+	}
+	if ee.Node != nil || ee.Instr != -1 || ee.Provenance == nil || ee.Provenance.Line != applyLine-1 {
+		t.Errorf("Expected the error to say where the op was applied. Got %v", ee)
+	}
+
+	// execution errors
+	x2 := tensor.New(tensor.WithShape(2, 4), tensor.WithBacking(make([]float64, 8)))
+	for _, m := range []VM{NewTapeMachine(g), NewLispMachine(g, ExecuteFwdOnly())} {
+		if err = Let(x, x2); err != nil {
+			t.Fatal(err)
+		}
+		err = m.RunAll()
+		if ee, ok = err.(*ExecError); !ok {
+			t.Fatalf("%T: Expected an unwrapped *ExecError. Got %v instead", m, err)
+		}
+		if ee.Node != xy || ee.Op != xy.op || ee.Instr < 0 || ee.Provenance != p {
+			t.Errorf("%T: Expected the error to be about %v. Got %v", m, xy, ee)
+		}
+		if len(ee.InputShapes) != 2 || !ee.InputShapes[0].Eq(tensor.Shape{2, 4}) || ee.InputDtypes[1] != Float64 {
+			t.Errorf("%T: Expected the shapes and dtypes of the inputs. Got %v and %v", m, ee.InputShapes, ee.InputDtypes)
+		}
+		if !strings.Contains(err.Error(), p.String()) {
+			t.Errorf("%T: Expected the error to say where the node was created. Got %q", m, err)
+		}
+	}
+}
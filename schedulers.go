@@ -0,0 +1,221 @@
+package gorgonia
+
+import "math"
+
+// LRScheduler decides the learn rate of a solver at every step. Attach one to a solver with WithLRScheduler.
+type LRScheduler interface {
+	// LearnRate returns the learn rate to use for the given step (counting from 0), given the learn rate the solver is configured with.
+	LearnRate(step int, base float64) float64
+}
+
+// WithLRScheduler attaches a learn rate scheduler to the solver. The solver consults the scheduler at the start of every Step that
+// updates the weights, so the caches of the solver (such as Adam's moments) are kept when the learn rate changes.
+func WithLRScheduler(sched LRScheduler) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *RMSPropSolver:
+			st.sched.sched = sched
+		case *AdamSolver:
+			st.sched.sched = sched
+		case *VanillaSolver:
+			st.sched.sched = sched
+		case *MomentumSolver:
+			st.sched.sched = sched
+		case *AdaGradSolver:
+			st.sched.sched = sched
+		case *LBFGSSolver:
+			st.sched.sched = sched
+		case *AdamWSolver:
+			st.sched.sched = sched
+		case *AMSGradSolver:
+			st.sched.sched = sched
+		case *AdadeltaSolver:
+			st.sched.sched = sched
+		case *AdamaxSolver:
+			st.sched.sched = sched
+		case *NadamSolver:
+			st.sched.sched = sched
+		}
+	}
+	return f
+}
+
+// lrSchedule is held by the solvers to keep track of their scheduler
+type lrSchedule struct {
+	sched LRScheduler
+	step  int
+}
+
+// apply sets eta to the learn rate of the next step. It returns a function that sets eta back to the learn rate the solver is configured
+// with, which is deferred by the solvers so that eta only holds the scheduled learn rate during a Step, and later changes to it are honored.
+func (s *lrSchedule) apply(eta *float64) (restore func()) {
+	if s.sched == nil {
+		return func() {}
+	}
+	base := *eta
+	*eta = s.sched.LearnRate(s.step, base)
+	s.step++
+	return func() { *eta = base }
+}
+
+// StepDecay multiplies the learn rate by gamma every stepSize steps
+type StepDecay struct {
+	stepSize int
+	gamma    float64
+}
+
+// NewStepDecay creates a new StepDecay scheduler.
+func NewStepDecay(stepSize int, gamma float64) *StepDecay {
+	return &StepDecay{stepSize: stepSize, gamma: gamma}
+}
+
+// LearnRate implements LRScheduler
+func (s *StepDecay) LearnRate(step int, base float64) float64 {
+	return base * math.Pow(s.gamma, float64(step/s.stepSize))
+}
+
+// ExponentialDecay multiplies the learn rate by gamma every step
+type ExponentialDecay struct {
+	gamma float64
+}
+
+// NewExponentialDecay creates a new ExponentialDecay scheduler.
+func NewExponentialDecay(gamma float64) *ExponentialDecay { return &ExponentialDecay{gamma: gamma} }
+
+// LearnRate implements LRScheduler
+func (s *ExponentialDecay) LearnRate(step int, base float64) float64 {
+	return base * math.Pow(s.gamma, float64(step))
+}
+
+// CosineAnnealing anneals the learn rate from its base value down to a minimum along a cosine curve, then restarts.
+// The first cycle is period steps long. Every cycle after is mult times as long as the one before.
+// Paper: https://arxiv.org/abs/1608.03983
+type CosineAnnealing struct {
+	period int
+	mult   int
+	min    float64
+}
+
+// NewCosineAnnealing creates a new CosineAnnealing scheduler. A mult of 1 restarts every period steps.
+func NewCosineAnnealing(period, mult int, min float64) *CosineAnnealing {
+	if mult < 1 {
+		mult = 1
+	}
+	return &CosineAnnealing{period: period, mult: mult, min: min}
+}
+
+// LearnRate implements LRScheduler
+func (s *CosineAnnealing) LearnRate(step int, base float64) float64 {
+	cur, length := step, s.period
+	for cur >= length {
+		cur -= length
+		length *= s.mult
+	}
+	return s.min + (base-s.min)*(1+math.Cos(math.Pi*float64(cur)/float64(length)))/2
+}
+
+// LinearWarmup ramps the learn rate up linearly from base/steps to base over the first steps steps, and then hands over to another scheduler.
+// The scheduler after the warmup counts its steps from the end of the warmup. If it is nil, the learn rate stays at base.
+type LinearWarmup struct {
+	steps int
+	after LRScheduler
+}
+
+// NewLinearWarmup creates a new LinearWarmup scheduler.
+func NewLinearWarmup(steps int, after LRScheduler) *LinearWarmup {
+	return &LinearWarmup{steps: steps, after: after}
+}
+
+// LearnRate implements LRScheduler
+func (s *LinearWarmup) LearnRate(step int, base float64) float64 {
+	switch {
+	case step < s.steps:
+		return base * float64(step+1) / float64(s.steps)
+	case s.after == nil:
+		return base
+	default:
+		return s.after.LearnRate(step-s.steps, base)
+	}
+}
+
+// OneCycle is the one cycle policy: the learn rate rises from base/25 to base over the first 30% of the steps, then falls to base/25e4 over
+// the rest of the steps, both along cosine curves. After total steps, the learn rate stays at the final value.
+// Paper: https://arxiv.org/abs/1803.09820
+type OneCycle struct {
+	total    int
+	pctStart float64
+	div      float64 // initial learn rate = base / div
+	finalDiv float64 // final learn rate = initial learn rate / finalDiv
+}
+
+// NewOneCycle creates a new OneCycle scheduler over the total number of steps.
+func NewOneCycle(total int) *OneCycle {
+	return &OneCycle{total: total, pctStart: 0.3, div: 25, finalDiv: 1e4}
+}
+
+// LearnRate implements LRScheduler
+func (s *OneCycle) LearnRate(step int, base float64) float64 {
+	initial := base / s.div
+	final := initial / s.finalDiv
+	up := int(s.pctStart * float64(s.total))
+
+	anneal := func(from, to, pct float64) float64 {
+		return to + (from-to)*(1+math.Cos(math.Pi*pct))/2
+	}
+
+	switch {
+	case step < up:
+		return anneal(initial, base, float64(step)/float64(up))
+	case step < s.total:
+		return anneal(base, final, float64(step-up)/float64(s.total-up))
+	default:
+		return final
+	}
+}
+
+// ReduceOnPlateau reduces the learn rate by a factor when a metric (typically the validation loss) has stopped improving.
+// The metric is reported with Report. Lower values of the metric are better.
+type ReduceOnPlateau struct {
+	factor    float64
+	patience  int     // number of reports without improvement to wait for before reducing the learn rate
+	threshold float64 // relative improvement needed to count as an improvement
+	min       float64 // the learn rate is never reduced below this
+
+	best       float64
+	bad        int
+	reductions int
+}
+
+// NewReduceOnPlateau creates a new ReduceOnPlateau scheduler. A metric has to improve on the best one by the relative threshold (1e-4 is
+// a good value) to count as an improvement, and the learn rate is never reduced below min.
+func NewReduceOnPlateau(factor float64, patience int, threshold, min float64) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		factor:    factor,
+		patience:  patience,
+		threshold: threshold,
+		min:       min,
+		best:      math.Inf(1),
+	}
+}
+
+// Report reports the value of the metric. It returns true if the learn rate has been reduced.
+func (s *ReduceOnPlateau) Report(metric float64) bool {
+	if math.IsInf(s.best, 1) || metric < s.best-math.Abs(s.best)*s.threshold {
+		s.best = metric
+		s.bad = 0
+		return false
+	}
+
+	s.bad++
+	if s.bad > s.patience {
+		s.reductions++
+		s.bad = 0
+		return true
+	}
+	return false
+}
+
+// LearnRate implements LRScheduler
+func (s *ReduceOnPlateau) LearnRate(step int, base float64) float64 {
+	return math.Max(base*math.Pow(s.factor, float64(s.reductions)), s.min)
+}
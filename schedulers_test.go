@@ -0,0 +1,106 @@
+package gorgonia
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLRSchedulers(t *testing.T) {
+	approx := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	cases := []struct {
+		name     string
+		sched    LRScheduler
+		expected map[int]float64 // step → learn rate, with a base of 1
+	}{
+		{"StepDecay", NewStepDecay(2, 0.5), map[int]float64{0: 1, 1: 1, 2: 0.5, 3: 0.5, 4: 0.25}},
+		{"ExponentialDecay", NewExponentialDecay(0.9), map[int]float64{0: 1, 1: 0.9, 2: 0.81}},
+		{"CosineAnnealing", NewCosineAnnealing(4, 2, 0), map[int]float64{0: 1, 2: 0.5, 4: 1, 8: 0.5, 12: 1}},
+		{"LinearWarmup", NewLinearWarmup(4, NewExponentialDecay(0.5)), map[int]float64{0: 0.25, 1: 0.5, 3: 1, 4: 1, 5: 0.5}},
+		{"OneCycle", NewOneCycle(10), map[int]float64{0: 0.04, 3: 1, 10: 0.04 / 1e4, 20: 0.04 / 1e4}},
+	}
+
+	for _, c := range cases {
+		for step, lr := range c.expected {
+			if got := c.sched.LearnRate(step, 1); !approx(got, lr) {
+				t.Errorf("%v: Expected the learn rate at step %d to be %v. Got %v instead", c.name, step, lr, got)
+			}
+		}
+	}
+
+	// the one cycle peaks in between
+	oc := NewOneCycle(10)
+	if lr := oc.LearnRate(1, 1); lr <= 0.04 || lr >= 1 {
+		t.Errorf("OneCycle: Expected the learn rate to be rising. Got %v", lr)
+	}
+	if lr := oc.LearnRate(6, 1); lr <= 0.04/1e4 || lr >= 1 {
+		t.Errorf("OneCycle: Expected the learn rate to be falling. Got %v", lr)
+	}
+
+	plateau := NewReduceOnPlateau(0.1, 1, 1e-4, 0.005)
+	reports := []struct {
+		metric  float64
+		reduced bool
+	}{
+		{1, false},
+		{0.5, false},
+		{0.5, false}, // 1 bad report: within patience
+		{0.6, true},  // 2 bad reports
+		{0.4, false},
+		{0.4, false},
+		{0.4, true},
+		{0.4, false},
+		{0.4, true},
+	}
+	for i, r := range reports {
+		if reduced := plateau.Report(r.metric); reduced != r.reduced {
+			t.Errorf("ReduceOnPlateau: report %d: Expected reduced to be %t", i, r.reduced)
+		}
+	}
+	if lr := plateau.LearnRate(0, 1); !approx(lr, 0.005) {
+		t.Errorf("ReduceOnPlateau: Expected the learn rate to be clamped at 0.005. Got %v", lr)
+	}
+}
+
+func TestWithLRScheduler(t *testing.T) {
+	g := NewGraph()
+	x := NewScalar(g, Float64, WithName("x"), WithValue(0.0))
+	dv := dvUnit0(x.Value())
+	x.boundTo = dv
+
+	s := NewVanillaSolver(WithLearnRate(1), WithLRScheduler(NewStepDecay(1, 0.5)))
+	for _, expected := range []float64{-1, -1.5, -1.75} {
+		dv.d, _ = anyToScalar(1.0)
+		if err := s.Step(Nodes{x}); err != nil {
+			t.Fatal(err)
+		}
+		if got := extractF64(x.Value()); got != expected {
+			t.Errorf("Expected %v. Got %v instead", expected, got)
+		}
+	}
+
+	// the caches of the solver are kept when the learn rate changes
+	model := tf64Node()
+	adam := NewAdamSolver(WithLearnRate(0.1), WithLRScheduler(NewExponentialDecay(0.5)))
+	for i := 0; i < 3; i++ {
+		if err := adam.Step(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if adam.iter != 3 {
+		t.Errorf("Expected the solver to keep its state. Iteration is %d", adam.iter)
+	}
+
+	// the scheduled learn rate does not replace the one the solver is configured with, so changes to it are honored
+	if s.eta != 1 {
+		t.Errorf("Expected the learn rate of the solver to stay at 1. Got %v instead", s.eta)
+	}
+	s.eta = 8
+	dv.d, _ = anyToScalar(1.0)
+	if err := s.Step(Nodes{x}); err != nil {
+		t.Fatal(err)
+	}
+	if got := extractF64(x.Value()); got != -2.75 {
+		t.Errorf("Expected -2.75. Got %v instead", got)
+	}
+}
@@ -1,6 +1,11 @@
 package gorgonia
 
-import "github.com/chewxy/gorgonia/tensor"
+import (
+	"sync"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
 
 var scalarShape = tensor.ScalarShape()
 
@@ -15,3 +20,147 @@ func transpose2D(shape tensor.Shape) tensor.Shape {
 
 	return tensor.Shape{shape[1], shape[0]}
 }
+
+/* SYMBOLIC DIMENSIONS */
+
+// dynDimOffset is the first symbolic dimension. Symbolic dimensions count downwards from it, which keeps them
+// well clear of any size a real tensor could have.
+const dynDimOffset = -(1 << 20)
+
+var dynDims = struct {
+	sync.Mutex
+	ids   map[string]int
+	names []string
+}{ids: make(map[string]int)}
+
+// Dyn returns a symbolic dimension. It may be used anywhere a dimension size is expected in a shape:
+//		x := NewMatrix(g, Float64, WithShape(Dyn("batch"), 784))
+//
+// The same name always returns the same dimension, so shapes with the same symbolic dimensions compare as equal
+// when the graph is built. The tapeMachine resolves the actual sizes from the values bound to the input nodes
+// when RunAll is called, so the same compiled program may be run with different batch sizes.
+func Dyn(name string) int {
+	dynDims.Lock()
+	defer dynDims.Unlock()
+
+	if d, ok := dynDims.ids[name]; ok {
+		return d
+	}
+	d := dynDimOffset - len(dynDims.names)
+	dynDims.ids[name] = d
+	dynDims.names = append(dynDims.names, name)
+	return d
+}
+
+func isDynDim(d int) bool { return d <= dynDimOffset }
+
+// dynDimName returns the name of the symbolic dimension d
+func dynDimName(d int) string {
+	dynDims.Lock()
+	defer dynDims.Unlock()
+
+	i := dynDimOffset - d
+	if i < 0 || i >= len(dynDims.names) {
+		return "?"
+	}
+	return dynDims.names[i]
+}
+
+func hasDynDims(s tensor.Shape) bool {
+	for _, d := range s {
+		if isDynDim(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVector, isColVec and isRowVec are the methods of tensor.Shape that also work with symbolic dimensions,
+// which are never assumed to be 1.
+func isVector(s tensor.Shape) bool {
+	return isColVec(s) || isRowVec(s) || (len(s) == 1 && (s[0] > 1 || isDynDim(s[0])))
+}
+
+func isColVec(s tensor.Shape) bool { return len(s) == 2 && s[1] == 1 && (s[0] > 1 || isDynDim(s[0])) }
+func isRowVec(s tensor.Shape) bool { return len(s) == 2 && s[0] == 1 && (s[1] > 1 || isDynDim(s[1])) }
+
+// sliceDynShape slices a shape with symbolic dimensions. The symbolic dimensions that are sliced are assumed to be big enough for the
+// slices, and the ones that are not keep their symbols.
+func sliceDynShape(s tensor.Shape, slices []tensor.Slice) (retVal tensor.Shape, err error) {
+	// every symbolic dimension stands in as a size no slice could reach
+	const placeholder = 1 << 30
+	static := s.Clone()
+	for i, d := range s {
+		if !isDynDim(d) {
+			continue
+		}
+		static[i] = placeholder - i
+		if i >= len(slices) || slices[i] == nil {
+			continue
+		}
+
+		// a slice that runs to the end of a symbolic dimension has a size that is only known at run time
+		var start, end, step int
+		if start, end, step, err = tensor.SliceDetails(slices[i], static[i]); err != nil {
+			return nil, err
+		}
+		if end == static[i] && (start != 0 || step != 1) {
+			return nil, errors.Errorf("Unable to slice the symbolic dimension %q of %v with %v", dynDimName(d), s, slices[i])
+		}
+	}
+
+	if retVal, err = static.S(slices...); err != nil {
+		return nil, err
+	}
+	for i, d := range retVal {
+		if d > placeholder-len(s) {
+			retVal[i] = s[placeholder-d]
+		}
+	}
+	return
+}
+
+// unifyDims binds the symbolic dimensions of s to the sizes found in actual. It returns an error if actual does not fit s,
+// or if a symbolic dimension has already been bound to a different size.
+func unifyDims(s, actual tensor.Shape, dims map[int]int) error {
+	if len(s) != len(actual) {
+		return errors.Errorf("Shape mismatch. Expected %v. Got %v", s, actual)
+	}
+
+	for i, d := range s {
+		switch {
+		case !isDynDim(d):
+			if d != actual[i] {
+				return errors.Errorf("Shape mismatch. Expected %v. Got %v", s, actual)
+			}
+		default:
+			if size, ok := dims[d]; ok && size != actual[i] {
+				return errors.Errorf("Symbolic dimension %q is %d and %d at the same time", dynDimName(d), size, actual[i])
+			}
+			dims[d] = actual[i]
+		}
+	}
+	return nil
+}
+
+// resolveShape replaces the symbolic dimensions of s with the sizes in dims
+func resolveShape(s tensor.Shape, dims map[int]int) (retVal tensor.Shape, err error) {
+	if !hasDynDims(s) {
+		return s, nil
+	}
+
+	retVal = make(tensor.Shape, len(s))
+	for i, d := range s {
+		if !isDynDim(d) {
+			retVal[i] = d
+			continue
+		}
+
+		size, ok := dims[d]
+		if !ok {
+			return nil, errors.Errorf("Unable to resolve symbolic dimension %q of %v", dynDimName(d), s)
+		}
+		retVal[i] = size
+	}
+	return
+}
@@ -0,0 +1,171 @@
+package gorgonia
+
+import (
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestDyn(t *testing.T) {
+	if Dyn("batch") != Dyn("batch") {
+		t.Error("Expected the same name to return the same symbolic dimension")
+	}
+	if Dyn("batch") == Dyn("time") {
+		t.Error("Expected different names to return different symbolic dimensions")
+	}
+	if !isDynDim(Dyn("batch")) || isDynDim(3) {
+		t.Error("isDynDim is wrong")
+	}
+	if dynDimName(Dyn("time")) != "time" {
+		t.Errorf("Expected the name to be \"time\". Got %q instead", dynDimName(Dyn("time")))
+	}
+
+	dims := make(map[int]int)
+	s := tensor.Shape{Dyn("batch"), 3}
+	if err := unifyDims(s, tensor.Shape{4, 3}, dims); err != nil {
+		t.Fatal(err)
+	}
+	if err := unifyDims(s, tensor.Shape{5, 3}, dims); err == nil {
+		t.Error("Expected an error when a symbolic dimension is bound to two sizes")
+	}
+	if err := unifyDims(s, tensor.Shape{4, 2}, dims); err == nil {
+		t.Error("Expected an error when a static dimension does not match")
+	}
+	resolved, err := resolveShape(tensor.Shape{2, Dyn("batch")}, dims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resolved.Eq(tensor.Shape{2, 4}) {
+		t.Errorf("Expected (2, 4). Got %v instead", resolved)
+	}
+	if _, err = resolveShape(tensor.Shape{Dyn("time")}, dims); err == nil {
+		t.Error("Expected an error when a symbolic dimension is unresolved")
+	}
+}
+
+func TestDynBatch(t *testing.T) {
+	g := NewGraph()
+	x := NewMatrix(g, Float64, WithName("x"), WithShape(Dyn("batch"), 3))
+	w := NewMatrix(g, Float64, WithName("w"), WithShape(3, 2), WithValue(tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6}))))
+	xw := Must(Mul(x, w))
+	if !xw.Shape().Eq(tensor.Shape{Dyn("batch"), 2}) {
+		t.Fatalf("Expected the shape of xw to be (batch, 2). Got %v instead", xw.Shape())
+	}
+
+	flat := Must(Reshape(xw, tensor.Shape{Dyn("flat")}))
+	cost := Must(Sum(flat))
+	grads, err := Grad(cost, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g)
+	for _, batch := range []int{4, 2, 7} {
+		ones := tensor.New(tensor.WithShape(batch, 3), tensor.WithBacking(tensor.Ones(Float64, batch*3).Data()))
+		Let(x, ones)
+		m.Reset()
+		if err = m.RunAll(); err != nil {
+			t.Fatalf("batch %d: %v", batch, err)
+		}
+
+		// every row of xw is [9 12]
+		if got := extractF64(cost.Value()); got != float64(21*batch) {
+			t.Errorf("batch %d: Expected cost to be %v. Got %v instead", batch, 21*batch, got)
+		}
+		for _, v := range extractF64s(grads[0].Value()) {
+			if v != float64(batch) {
+				t.Errorf("batch %d: Expected every gradient of w to be %d. Got %v instead", batch, batch, grads[0].Value())
+				break
+			}
+		}
+	}
+
+	// static dimensions are still checked
+	Let(x, tensor.New(tensor.WithShape(2, 4), tensor.WithBacking(make([]float64, 8))))
+	m.Reset()
+	if err = m.RunAll(); err == nil {
+		t.Error("Expected an error when the static dimensions of the input do not match")
+	}
+}
+
+func TestDynOps(t *testing.T) {
+	type graph struct {
+		g       *ExprGraph
+		x       *Node
+		outputs Nodes
+	}
+	build := func(batch int) (r graph) {
+		r.g = NewGraph()
+		r.x = NewMatrix(r.g, Float64, WithName("x"), WithShape(batch, 3))
+		w := NewMatrix(r.g, Float64, WithName("w"), WithShape(2, 3), WithInit(RangedFrom(-2)))
+		b := NewMatrix(r.g, Float64, WithName("b"), WithShape(1, 2), WithInit(RangedFrom(1)))
+		v := NewVector(r.g, Float64, WithName("v"), WithShape(3), WithInit(RangedFrom(0)))
+
+		xw := Must(Mul(r.x, Must(Transpose(w))))
+		h := Must(Tanh(Must(Broadcast(addOpType, xw, b, NewBroadcastPattern(nil, []byte{0})))))
+		rows := Must(Sum(h, 1))
+		means := Must(Mean(h, 1))
+		xv := Must(Mul(r.x, v))
+		first := Must(Slice(h, nil, S(0)))
+		top := Must(Slice(h, S(0, 1)))
+		cat := Must(Concat(1, h, xw))
+
+		cost := Must(Add(Must(Sum(rows)), Must(Sum(cat))))
+		cost = Must(Add(cost, Must(Sum(Must(HadamardProd(first, means))))))
+		cost = Must(Add(cost, Must(Sum(top))))
+		cost = Must(Add(cost, Must(Mean(Must(HadamardProd(xv, xv))))))
+
+		grads, err := Grad(cost, w, b, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.outputs = append(Nodes{xw, h, rows, means, xv, first, top, cat, cost}, grads...)
+		return
+	}
+
+	dyn := build(Dyn("batch"))
+	batch := Dyn("batch")
+	shapes := []tensor.Shape{{batch, 2}, {batch, 2}, {batch}, {batch}, {batch}, {batch}, {2}, {batch, 4}, scalarShape}
+	for i, s := range shapes {
+		if !dyn.outputs[i].Shape().Eq(s) {
+			t.Errorf("Expected the shape of %v to be %v. Got %v instead", dyn.outputs[i], s, dyn.outputs[i].Shape())
+		}
+	}
+
+	// the same program gives the same values as graphs built for each batch size
+	m := NewTapeMachine(dyn.g)
+	for _, size := range []int{4, 2, 7} {
+		x := tensor.New(tensor.WithShape(size, 3), tensor.WithBacking(Uniform64(-1, 1, size, 3)))
+		static := build(size)
+		Let(static.x, x)
+		if err := NewTapeMachine(static.g).RunAll(); err != nil {
+			t.Fatalf("batch %d: %v", size, err)
+		}
+
+		Let(dyn.x, x)
+		m.Reset()
+		if err := m.RunAll(); err != nil {
+			t.Fatalf("batch %d: %v", size, err)
+		}
+		for i, n := range dyn.outputs {
+			if !ValueClose(n.Value(), static.outputs[i].Value()) {
+				t.Errorf("batch %d: Expected %v to be %v. Got %v instead", size, n, static.outputs[i].Value(), n.Value())
+			}
+		}
+	}
+
+	// symbolic dimensions can't be concatenated
+	if _, err := Concat(0, dyn.outputs[0], dyn.outputs[0]); err == nil {
+		t.Error("Expected an error when concatenating along a symbolic dimension")
+	}
+
+	// nor sliced from an offset to their end
+	if _, err := Slice(dyn.outputs[0], S(1, 1<<30)); err == nil {
+		t.Error("Expected an error when slicing a symbolic dimension to its end")
+	}
+
+	// nor do they have a size before they are resolved
+	if size := calcMemSize(Float64, tensor.Shape{batch, 3}); size != 0 {
+		t.Errorf("Expected the memory size of a shape with symbolic dimensions to be 0. Got %d", size)
+	}
+}
@@ -30,6 +30,24 @@ func WithL2Reg(l2reg float64) SolverOpt {
 		case *VanillaSolver:
 			st.l2reg = l2reg
 			st.useL2Reg = true
+		case *MomentumSolver:
+			st.l2reg = l2reg
+			st.useL2Reg = true
+		case *AdamWSolver:
+			st.l2reg = l2reg
+			st.useL2Reg = true
+		case *AMSGradSolver:
+			st.l2reg = l2reg
+			st.useL2Reg = true
+		case *AdadeltaSolver:
+			st.l2reg = l2reg
+			st.useL2Reg = true
+		case *AdamaxSolver:
+			st.l2reg = l2reg
+			st.useL2Reg = true
+		case *NadamSolver:
+			st.l2reg = l2reg
+			st.useL2Reg = true
 		}
 	}
 	return f
@@ -45,12 +63,30 @@ func WithL1Reg(l1reg float64) SolverOpt {
 		case *VanillaSolver:
 			st.l1reg = l1reg
 			st.useL1Reg = true
+		case *MomentumSolver:
+			st.l1reg = l1reg
+			st.useL1Reg = true
+		case *AdamWSolver:
+			st.l1reg = l1reg
+			st.useL1Reg = true
+		case *AMSGradSolver:
+			st.l1reg = l1reg
+			st.useL1Reg = true
+		case *AdadeltaSolver:
+			st.l1reg = l1reg
+			st.useL1Reg = true
+		case *AdamaxSolver:
+			st.l1reg = l1reg
+			st.useL1Reg = true
+		case *NadamSolver:
+			st.l1reg = l1reg
+			st.useL1Reg = true
 		}
 	}
 	return f
 }
 
-// WithBatchSize sets the batch size for the solver. Currently only Adam and Vanilla (basic SGD) has batch size support
+// WithBatchSize sets the batch size for the solver. Currently RMSProp and AdaGrad have no batch size support
 func WithBatchSize(batch float64) SolverOpt {
 	f := func(s Solver) {
 		switch st := s.(type) {
@@ -58,6 +94,113 @@ func WithBatchSize(batch float64) SolverOpt {
 			st.batch = batch
 		case *VanillaSolver:
 			st.batch = batch
+		case *MomentumSolver:
+			st.batch = batch
+		case *AdamWSolver:
+			st.batch = batch
+		case *AMSGradSolver:
+			st.batch = batch
+		case *AdadeltaSolver:
+			st.batch = batch
+		case *AdamaxSolver:
+			st.batch = batch
+		case *NadamSolver:
+			st.batch = batch
+		}
+	}
+	return f
+}
+
+// WithAccumulationSteps makes the solver accumulate gradients over n calls of Step before applying them. The calls to Step in between
+// leave the derivatives of the nodes untouched, so that the VM may keep summing into them (see WithGradAccumulation).
+// On the nth call, the summed gradients are averaged, applied and then cleared.
+func WithAccumulationSteps(n int) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *RMSPropSolver:
+			st.accum = n
+		case *AdamSolver:
+			st.accum = n
+		case *VanillaSolver:
+			st.accum = n
+		case *AdaGradSolver:
+			st.accum = n
+		case *MomentumSolver:
+			st.accum = n
+		case *AdamWSolver:
+			st.accum = n
+		case *AMSGradSolver:
+			st.accum = n
+		case *AdadeltaSolver:
+			st.accum = n
+		case *AdamaxSolver:
+			st.accum = n
+		case *NadamSolver:
+			st.accum = n
+		}
+	}
+	return f
+}
+
+// WithGlobalNormClip makes the solver scale the gradients so that their global norm is at most maxNorm before each update (see ClipGradsByGlobalNorm).
+// Step returns an error without updating the model if the global norm is NaN or infinite. The LBFGSSolver does not support clipping.
+func WithGlobalNormClip(maxNorm float64) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *RMSPropSolver:
+			st.maxNorm = maxNorm
+		case *AdamSolver:
+			st.maxNorm = maxNorm
+		case *VanillaSolver:
+			st.maxNorm = maxNorm
+		case *MomentumSolver:
+			st.maxNorm = maxNorm
+		case *AdaGradSolver:
+			st.maxNorm = maxNorm
+		case *AdamWSolver:
+			st.maxNorm = maxNorm
+		case *AMSGradSolver:
+			st.maxNorm = maxNorm
+		case *AdadeltaSolver:
+			st.maxNorm = maxNorm
+		case *AdamaxSolver:
+			st.maxNorm = maxNorm
+		case *NadamSolver:
+			st.maxNorm = maxNorm
+		}
+	}
+	return f
+}
+
+// WithParamGroup sets the options of a parameter group. The group is made of the nodes created with WithGroupName(name).
+// The nodes of each group are stepped by a copy of the solver, which is created with the options of the solver followed by the options
+// of the group. This allows, for example, biases to be excluded from weight decay, or pretrained layers to be fine-tuned with a lower learn rate:
+//		s := NewAdamSolver(WithLearnRate(0.01), WithL2Reg(1e-4), WithParamGroup("bias", WithL2Reg(0)), WithParamGroup("pretrained", WithLearnRate(1e-4)))
+//
+// Nodes that are not in any of the parameter groups are stepped with the options of the solver. The LBFGSSolver does not support parameter groups.
+func WithParamGroup(name string, opts ...SolverOpt) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *RMSPropSolver:
+			st.groups = st.groups.add(name, opts)
+		case *AdamSolver:
+			st.groups = st.groups.add(name, opts)
+		case *VanillaSolver:
+			st.groups = st.groups.add(name, opts)
+		case *MomentumSolver:
+			st.groups = st.groups.add(name, opts)
+		case *AdaGradSolver:
+			st.groups = st.groups.add(name, opts)
+		case *AdamWSolver:
+			st.groups = st.groups.add(name, opts)
+		case *AMSGradSolver:
+			st.groups = st.groups.add(name, opts)
+		case *AdadeltaSolver:
+			st.groups = st.groups.add(name, opts)
+		case *AdamaxSolver:
+			st.groups = st.groups.add(name, opts)
+		case *NadamSolver:
+			st.groups = st.groups.add(name, opts)
 		}
 	}
 	return f
@@ -71,6 +214,16 @@ func WithEps(eps float64) SolverOpt {
 			st.eps = eps
 		case *AdamSolver:
 			st.eps = eps
+		case *AdamWSolver:
+			st.eps = eps
+		case *AMSGradSolver:
+			st.eps = eps
+		case *AdadeltaSolver:
+			st.eps = eps
+		case *AdamaxSolver:
+			st.eps = eps
+		case *NadamSolver:
+			st.eps = eps
 		}
 	}
 	return f
@@ -89,6 +242,24 @@ func WithClip(clip float64) SolverOpt {
 		case *VanillaSolver:
 			st.clip = clip
 			st.useClip = true
+		case *MomentumSolver:
+			st.clip = clip
+			st.useClip = true
+		case *AdamWSolver:
+			st.clip = clip
+			st.useClip = true
+		case *AMSGradSolver:
+			st.clip = clip
+			st.useClip = true
+		case *AdadeltaSolver:
+			st.clip = clip
+			st.useClip = true
+		case *AdamaxSolver:
+			st.clip = clip
+			st.useClip = true
+		case *NadamSolver:
+			st.clip = clip
+			st.useClip = true
 		}
 	}
 	return f
@@ -104,39 +275,126 @@ func WithLearnRate(eta float64) SolverOpt {
 			st.eta = eta
 		case *VanillaSolver:
 			st.eta = eta
+		case *MomentumSolver:
+			st.eta = eta
+		case *LBFGSSolver:
+			st.eta = eta
+		case *AdamWSolver:
+			st.eta = eta
+		case *AMSGradSolver:
+			st.eta = eta
+		case *AdadeltaSolver:
+			st.eta = eta
+		case *AdamaxSolver:
+			st.eta = eta
+		case *NadamSolver:
+			st.eta = eta
 		}
 	}
 	return f
 }
 
-// WithBeta1 sets the beta1 param of the solver. Only works with Adam
+// WithBeta1 sets the beta1 param of the solver. Only works with Adam and its variants
 func WithBeta1(beta1 float64) SolverOpt {
 	f := func(s Solver) {
 		switch st := s.(type) {
 		case *AdamSolver:
 			st.beta1 = beta1
+		case *AdamWSolver:
+			st.beta1 = beta1
+		case *AMSGradSolver:
+			st.beta1 = beta1
+		case *AdamaxSolver:
+			st.beta1 = beta1
+		case *NadamSolver:
+			st.beta1 = beta1
 		}
 	}
 	return f
 }
 
-// WithBeta2 sets the beta1 param of the solver. Only works with Adam
+// WithBeta2 sets the beta2 param of the solver. Only works with Adam and its variants
 func WithBeta2(beta2 float64) SolverOpt {
 	f := func(s Solver) {
 		switch st := s.(type) {
 		case *AdamSolver:
 			st.beta2 = beta2
+		case *AdamWSolver:
+			st.beta2 = beta2
+		case *AMSGradSolver:
+			st.beta2 = beta2
+		case *AdamaxSolver:
+			st.beta2 = beta2
+		case *NadamSolver:
+			st.beta2 = beta2
 		}
 	}
 	return f
 }
 
-// WithRho sets the decay parameter of the RMSProp solver
+// WithRho sets the decay parameter of the RMSProp and Adadelta solvers
 func WithRho(rho float64) SolverOpt {
 	f := func(s Solver) {
 		switch st := s.(type) {
 		case *RMSPropSolver:
 			st.decay = rho
+		case *AdadeltaSolver:
+			st.rho = rho
+		}
+	}
+	return f
+}
+
+// WithWeightDecay sets the decoupled weight decay of the AdamW solver
+func WithWeightDecay(decay float64) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *AdamWSolver:
+			st.weightDecay = decay
+		}
+	}
+	return f
+}
+
+// WithHistorySize sets the number of correction pairs the LBFGSSolver keeps to approximate the inverse Hessian
+func WithHistorySize(n int) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *LBFGSSolver:
+			st.history = n
+		}
+	}
+	return f
+}
+
+// WithMaxIterations sets the number of iterations the LBFGSSolver runs in each Step
+func WithMaxIterations(n int) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *LBFGSSolver:
+			st.maxIter = n
+		}
+	}
+	return f
+}
+
+// WithMomentum sets the momentum of the MomentumSolver
+func WithMomentum(momentum float64) SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *MomentumSolver:
+			st.momentum = momentum
+		}
+	}
+	return f
+}
+
+// WithNesterov makes the MomentumSolver use Nesterov's accelerated gradient
+func WithNesterov() SolverOpt {
+	f := func(s Solver) {
+		switch st := s.(type) {
+		case *MomentumSolver:
+			st.nesterov = true
 		}
 	}
 	return f
@@ -150,11 +408,17 @@ type RMSPropSolver struct {
 	l2reg float64 // l2 regularization
 	clip  float64 // clip value
 	eta   float64 // learn rate
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
 
 	useClip, useL2Reg bool
 
 	// unsettable
-	cache []*dualValue
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
 }
 
 // NewRMSPropSolver creates an RMSProp solver with these default values:
@@ -178,6 +442,18 @@ func NewRMSPropSolver(opts ...SolverOpt) *RMSPropSolver {
 //
 // This function will error out if the nodes do not have an associated Grad value.
 func (s *RMSPropSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
 	if s.cache == nil {
 		s.cache = make([]*dualValue, len(model))
 	}
@@ -188,6 +464,7 @@ func (s *RMSPropSolver) Step(model Nodes) (err error) {
 		if !ok {
 			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
 		}
+		densifyGrad(dv)
 
 		var cached *dualValue
 		if cached = s.cache[i]; cached == nil {
@@ -337,12 +614,18 @@ type AdamSolver struct {
 	l1reg float64 // l1 regularization parameter
 	l2reg float64 // l2 regularization parameter
 	batch float64 // batch size
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
 
 	useClip, useL1Reg, useL2Reg bool
 
 	// unsettable
-	iter  int
-	cache []*dualValue
+	iter      int
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
 }
 
 // NewAdamSolver creates an Adam solver with these default values:
@@ -370,6 +653,18 @@ func NewAdamSolver(opts ...SolverOpt) *AdamSolver {
 //
 // This function will error out if the nodes do not have an associated Grad value.
 func (s *AdamSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
 	if s.cache == nil {
 		s.cache = make([]*dualValue, len(model))
 	}
@@ -384,6 +679,13 @@ func (s *AdamSolver) Step(model Nodes) (err error) {
 			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
 		}
 
+		if g, ok := dv.d.(*RowSparse); ok {
+			if err = s.sparseStep(i, dv, g, correction1, correction2); err != nil {
+				return
+			}
+			continue
+		}
+
 		var cached *dualValue
 		if cached = s.cache[i]; cached == nil {
 			if cached, err = dv.clone0(); err != nil {
@@ -672,8 +974,16 @@ type VanillaSolver struct {
 	l1reg float64 // l1 regularization parameter
 	l2reg float64 // l2 regularization parameter
 	batch float64 // batch size
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
 
 	useClip, useL1Reg, useL2Reg bool
+
+	// unsettable
+	accumIter int
+	sched     lrSchedule
+	groups    *paramGroups
 }
 
 // NewVanillaSolver creates a new VanillaSolver with sane-ish default values
@@ -692,11 +1002,24 @@ func NewVanillaSolver(opts ...SolverOpt) *VanillaSolver {
 //
 // This function will error out if the nodes do not have an associated Grad value.
 func (s *VanillaSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
 	for _, n := range model {
 		dv, ok := n.boundTo.(*dualValue)
 		if !ok {
 			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
 		}
+		densifyGrad(dv)
 
 		grad := dv.d
 		weights := dv.Value
@@ -860,36 +1183,66 @@ func (s *VanillaSolver) Step(model Nodes) (err error) {
 	return
 }
 
-// AdaGradSolver is the solver that does adaptive gradient descent. Read the paper: http://jmlr.org/papers/v12/duchi11a.html
-type AdaGradSolver struct {
-	eta   float64 // learn rate
-	eps   float64 // smoothing factor
-	l1Reg float64 // l1reg param
-	l2reg float64 // l2reg param
-	clip  float64 // clip at
+// MomentumSolver is stochastic gradient descent with momentum. A velocity is kept for every node, and the weights move along the velocity
+// instead of the gradient:
+//		v = μ * v + g
+//		w = w - η * v
+//
+// With Nesterov's accelerated gradient (see WithNesterov), the weights are updated with a look ahead along the velocity instead:
+//		w = w - η * (g + μ * v)
+type MomentumSolver struct {
+	eta      float64 // learn rate
+	momentum float64 // momentum
+	clip     float64 // clip gradients
+	l1reg    float64 // l1 regularization parameter
+	l2reg    float64 // l2 regularization parameter
+	batch    float64 // batch size
+	accum    int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
 
-	useL2Reg, useClip bool
+	useClip, useL1Reg, useL2Reg bool
+	nesterov                    bool
 
-	cache []*dualValue
+	// unsettable
+	accumIter int
+	cache     []*dualValue // the velocities are held in .Value
+	sched     lrSchedule
+	groups    *paramGroups
 }
 
-// NewAdaGradSolver creates a new AdaGradSolver with sane-ish default values
-func NewAdaGradSolver(opts ...SolverOpt) *AdaGradSolver {
-	s := &AdaGradSolver{
-		eta: 0.001,
-		eps: 1e-8,
+// NewMomentumSolver creates a new MomentumSolver with these default values:
+//		eta (learn rate)	: 0.001
+//		momentum		: 0.9
+//		batch			: 1
+func NewMomentumSolver(opts ...SolverOpt) *MomentumSolver {
+	s := &MomentumSolver{
+		eta:      0.001,
+		momentum: 0.9,
+		batch:    1,
 	}
-
 	for _, opt := range opts {
 		opt(s)
 	}
 	return s
 }
 
-// Step steps through each node in the model and applies the Adaptive Gradient gradient descent algorithm on the value.
+// Step steps through each node in the model and applies gradient descent with momentum on the value.
 //
 // This function will error out if the nodes do not have an associated Grad value.
-func (s *AdaGradSolver) Step(model Nodes) (err error) {
+func (s *MomentumSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
 	if s.cache == nil {
 		s.cache = make([]*dualValue, len(model))
 	}
@@ -899,6 +1252,7 @@ func (s *AdaGradSolver) Step(model Nodes) (err error) {
 		if !ok {
 			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
 		}
+		densifyGrad(dv)
 
 		var cached *dualValue
 		if cached = s.cache[i]; cached == nil {
@@ -911,82 +1265,348 @@ func (s *AdaGradSolver) Step(model Nodes) (err error) {
 		grad := dv.d
 		weights := dv.Value
 
-		cv := cached.Value
-
-		switch cw := cv.(type) {
+		switch w := weights.(type) {
 		case *tensor.Dense:
-			var w, g, c, g2, regularized tensor.Tensor
+			g := grad.(*tensor.Dense)
+			v := cached.Value.(*tensor.Dense)
 
-			var l2reg, clip, negClip, eps, eta interface{}
-			switch cw.Dtype() {
+			var l1reg, l2reg, clip, negClip, momentum, eta interface{}
+			var onePerBatch interface{}
+			switch w.Dtype() {
 			case tensor.Float64:
+				l1reg = s.l1reg
 				l2reg = s.l2reg
 				clip = s.clip
 				negClip = -s.clip
-				eps = s.eps
+				momentum = s.momentum
 				eta = -s.eta
+				onePerBatch = float64(1) / s.batch
 			case tensor.Float32:
+				l1reg = float32(s.l1reg)
 				l2reg = float32(s.l2reg)
 				clip = float32(s.clip)
 				negClip = float32(-s.clip)
-				eps = float32(s.eps)
+				momentum = float32(s.momentum)
 				eta = float32(-s.eta)
+				onePerBatch = float32(1) / float32(s.batch)
 			}
 
-			g = grad.(*tensor.Dense)
-			if g2, err = tensor.Square(g); err != nil {
-				return errors.Wrap(err, pointWiseSquareFail)
-			}
+			// prep the regularization of gradients
+			var l1regs, l2regs tensor.Tensor
+			if s.useL1Reg {
+				if l1regs, err = tensor.Sign(w); err != nil {
+					return errors.Wrap(err, signFail)
+				}
 
-			c = cw
-			tensor.Add(c, g2, tensor.UseUnsafe())
-			defer returnTensor(g2)
+				if l1regs, err = tensor.Mul(l1reg, l1regs, tensor.UseUnsafe()); err != nil {
+					return errors.Wrap(err, pointWiseMulFail)
+				}
 
-			if s.useClip {
-				if _, err = tensor.Clamp(g, negClip, clip, tensor.UseUnsafe()); err != nil {
-					return errors.Wrap(err, clampFail)
+				if _, err = tensor.Add(g, l1regs, tensor.UseUnsafe()); err != nil {
+					return errors.Wrap(err, addFail)
 				}
-			}
 
-			// update
-			var upd tensor.Tensor
-			if upd, err = tensor.Add(c, eps); err != nil {
-				return errors.Wrap(err, addFail)
+				defer returnTensor(l1regs)
 			}
 
-			if _, err = tensor.InvSqrt(upd, tensor.UseUnsafe()); err != nil {
-				return errors.Wrap(err, invSqrtFail)
-			}
-			if _, err = tensor.Mul(g, eta, tensor.UseUnsafe()); err != nil {
-				return errors.Wrap(err, pointWiseMulFail)
-			}
+			if s.useL2Reg {
+				if l2regs, err = tensor.Mul(l2reg, w); err != nil {
+					return errors.Wrap(err, pointWiseMulFail)
+				}
 
-			if _, err = tensor.Mul(upd, g, tensor.UseUnsafe()); err != nil {
-				return errors.Wrap(err, pointWiseMulFail)
-			}
+				if _, err = tensor.Add(g, l2regs, tensor.UseUnsafe()); err != nil {
+					return errors.Wrap(err, addFail)
+				}
 
-			// regularize
-			w = weights.(*tensor.Dense)
+				defer returnTensor(l2regs)
+			}
 
-			if s.useL2Reg {
-				if regularized, err = tensor.Mul(w, l2reg); err != nil {
+			if s.batch > 1 {
+				if _, err = tensor.Mul(onePerBatch, g, tensor.UseUnsafe()); err != nil {
 					return errors.Wrap(err, pointWiseMulFail)
 				}
+			}
 
-				if _, err = tensor.Sub(upd, regularized, tensor.UseUnsafe()); err != nil {
-					return errors.Wrap(err, subFail)
+			if s.useClip && s.clip > 0 {
+				if _, err = tensor.Clamp(g, negClip, clip, tensor.UseUnsafe()); err != nil {
+					return errors.Wrap(err, clampFail)
 				}
-
-				defer returnTensor(regularized)
 			}
 
-			if _, err = tensor.Add(w, upd, tensor.UseUnsafe()); err != nil {
+			// v = μ * v + g
+			if _, err = tensor.Mul(momentum, v, tensor.UseUnsafe()); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			if _, err = tensor.Add(v, g, tensor.UseUnsafe()); err != nil {
 				return errors.Wrap(err, addFail)
 			}
-			defer returnTensor(upd)
 
-			// zero all
-			g.Zero()
+			upd := v
+			if s.nesterov {
+				// g + μ * v
+				if _, err = tensor.Mul(momentum, v, tensor.WithIncr(g)); err != nil {
+					return errors.Wrap(err, pointWiseMulFail)
+				}
+				upd = g
+			}
+
+			if _, err = tensor.Mul(eta, upd, tensor.WithIncr(w)); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+
+			g.Zero()
+
+		case *F32:
+			g := grad.(*F32).any()
+			v := cached.Value.(*F32).any()
+			wv := w.any()
+
+			l1reg := float32(s.l1reg)
+			l2reg := float32(s.l2reg)
+			batch := float32(s.batch)
+			clip := float32(s.clip)
+			momentum := float32(s.momentum)
+			eta := float32(s.eta)
+
+			if s.useL1Reg {
+				if wv < 0 {
+					l1reg = -l1reg
+				}
+				g += l1reg
+			}
+
+			if s.useL2Reg {
+				l2reg *= wv
+				g += l2reg
+			}
+
+			if batch > 1 {
+				g *= (1 / batch)
+			}
+
+			if s.useClip && s.clip > 0 {
+				if g > clip {
+					g = clip
+				} else if g < -clip {
+					g = -clip
+				}
+			}
+
+			v = momentum*v + g
+			upd := v
+			if s.nesterov {
+				upd = g + momentum*v
+			}
+			wv -= eta * upd
+
+			cached.Value, _ = anyToScalar(v)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float32)
+		case *F64:
+			g := grad.(*F64).any()
+			v := cached.Value.(*F64).any()
+			wv := w.any()
+
+			l1reg := s.l1reg
+			l2reg := s.l2reg
+			batch := s.batch
+			clip := s.clip
+			momentum := s.momentum
+			eta := s.eta
+
+			if s.useL1Reg {
+				if wv < 0 {
+					l1reg = -l1reg
+				}
+				g += l1reg
+			}
+
+			if s.useL2Reg {
+				l2reg *= wv
+				g += l2reg
+			}
+
+			if batch > 1 {
+				g *= (1 / batch)
+			}
+
+			if s.useClip && s.clip > 0 {
+				if g > clip {
+					g = clip
+				} else if g < -clip {
+					g = -clip
+				}
+			}
+
+			v = momentum*v + g
+			upd := v
+			if s.nesterov {
+				upd = g + momentum*v
+			}
+			wv -= eta * upd
+
+			cached.Value, _ = anyToScalar(v)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float64)
+		default:
+			return errors.Errorf(nyiFail, "MomentumSolver.step", w)
+		}
+	}
+	return
+}
+
+// AdaGradSolver is the solver that does adaptive gradient descent. Read the paper: http://jmlr.org/papers/v12/duchi11a.html
+type AdaGradSolver struct {
+	eta   float64 // learn rate
+	eps   float64 // smoothing factor
+	l1Reg float64 // l1reg param
+	l2reg float64 // l2reg param
+	clip  float64 // clip at
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
+
+	useL2Reg, useClip bool
+
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
+}
+
+// NewAdaGradSolver creates a new AdaGradSolver with sane-ish default values
+func NewAdaGradSolver(opts ...SolverOpt) *AdaGradSolver {
+	s := &AdaGradSolver{
+		eta: 0.001,
+		eps: 1e-8,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step steps through each node in the model and applies the Adaptive Gradient gradient descent algorithm on the value.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *AdaGradSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
+	if s.cache == nil {
+		s.cache = make([]*dualValue, len(model))
+	}
+
+	for i, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+
+		if g, ok := dv.d.(*RowSparse); ok {
+			if err = s.sparseStep(i, dv, g); err != nil {
+				return
+			}
+			continue
+		}
+
+		var cached *dualValue
+		if cached = s.cache[i]; cached == nil {
+			if cached, err = dv.clone0(); err != nil {
+				return errors.Wrap(err, clone0Fail)
+			}
+			s.cache[i] = cached
+		}
+
+		grad := dv.d
+		weights := dv.Value
+
+		cv := cached.Value
+
+		switch cw := cv.(type) {
+		case *tensor.Dense:
+			var w, g, c, g2, regularized tensor.Tensor
+
+			var l2reg, clip, negClip, eps, eta interface{}
+			switch cw.Dtype() {
+			case tensor.Float64:
+				l2reg = s.l2reg
+				clip = s.clip
+				negClip = -s.clip
+				eps = s.eps
+				eta = -s.eta
+			case tensor.Float32:
+				l2reg = float32(s.l2reg)
+				clip = float32(s.clip)
+				negClip = float32(-s.clip)
+				eps = float32(s.eps)
+				eta = float32(-s.eta)
+			}
+
+			g = grad.(*tensor.Dense)
+			if g2, err = tensor.Square(g); err != nil {
+				return errors.Wrap(err, pointWiseSquareFail)
+			}
+
+			c = cw
+			tensor.Add(c, g2, tensor.UseUnsafe())
+			defer returnTensor(g2)
+
+			if s.useClip {
+				if _, err = tensor.Clamp(g, negClip, clip, tensor.UseUnsafe()); err != nil {
+					return errors.Wrap(err, clampFail)
+				}
+			}
+
+			// update
+			var upd tensor.Tensor
+			if upd, err = tensor.Add(c, eps); err != nil {
+				return errors.Wrap(err, addFail)
+			}
+
+			if _, err = tensor.InvSqrt(upd, tensor.UseUnsafe()); err != nil {
+				return errors.Wrap(err, invSqrtFail)
+			}
+			if _, err = tensor.Mul(g, eta, tensor.UseUnsafe()); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+
+			if _, err = tensor.Mul(upd, g, tensor.UseUnsafe()); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+
+			// regularize
+			w = weights.(*tensor.Dense)
+
+			if s.useL2Reg {
+				if regularized, err = tensor.Mul(w, l2reg); err != nil {
+					return errors.Wrap(err, pointWiseMulFail)
+				}
+
+				if _, err = tensor.Sub(upd, regularized, tensor.UseUnsafe()); err != nil {
+					return errors.Wrap(err, subFail)
+				}
+
+				defer returnTensor(regularized)
+			}
+
+			if _, err = tensor.Add(w, upd, tensor.UseUnsafe()); err != nil {
+				return errors.Wrap(err, addFail)
+			}
+			defer returnTensor(upd)
+
+			// zero all
+			g.Zero()
 
 		case *F32:
 			var w, g, c float32
@@ -1063,3 +1683,1502 @@ func (s *AdaGradSolver) Step(model Nodes) (err error) {
 
 	return
 }
+
+// LBFGSClosure re-evaluates a model for the LBFGSSolver. It is called after the solver has updated the weights of the model and cleared
+// their gradients. It should reset and run the VM, which computes the cost and the gradients of the model, and return the cost.
+type LBFGSClosure func() (cost float64, err error)
+
+// LBFGSSolver is a full batch, limited memory quasi-Newton optimizer (L-BFGS). Nocedal & Wright, Numerical Optimization, chapters 3 and 7.
+//
+// The parameters of the model are treated as one flat vector. Each Step runs a number of iterations, each one searching along the
+// direction given by the approximate inverse Hessian for a step length that satisfies the strong Wolfe conditions. Because the line search
+// needs to evaluate the cost at several points, the solver takes an LBFGSClosure that re-evaluates the model.
+type LBFGSSolver struct {
+	eta       float64 // initial step length
+	history   int     // number of correction pairs to keep
+	maxIter   int     // number of iterations per Step
+	maxEvals  int     // number of evaluations per line search
+	tolGrad   float64 // stop when the largest gradient is smaller than this
+	tolChange float64 // stop when the cost or the weights change less than this
+	c1, c2    float64 // the constants of the Wolfe conditions
+
+	closure LBFGSClosure
+
+	// unsettable
+	s, y  [][]float64 // correction pairs
+	rho   []float64
+	evals int // number of times the closure has been called
+	sched lrSchedule
+}
+
+// NewLBFGSSolver creates a new LBFGSSolver that uses the closure to evaluate the model, with these default values:
+//		eta (initial step length)	: 1
+//		history				: 10
+//		max iterations			: 20
+func NewLBFGSSolver(closure LBFGSClosure, opts ...SolverOpt) *LBFGSSolver {
+	s := &LBFGSSolver{
+		eta:       1,
+		history:   10,
+		maxIter:   20,
+		maxEvals:  25,
+		tolGrad:   1e-7,
+		tolChange: 1e-9,
+		c1:        1e-4,
+		c2:        0.9,
+		closure:   closure,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step evaluates the model at its current weights, then runs the L-BFGS iterations on it. When Step returns, the model holds the new weights
+// and their gradients are cleared. The correction pairs are kept across calls to Step.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *LBFGSSolver) Step(model Nodes) (err error) {
+	if s.closure == nil {
+		return errors.New("LBFGSSolver requires a closure to re-evaluate the model")
+	}
+	defer s.sched.apply(&s.eta)()
+
+	var f float64
+	if f, err = s.evaluate(model, nil); err != nil {
+		return
+	}
+
+	var x, g []float64
+	if x, g, err = lbfgsFlattenModel(model); err != nil {
+		return
+	}
+
+	for iter := 0; iter < s.maxIter; iter++ {
+		if lbfgsMaxAbs(g) <= s.tolGrad {
+			break
+		}
+
+		d := s.direction(g)
+		gtd := lbfgsDot(g, d)
+		if gtd > -s.tolChange {
+			// not a descent direction: the curvature information is no good. Start over with steepest descent
+			s.s, s.y, s.rho = nil, nil, nil
+			d = s.direction(g)
+			gtd = lbfgsDot(g, d)
+		}
+
+		t := s.eta
+		if len(s.s) == 0 {
+			t = math.Min(1, 1/lbfgsSumAbs(g)) * s.eta
+		}
+
+		var fNew float64
+		var xNew, gNew []float64
+		if t, fNew, xNew, gNew, err = s.lineSearch(model, x, d, f, gtd, t); err != nil {
+			return
+		}
+
+		// update the correction pairs
+		sk := make([]float64, len(x))
+		yk := make([]float64, len(x))
+		for i := range x {
+			sk[i] = xNew[i] - x[i]
+			yk[i] = gNew[i] - g[i]
+		}
+		if ys := lbfgsDot(yk, sk); ys > 1e-10 && s.history > 0 {
+			if len(s.s) == s.history {
+				s.s, s.y, s.rho = s.s[1:], s.y[1:], s.rho[1:]
+			}
+			s.s = append(s.s, sk)
+			s.y = append(s.y, yk)
+			s.rho = append(s.rho, 1/ys)
+		}
+
+		converged := math.Abs(fNew-f) < s.tolChange || math.Abs(t)*lbfgsMaxAbs(d) < s.tolChange
+		x, g, f = xNew, gNew, fNew
+		if converged {
+			break
+		}
+	}
+
+	zeroGrads(model)
+	return nil
+}
+
+// direction computes -H⋅g with the two loop recursion.
+func (s *LBFGSSolver) direction(g []float64) []float64 {
+	q := make([]float64, len(g))
+	for i, v := range g {
+		q[i] = -v
+	}
+
+	k := len(s.s)
+	alpha := make([]float64, k)
+	for i := k - 1; i >= 0; i-- {
+		alpha[i] = s.rho[i] * lbfgsDot(s.s[i], q)
+		lbfgsAxpy(-alpha[i], s.y[i], q)
+	}
+
+	if k > 0 {
+		// scale the initial Hessian by γ = sᵀy/yᵀy
+		gamma := 1 / (s.rho[k-1] * lbfgsDot(s.y[k-1], s.y[k-1]))
+		for i := range q {
+			q[i] *= gamma
+		}
+	}
+
+	for i := 0; i < k; i++ {
+		beta := s.rho[i] * lbfgsDot(s.y[i], q)
+		lbfgsAxpy(alpha[i]-beta, s.s[i], q)
+	}
+	return q
+}
+
+// lineSearch finds a step length t along d that satisfies the strong Wolfe conditions (Nocedal & Wright, algorithms 3.5 and 3.6).
+// The model is left with the weights of the returned step.
+func (s *LBFGSSolver) lineSearch(model Nodes, x, d []float64, f0, gtd0, t float64) (tRet, f float64, xRet, g []float64, err error) {
+	xt := make([]float64, len(x))
+	eval := func(t float64) (f float64, g []float64, gtd float64, err error) {
+		for i := range x {
+			xt[i] = x[i] + t*d[i]
+		}
+		if f, err = s.evaluate(model, xt); err != nil {
+			return
+		}
+		if _, g, err = lbfgsFlattenModel(model); err != nil {
+			return
+		}
+		return f, g, lbfgsDot(g, d), nil
+	}
+
+	var gtd float64
+	tPrev, fPrev, gPrev, gtdPrev := 0.0, f0, []float64(nil), gtd0
+	var lo, hi, flo, fhi, gtdlo, gtdhi float64
+	var glo []float64
+	bracketed := false
+	for i := 0; i < s.maxEvals; i++ {
+		if f, g, gtd, err = eval(t); err != nil {
+			return
+		}
+		switch {
+		case f > f0+s.c1*t*gtd0 || (i > 0 && f >= fPrev):
+			lo, hi, flo, fhi, gtdlo, gtdhi, glo = tPrev, t, fPrev, f, gtdPrev, gtd, gPrev
+			bracketed = true
+		case math.Abs(gtd) <= -s.c2*gtd0:
+			return t, f, xt, g, nil
+		case gtd >= 0:
+			lo, hi, flo, fhi, gtdlo, gtdhi, glo = t, tPrev, f, fPrev, gtd, gtdPrev, g
+			bracketed = true
+		}
+		if bracketed {
+			break
+		}
+		tPrev, fPrev, gPrev, gtdPrev = t, f, g, gtd
+		t *= 2
+	}
+	if !bracketed {
+		return t, f, xt, g, nil
+	}
+
+	// zoom
+	last := hi
+	for i := 0; i < s.maxEvals && math.Abs(hi-lo)*lbfgsMaxAbs(d) >= s.tolChange; i++ {
+		t = lbfgsCubicMin(lo, flo, gtdlo, hi, fhi, gtdhi)
+		if f, g, gtd, err = eval(t); err != nil {
+			return
+		}
+		last = t
+
+		switch {
+		case f > f0+s.c1*t*gtd0 || f >= flo:
+			hi, fhi, gtdhi = t, f, gtd
+		case math.Abs(gtd) <= -s.c2*gtd0:
+			return t, f, xt, g, nil
+		default:
+			if gtd*(hi-lo) >= 0 {
+				hi, fhi, gtdhi = lo, flo, gtdlo
+			}
+			lo, flo, gtdlo, glo = t, f, gtd, g
+		}
+	}
+
+	// settle for the best step found so far
+	if last != lo || glo == nil {
+		if f, g, _, err = eval(lo); err != nil {
+			return
+		}
+		return lo, f, xt, g, nil
+	}
+	return lo, flo, xt, glo, nil
+}
+
+// evaluate sets the weights of the model to x (if any), clears the gradients and calls the closure.
+func (s *LBFGSSolver) evaluate(model Nodes, x []float64) (f float64, err error) {
+	if x != nil {
+		if err = s.setWeights(model, x); err != nil {
+			return
+		}
+	}
+	zeroGrads(model)
+	s.evals++
+	return s.closure()
+}
+
+func (s *LBFGSSolver) setWeights(model Nodes, x []float64) error {
+	var i int
+	for _, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+
+		switch w := dv.Value.(type) {
+		case *tensor.Dense:
+			switch data := w.Data().(type) {
+			case []float64:
+				i += copy(data, x[i:])
+			case []float32:
+				for j := range data {
+					data[j] = float32(x[i])
+					i++
+				}
+			default:
+				return errors.Errorf(nyiFail, "LBFGSSolver", w.Dtype())
+			}
+		case *F64:
+			dv.Value, _ = anyToScalar(x[i])
+			i++
+		case *F32:
+			dv.Value, _ = anyToScalar(float32(x[i]))
+			i++
+		default:
+			return errors.Errorf(nyiTypeFail, "LBFGSSolver", dv.Value)
+		}
+	}
+	return nil
+}
+
+// lbfgsFlattenModel copies the weights and gradients of the model into two flat slices.
+func lbfgsFlattenModel(model Nodes) (x, g []float64, err error) {
+	for _, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return nil, nil, errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+
+		for _, v := range []Value{dv.Value, dv.d} {
+			var vals []float64
+			switch vt := v.(type) {
+			case *tensor.Dense:
+				switch data := vt.Data().(type) {
+				case []float64:
+					vals = append([]float64(nil), data...)
+				case []float32:
+					vals = make([]float64, len(data))
+					for i, f := range data {
+						vals[i] = float64(f)
+					}
+				default:
+					return nil, nil, errors.Errorf(nyiFail, "LBFGSSolver", vt.Dtype())
+				}
+			case *F64:
+				vals = []float64{vt.any()}
+			case *F32:
+				vals = []float64{float64(vt.any())}
+			default:
+				return nil, nil, errors.Errorf(nyiTypeFail, "LBFGSSolver", v)
+			}
+
+			if v == dv.Value {
+				x = append(x, vals...)
+			} else {
+				g = append(g, vals...)
+			}
+		}
+	}
+	return
+}
+
+// lbfgsCubicMin finds the minimizer of the cubic interpolating f and its derivative at x1 and x2 (Nocedal & Wright, equation 3.59).
+// It falls back to bisection when the minimizer is not safely inside the interval.
+func lbfgsCubicMin(x1, f1, g1, x2, f2, g2 float64) float64 {
+	lo, hi := math.Min(x1, x2), math.Max(x1, x2)
+	mid := (lo + hi) / 2
+
+	d1 := g1 + g2 - 3*(f1-f2)/(x1-x2)
+	d2sq := d1*d1 - g1*g2
+	if d2sq < 0 {
+		return mid
+	}
+	d2 := math.Sqrt(d2sq)
+	if x2 < x1 {
+		d2 = -d2
+	}
+	t := x2 - (x2-x1)*(g2+d2-d1)/(g2-g1+2*d2)
+
+	margin := 0.1 * (hi - lo)
+	if math.IsNaN(t) || t < lo+margin || t > hi-margin {
+		return mid
+	}
+	return t
+}
+
+func lbfgsDot(a, b []float64) (retVal float64) {
+	for i, v := range a {
+		retVal += v * b[i]
+	}
+	return
+}
+
+// lbfgsAxpy computes y += alpha * x
+func lbfgsAxpy(alpha float64, x, y []float64) {
+	for i, v := range x {
+		y[i] += alpha * v
+	}
+}
+
+func lbfgsMaxAbs(a []float64) (retVal float64) {
+	for _, v := range a {
+		retVal = math.Max(retVal, math.Abs(v))
+	}
+	return
+}
+
+func lbfgsSumAbs(a []float64) (retVal float64) {
+	for _, v := range a {
+		retVal += math.Abs(v)
+	}
+	return
+}
+
+// gradPrep holds the options with which the AdamW, AMSGrad, Adadelta, Adamax and Nadam solvers prepare the gradients before an update:
+// the gradients are regularized, scaled by the batch size and clipped, the same way the AdamSolver does.
+type gradPrep struct {
+	clip  float64 // clip gradients
+	l1reg float64 // l1 regularization parameter
+	l2reg float64 // l2 regularization parameter
+	batch float64 // batch size
+
+	useClip, useL1Reg, useL2Reg bool
+}
+
+// dense prepares g, the gradient of the weights w, in place
+func (p *gradPrep) dense(w, g *tensor.Dense) (err error) {
+	dt := w.Dtype()
+	if p.useL1Reg {
+		var l1regs tensor.Tensor
+		if l1regs, err = tensor.Sign(w); err != nil {
+			return errors.Wrap(err, signFail)
+		}
+		defer returnTensor(l1regs)
+
+		if _, err = tensor.Mul(scalarOf(dt, p.l1reg), l1regs, tensor.UseUnsafe()); err != nil {
+			return errors.Wrap(err, pointWiseMulFail)
+		}
+		if _, err = tensor.Add(g, l1regs, tensor.UseUnsafe()); err != nil {
+			return errors.Wrap(err, addFail)
+		}
+	}
+
+	if p.useL2Reg {
+		if _, err = tensor.Mul(scalarOf(dt, p.l2reg), w, tensor.WithIncr(g)); err != nil {
+			return errors.Wrap(err, pointWiseMulFail)
+		}
+	}
+
+	if p.batch > 1 {
+		if _, err = tensor.Mul(scalarOf(dt, 1/p.batch), g, tensor.UseUnsafe()); err != nil {
+			return errors.Wrap(err, pointWiseMulFail)
+		}
+	}
+
+	if p.useClip && p.clip > 0 {
+		if _, err = tensor.Clamp(g, scalarOf(dt, -p.clip), scalarOf(dt, p.clip), tensor.UseUnsafe()); err != nil {
+			return errors.Wrap(err, clampFail)
+		}
+	}
+	return nil
+}
+
+// f64 returns the prepared gradient g of the weight w
+func (p *gradPrep) f64(w, g float64) float64 {
+	if p.useL1Reg {
+		g += p.l1reg * signum(w)
+	}
+	if p.useL2Reg {
+		g += p.l2reg * w
+	}
+	if p.batch > 1 {
+		g /= p.batch
+	}
+	if p.useClip && p.clip > 0 {
+		g = math.Max(-p.clip, math.Min(p.clip, g))
+	}
+	return g
+}
+
+// f32 returns the prepared gradient g of the weight w
+func (p *gradPrep) f32(w, g float32) float32 {
+	if p.useL1Reg {
+		g += float32(p.l1reg) * float32(signum(float64(w)))
+	}
+	if p.useL2Reg {
+		g += float32(p.l2reg) * w
+	}
+	if p.batch > 1 {
+		g /= float32(p.batch)
+	}
+	if p.useClip && p.clip > 0 {
+		clip := float32(p.clip)
+		g = math32.Max(-clip, math32.Min(clip, g))
+	}
+	return g
+}
+
+// scalarOf returns v as a scalar of the dtype, so that it may be used in the tensor ops on tensors of that dtype
+func scalarOf(dt tensor.Dtype, v float64) interface{} {
+	if dt == tensor.Float32 {
+		return float32(v)
+	}
+	return v
+}
+
+// maxInto sets a to the elementwise maximum of a and b
+func maxInto(a, b *tensor.Dense) (err error) {
+	var mask, diff tensor.Tensor
+	if mask, err = tensor.Gt(b, a, tensor.AsSameType()); err != nil {
+		return errors.Wrap(err, gtFail)
+	}
+	defer returnTensor(mask)
+	if diff, err = tensor.Sub(b, a); err != nil {
+		return errors.Wrap(err, subFail)
+	}
+	defer returnTensor(diff)
+
+	// a += (b > a) * (b - a)
+	if _, err = tensor.Mul(mask, diff, tensor.WithIncr(a)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	return nil
+}
+
+// moments updates the running averages of the gradient g, m and v, in place:
+//		m = β_1 * m + (1 - β_1) * g
+//		v = β_2 * v + (1 - β_2) * g^2
+func moments(m, v, g *tensor.Dense, beta1, beta2 float64) (err error) {
+	dt := g.Dtype()
+	if _, err = tensor.Mul(scalarOf(dt, beta1), m, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	if _, err = tensor.Mul(scalarOf(dt, 1-beta1), g, tensor.WithIncr(m)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+
+	var g2 tensor.Tensor
+	if g2, err = tensor.Mul(g, g); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	defer returnTensor(g2)
+	if _, err = tensor.Mul(scalarOf(dt, beta2), v, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	if _, err = tensor.Mul(scalarOf(dt, 1-beta2), g2, tensor.WithIncr(v)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	return nil
+}
+
+// divRMS divides mHats in place by √v̂ + ε, where v̂ is v scaled by correctionV2, the reciprocal of its bias correction
+func divRMS(mHats, v *tensor.Dense, correctionV2, eps float64) (err error) {
+	dt := v.Dtype()
+	var vHats tensor.Tensor
+	if vHats, err = tensor.Mul(scalarOf(dt, correctionV2), v); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	defer returnTensor(vHats)
+
+	if _, err = tensor.Sqrt(vHats, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, sqrtFail)
+	}
+	if _, err = tensor.Add(scalarOf(dt, eps), vHats, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, addFail)
+	}
+	if _, err = tensor.Div(mHats, vHats, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, divFail)
+	}
+	return nil
+}
+
+// cached returns the cache of the ith node of the model, creating it on first use
+func cached(cache []*dualValue, i int, dv *dualValue) (retVal *dualValue, err error) {
+	if retVal = cache[i]; retVal == nil {
+		if retVal, err = dv.clone0(); err != nil {
+			return nil, errors.Wrap(err, clone0Fail)
+		}
+		cache[i] = retVal
+	}
+	return
+}
+
+// AdamWSolver is the Adam solver with decoupled weight decay. Paper: https://arxiv.org/abs/1711.05101
+//
+// Unlike WithL2Reg, which adds the decay to the gradients (and so gets scaled by the adaptive learn rates), the weight decay is applied
+// directly to the weights:
+//		w = w - η * (m̂ / (√v̂ + ε) + λw)
+//
+// Like the AdamSolver, the cache's *dualValues hold the means of gradients (in .Value) and the variances of the gradients (in .d).
+type AdamWSolver struct {
+	gradPrep
+	eta         float64 // learn rate
+	eps         float64 // smoothing
+	beta1       float64 // modifier for means
+	beta2       float64 // modifier for variances
+	weightDecay float64 // decoupled weight decay
+	accum       int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
+
+	// unsettable
+	iter      int
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
+}
+
+// NewAdamWSolver creates an AdamW solver with these default values:
+//		eta (learn rate)	  	: 0.001
+//		eps (smoothing factor)		: 1e-8
+//		beta1				: 0.9
+//		beta2 				: 0.999
+//		weight decay			: 0.01
+//		batch				: 1
+func NewAdamWSolver(opts ...SolverOpt) *AdamWSolver {
+	s := &AdamWSolver{
+		gradPrep:    gradPrep{batch: 1},
+		eta:         0.001,
+		eps:         1e-8,
+		beta1:       0.9,
+		beta2:       0.999,
+		weightDecay: 0.01,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step steps through each node in the model and applies the AdamW gradient descent algorithm on the value.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *AdamWSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
+	if s.cache == nil {
+		s.cache = make([]*dualValue, len(model))
+	}
+
+	s.iter++
+	correction1 := 1 - math.Pow(s.beta1, float64(s.iter))
+	correction2 := 1 - math.Pow(s.beta2, float64(s.iter))
+
+	for i, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+		densifyGrad(dv)
+
+		var c *dualValue
+		if c, err = cached(s.cache, i, dv); err != nil {
+			return
+		}
+
+		switch w := dv.Value.(type) {
+		case *tensor.Dense:
+			g := dv.d.(*tensor.Dense)
+			if err = s.dense(w, g); err != nil {
+				return
+			}
+			if err = moments(c.Value.(*tensor.Dense), c.d.(*tensor.Dense), g, s.beta1, s.beta2); err != nil {
+				return
+			}
+
+			// upd = m̂ / (√v̂ + ε) + λw
+			var upd tensor.Tensor
+			if upd, err = tensor.Mul(scalarOf(w.Dtype(), 1/correction1), c.Value); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			defer returnTensor(upd)
+			if err = divRMS(upd.(*tensor.Dense), c.d.(*tensor.Dense), 1/correction2, s.eps); err != nil {
+				return
+			}
+			if _, err = tensor.Mul(scalarOf(w.Dtype(), s.weightDecay), w, tensor.WithIncr(upd)); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+
+			if _, err = tensor.Mul(scalarOf(w.Dtype(), -s.eta), upd, tensor.WithIncr(w)); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			g.Zero()
+		case *F64:
+			wv := w.any()
+			g := s.f64(wv, dv.d.(*F64).any())
+			m := s.beta1*c.Value.(*F64).any() + (1-s.beta1)*g
+			v := s.beta2*c.d.(*F64).any() + (1-s.beta2)*g*g
+
+			mHat := m / correction1
+			vHat := v / correction2
+			wv -= s.eta * (mHat/(math.Sqrt(vHat)+s.eps) + s.weightDecay*wv)
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(v)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float64)
+		case *F32:
+			wv := w.any()
+			g := s.f32(wv, dv.d.(*F32).any())
+			beta1, beta2 := float32(s.beta1), float32(s.beta2)
+			m := beta1*c.Value.(*F32).any() + (1-beta1)*g
+			v := beta2*c.d.(*F32).any() + (1-beta2)*g*g
+
+			mHat := m / float32(correction1)
+			vHat := v / float32(correction2)
+			wv -= float32(s.eta) * (mHat/(math32.Sqrt(vHat)+float32(s.eps)) + float32(s.weightDecay)*wv)
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(v)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float32)
+		default:
+			return errors.Errorf(nyiTypeFail, "AdamWSolver", w)
+		}
+	}
+	return
+}
+
+// AMSGradSolver is the variant of Adam that uses the maximum of the past second moments instead of their running average,
+// so that the effective learn rate never increases. Paper: https://openreview.net/forum?id=ryQu7f-RZ
+//
+// Like the AdamSolver, the cache's *dualValues hold the means of gradients (in .Value) and the variances of the gradients (in .d).
+// The maximums of the variances are held in maxCache.
+type AMSGradSolver struct {
+	gradPrep
+	eta   float64 // learn rate
+	eps   float64 // smoothing
+	beta1 float64 // modifier for means
+	beta2 float64 // modifier for variances
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
+
+	// unsettable
+	iter      int
+	accumIter int
+	cache     []*dualValue
+	maxCache  []Value
+	sched     lrSchedule
+	groups    *paramGroups
+}
+
+// NewAMSGradSolver creates an AMSGrad solver with these default values:
+//		eta (learn rate)	  	: 0.001
+//		eps (smoothing factor)		: 1e-8
+//		beta1				: 0.9
+//		beta2 				: 0.999
+//		batch				: 1
+func NewAMSGradSolver(opts ...SolverOpt) *AMSGradSolver {
+	s := &AMSGradSolver{
+		gradPrep: gradPrep{batch: 1},
+		eta:      0.001,
+		eps:      1e-8,
+		beta1:    0.9,
+		beta2:    0.999,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step steps through each node in the model and applies the AMSGrad gradient descent algorithm on the value.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *AMSGradSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
+	if s.cache == nil {
+		s.cache = make([]*dualValue, len(model))
+	}
+	if s.maxCache == nil {
+		s.maxCache = make([]Value, len(model))
+	}
+
+	s.iter++
+	correction1 := 1 - math.Pow(s.beta1, float64(s.iter))
+	correction2 := 1 - math.Pow(s.beta2, float64(s.iter))
+
+	for i, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+		densifyGrad(dv)
+
+		var c *dualValue
+		if c, err = cached(s.cache, i, dv); err != nil {
+			return
+		}
+		if s.maxCache[i] == nil {
+			if s.maxCache[i], err = CloneValue(c.d); err != nil {
+				return errors.Wrap(err, cloneFail)
+			}
+		}
+
+		switch w := dv.Value.(type) {
+		case *tensor.Dense:
+			g := dv.d.(*tensor.Dense)
+			vMax := s.maxCache[i].(*tensor.Dense)
+			if err = s.dense(w, g); err != nil {
+				return
+			}
+			if err = moments(c.Value.(*tensor.Dense), c.d.(*tensor.Dense), g, s.beta1, s.beta2); err != nil {
+				return
+			}
+			if err = maxInto(vMax, c.d.(*tensor.Dense)); err != nil {
+				return
+			}
+
+			var upd tensor.Tensor
+			if upd, err = tensor.Mul(scalarOf(w.Dtype(), 1/correction1), c.Value); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			defer returnTensor(upd)
+			if err = divRMS(upd.(*tensor.Dense), vMax, 1/correction2, s.eps); err != nil {
+				return
+			}
+			if _, err = tensor.Mul(scalarOf(w.Dtype(), -s.eta), upd, tensor.WithIncr(w)); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			g.Zero()
+		case *F64:
+			wv := w.any()
+			g := s.f64(wv, dv.d.(*F64).any())
+			m := s.beta1*c.Value.(*F64).any() + (1-s.beta1)*g
+			v := s.beta2*c.d.(*F64).any() + (1-s.beta2)*g*g
+			vMax := math.Max(s.maxCache[i].(*F64).any(), v)
+
+			mHat := m / correction1
+			vHat := vMax / correction2
+			wv -= s.eta * mHat / (math.Sqrt(vHat) + s.eps)
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(v)
+			s.maxCache[i], _ = anyToScalar(vMax)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float64)
+		case *F32:
+			wv := w.any()
+			g := s.f32(wv, dv.d.(*F32).any())
+			beta1, beta2 := float32(s.beta1), float32(s.beta2)
+			m := beta1*c.Value.(*F32).any() + (1-beta1)*g
+			v := beta2*c.d.(*F32).any() + (1-beta2)*g*g
+			vMax := math32.Max(s.maxCache[i].(*F32).any(), v)
+
+			mHat := m / float32(correction1)
+			vHat := vMax / float32(correction2)
+			wv -= float32(s.eta) * mHat / (math32.Sqrt(vHat) + float32(s.eps))
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(v)
+			s.maxCache[i], _ = anyToScalar(vMax)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float32)
+		default:
+			return errors.Errorf(nyiTypeFail, "AMSGradSolver", w)
+		}
+	}
+	return
+}
+
+// AdadeltaSolver is the solver that adapts the learn rate of each element from a running average of the past gradients and
+// a running average of the past updates. Paper: https://arxiv.org/abs/1212.5701
+//
+// The cache's *dualValues hold the running average of the squared gradients (in .Value) and the running average of the squared updates (in .d).
+// The decay of both running averages is set with WithRho.
+type AdadeltaSolver struct {
+	gradPrep
+	eta   float64 // learn rate
+	eps   float64 // smoothing
+	rho   float64 // decay
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
+
+	// unsettable
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
+}
+
+// NewAdadeltaSolver creates an Adadelta solver with these default values:
+//		eta (learn rate)	  	: 1
+//		eps (smoothing factor)		: 1e-6
+//		rho (decay)			: 0.95
+//		batch				: 1
+func NewAdadeltaSolver(opts ...SolverOpt) *AdadeltaSolver {
+	s := &AdadeltaSolver{
+		gradPrep: gradPrep{batch: 1},
+		eta:      1,
+		eps:      1e-6,
+		rho:      0.95,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step steps through each node in the model and applies the Adadelta gradient descent algorithm on the value.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *AdadeltaSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
+	if s.cache == nil {
+		s.cache = make([]*dualValue, len(model))
+	}
+
+	for i, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+		densifyGrad(dv)
+
+		var c *dualValue
+		if c, err = cached(s.cache, i, dv); err != nil {
+			return
+		}
+
+		switch w := dv.Value.(type) {
+		case *tensor.Dense:
+			g := dv.d.(*tensor.Dense)
+			if err = s.dense(w, g); err != nil {
+				return
+			}
+			if err = s.denseStep(w, g, c.Value.(*tensor.Dense), c.d.(*tensor.Dense)); err != nil {
+				return
+			}
+			g.Zero()
+		case *F64:
+			wv := w.any()
+			g := s.f64(wv, dv.d.(*F64).any())
+			eg2 := s.rho*c.Value.(*F64).any() + (1-s.rho)*g*g
+			edx2 := c.d.(*F64).any()
+			upd := math.Sqrt(edx2+s.eps) / math.Sqrt(eg2+s.eps) * g
+			edx2 = s.rho*edx2 + (1-s.rho)*upd*upd
+			wv -= s.eta * upd
+
+			c.Value, _ = anyToScalar(eg2)
+			c.d, _ = anyToScalar(edx2)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float64)
+		case *F32:
+			wv := w.any()
+			g := s.f32(wv, dv.d.(*F32).any())
+			rho, eps := float32(s.rho), float32(s.eps)
+			eg2 := rho*c.Value.(*F32).any() + (1-rho)*g*g
+			edx2 := c.d.(*F32).any()
+			upd := math32.Sqrt(edx2+eps) / math32.Sqrt(eg2+eps) * g
+			edx2 = rho*edx2 + (1-rho)*upd*upd
+			wv -= float32(s.eta) * upd
+
+			c.Value, _ = anyToScalar(eg2)
+			c.d, _ = anyToScalar(edx2)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float32)
+		default:
+			return errors.Errorf(nyiTypeFail, "AdadeltaSolver", w)
+		}
+	}
+	return
+}
+
+// denseStep updates the weights w given their prepared gradient g, and the running averages of the squared gradients and updates
+func (s *AdadeltaSolver) denseStep(w, g, eg2, edx2 *tensor.Dense) (err error) {
+	dt := w.Dtype()
+	rho, omRho, eps := scalarOf(dt, s.rho), scalarOf(dt, 1-s.rho), scalarOf(dt, s.eps)
+
+	// E[g²] = ρ * E[g²] + (1 - ρ) * g²
+	var g2 tensor.Tensor
+	if g2, err = tensor.Mul(g, g); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	defer returnTensor(g2)
+	if _, err = tensor.Mul(rho, eg2, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	if _, err = tensor.Mul(omRho, g2, tensor.WithIncr(eg2)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+
+	// upd = √(E[Δx²] + ε) / √(E[g²] + ε) * g
+	upd := edx2.Clone().(*tensor.Dense)
+	rmsG := eg2.Clone().(*tensor.Dense)
+	defer returnTensor(upd)
+	defer returnTensor(rmsG)
+	if _, err = tensor.Add(eps, upd, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, addFail)
+	}
+	if _, err = tensor.Sqrt(upd, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, sqrtFail)
+	}
+	if _, err = tensor.Add(eps, rmsG, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, addFail)
+	}
+	if _, err = tensor.Sqrt(rmsG, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, sqrtFail)
+	}
+	if _, err = tensor.Div(upd, rmsG, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, divFail)
+	}
+	if _, err = tensor.Mul(upd, g, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+
+	// E[Δx²] = ρ * E[Δx²] + (1 - ρ) * upd²
+	var upd2 tensor.Tensor
+	if upd2, err = tensor.Mul(upd, upd); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	defer returnTensor(upd2)
+	if _, err = tensor.Mul(rho, edx2, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	if _, err = tensor.Mul(omRho, upd2, tensor.WithIncr(edx2)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+
+	// w = w - η * upd
+	if _, err = tensor.Mul(scalarOf(dt, -s.eta), upd, tensor.WithIncr(w)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	return nil
+}
+
+// AdamaxSolver is the variant of Adam based on the infinity norm. Paper: http://arxiv.org/abs/1412.6980
+//
+// The cache's *dualValues hold the means of gradients (in .Value) and the exponentially weighted infinity norm of the gradients (in .d).
+type AdamaxSolver struct {
+	gradPrep
+	eta   float64 // learn rate
+	eps   float64 // smoothing
+	beta1 float64 // modifier for means
+	beta2 float64 // modifier for the infinity norms
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
+
+	// unsettable
+	iter      int
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
+}
+
+// NewAdamaxSolver creates an Adamax solver with these default values:
+//		eta (learn rate)	  	: 0.002
+//		eps (smoothing factor)		: 1e-8
+//		beta1				: 0.9
+//		beta2 				: 0.999
+//		batch				: 1
+func NewAdamaxSolver(opts ...SolverOpt) *AdamaxSolver {
+	s := &AdamaxSolver{
+		gradPrep: gradPrep{batch: 1},
+		eta:      0.002,
+		eps:      1e-8,
+		beta1:    0.9,
+		beta2:    0.999,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step steps through each node in the model and applies the Adamax gradient descent algorithm on the value.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *AdamaxSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
+	if s.cache == nil {
+		s.cache = make([]*dualValue, len(model))
+	}
+
+	s.iter++
+	correction1 := 1 - math.Pow(s.beta1, float64(s.iter))
+
+	for i, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+		densifyGrad(dv)
+
+		var c *dualValue
+		if c, err = cached(s.cache, i, dv); err != nil {
+			return
+		}
+
+		switch w := dv.Value.(type) {
+		case *tensor.Dense:
+			g := dv.d.(*tensor.Dense)
+			if err = s.dense(w, g); err != nil {
+				return
+			}
+			if err = s.denseStep(w, g, c.Value.(*tensor.Dense), c.d.(*tensor.Dense), correction1); err != nil {
+				return
+			}
+			g.Zero()
+		case *F64:
+			wv := w.any()
+			g := s.f64(wv, dv.d.(*F64).any())
+			m := s.beta1*c.Value.(*F64).any() + (1-s.beta1)*g
+			u := math.Max(s.beta2*c.d.(*F64).any(), math.Abs(g))
+			wv -= s.eta / correction1 * m / (u + s.eps)
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(u)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float64)
+		case *F32:
+			wv := w.any()
+			g := s.f32(wv, dv.d.(*F32).any())
+			beta1 := float32(s.beta1)
+			m := beta1*c.Value.(*F32).any() + (1-beta1)*g
+			u := math32.Max(float32(s.beta2)*c.d.(*F32).any(), math32.Abs(g))
+			wv -= float32(s.eta/correction1) * m / (u + float32(s.eps))
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(u)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float32)
+		default:
+			return errors.Errorf(nyiTypeFail, "AdamaxSolver", w)
+		}
+	}
+	return
+}
+
+// denseStep updates the weights w given their prepared gradient g, the means m and the infinity norms u
+func (s *AdamaxSolver) denseStep(w, g, m, u *tensor.Dense, correction1 float64) (err error) {
+	dt := w.Dtype()
+
+	// m = β_1 * m + (1 - β_1) * g
+	if _, err = tensor.Mul(scalarOf(dt, s.beta1), m, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	if _, err = tensor.Mul(scalarOf(dt, 1-s.beta1), g, tensor.WithIncr(m)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+
+	// u = max(β_2 * u, |g|)
+	if _, err = tensor.Mul(scalarOf(dt, s.beta2), u, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	if _, err = tensor.Abs(g, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, absFail)
+	}
+	if err = maxInto(u, g); err != nil {
+		return
+	}
+
+	// w = w - η / (1 - β_1^t) * m / (u + ε)
+	upd := m.Clone().(*tensor.Dense)
+	den := u.Clone().(*tensor.Dense)
+	defer returnTensor(upd)
+	defer returnTensor(den)
+	if _, err = tensor.Add(scalarOf(dt, s.eps), den, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, addFail)
+	}
+	if _, err = tensor.Div(upd, den, tensor.UseUnsafe()); err != nil {
+		return errors.Wrap(err, divFail)
+	}
+	if _, err = tensor.Mul(scalarOf(dt, -s.eta/correction1), upd, tensor.WithIncr(w)); err != nil {
+		return errors.Wrap(err, pointWiseMulFail)
+	}
+	return nil
+}
+
+// NadamSolver is Adam with Nesterov momentum. Paper: http://cs229.stanford.edu/proj2015/054_report.pdf
+//
+// Like the AdamSolver, the cache's *dualValues hold the means of gradients (in .Value) and the variances of the gradients (in .d).
+type NadamSolver struct {
+	gradPrep
+	eta   float64 // learn rate
+	eps   float64 // smoothing
+	beta1 float64 // modifier for means
+	beta2 float64 // modifier for variances
+	accum int     // number of steps to accumulate gradients over
+
+	maxNorm float64 // maximum global norm of the gradients
+
+	// unsettable
+	iter      int
+	accumIter int
+	cache     []*dualValue
+	sched     lrSchedule
+	groups    *paramGroups
+}
+
+// NewNadamSolver creates a Nadam solver with these default values:
+//		eta (learn rate)	  	: 0.002
+//		eps (smoothing factor)		: 1e-8
+//		beta1				: 0.9
+//		beta2 				: 0.999
+//		batch				: 1
+func NewNadamSolver(opts ...SolverOpt) *NadamSolver {
+	s := &NadamSolver{
+		gradPrep: gradPrep{batch: 1},
+		eta:      0.002,
+		eps:      1e-8,
+		beta1:    0.9,
+		beta2:    0.999,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Step steps through each node in the model and applies the Nadam gradient descent algorithm on the value.
+//
+// This function will error out if the nodes do not have an associated Grad value.
+func (s *NadamSolver) Step(model Nodes) (err error) {
+	var skip bool
+	if skip, err = accumulateGrads(model, s.accum, &s.accumIter); skip || err != nil {
+		return
+	}
+	if err = clipGlobalNorm(model, s.maxNorm); err != nil {
+		return
+	}
+	if s.groups != nil {
+		return s.groups.step(s, model)
+	}
+	defer s.sched.apply(&s.eta)()
+
+	if s.cache == nil {
+		s.cache = make([]*dualValue, len(model))
+	}
+
+	s.iter++
+	correction1 := 1 - math.Pow(s.beta1, float64(s.iter))
+	correction1Next := 1 - math.Pow(s.beta1, float64(s.iter+1))
+	correction2 := 1 - math.Pow(s.beta2, float64(s.iter))
+
+	for i, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+		densifyGrad(dv)
+
+		var c *dualValue
+		if c, err = cached(s.cache, i, dv); err != nil {
+			return
+		}
+
+		switch w := dv.Value.(type) {
+		case *tensor.Dense:
+			g := dv.d.(*tensor.Dense)
+			dt := w.Dtype()
+			if err = s.dense(w, g); err != nil {
+				return
+			}
+
+			// the look ahead: the momentum of the next step, plus the current gradient
+			//		m̂ = β_1 * m / (1 - β_1^(t+1)) + (1 - β_1) * g / (1 - β_1^t)
+			var mHats tensor.Tensor
+			if mHats, err = tensor.Mul(scalarOf(dt, (1-s.beta1)/correction1), g); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			defer returnTensor(mHats)
+			if err = moments(c.Value.(*tensor.Dense), c.d.(*tensor.Dense), g, s.beta1, s.beta2); err != nil {
+				return
+			}
+			if _, err = tensor.Mul(scalarOf(dt, s.beta1/correction1Next), c.Value, tensor.WithIncr(mHats)); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+
+			if err = divRMS(mHats.(*tensor.Dense), c.d.(*tensor.Dense), 1/correction2, s.eps); err != nil {
+				return
+			}
+			if _, err = tensor.Mul(scalarOf(dt, -s.eta), mHats, tensor.WithIncr(w)); err != nil {
+				return errors.Wrap(err, pointWiseMulFail)
+			}
+			g.Zero()
+		case *F64:
+			wv := w.any()
+			g := s.f64(wv, dv.d.(*F64).any())
+			m := s.beta1*c.Value.(*F64).any() + (1-s.beta1)*g
+			v := s.beta2*c.d.(*F64).any() + (1-s.beta2)*g*g
+
+			mHat := s.beta1*m/correction1Next + (1-s.beta1)*g/correction1
+			vHat := v / correction2
+			wv -= s.eta * mHat / (math.Sqrt(vHat) + s.eps)
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(v)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float64)
+		case *F32:
+			wv := w.any()
+			g := s.f32(wv, dv.d.(*F32).any())
+			beta1, beta2 := float32(s.beta1), float32(s.beta2)
+			m := beta1*c.Value.(*F32).any() + (1-beta1)*g
+			v := beta2*c.d.(*F32).any() + (1-beta2)*g*g
+
+			mHat := beta1*m/float32(correction1Next) + (1-beta1)*g/float32(correction1)
+			vHat := v / float32(correction2)
+			wv -= float32(s.eta) * mHat / (math32.Sqrt(vHat) + float32(s.eps))
+
+			c.Value, _ = anyToScalar(m)
+			c.d, _ = anyToScalar(v)
+			dv.Value, _ = anyToScalar(wv)
+			dv.d = zero(Float32)
+		default:
+			return errors.Errorf(nyiTypeFail, "NadamSolver", w)
+		}
+	}
+	return
+}
+
+// paramGroups holds the parameter groups of a solver, and the solvers that step them.
+type paramGroups struct {
+	opts    map[string][]SolverOpt
+	solvers map[string]Solver // created on the first step. The solver of the nodes that are not in any group is under ""
+}
+
+func (pg *paramGroups) add(name string, opts []SolverOpt) *paramGroups {
+	if pg == nil {
+		pg = &paramGroups{
+			opts:    make(map[string][]SolverOpt),
+			solvers: make(map[string]Solver),
+		}
+	}
+	pg.opts[name] = append(pg.opts[name], opts...)
+	return pg
+}
+
+// split splits the model into its parameter groups. The names of the groups are returned in the order they first appear in the model.
+func (pg *paramGroups) split(model Nodes) (names []string, groups map[string]Nodes) {
+	groups = make(map[string]Nodes)
+	for _, n := range model {
+		name := n.group
+		if _, ok := pg.opts[name]; !ok {
+			name = ""
+		}
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], n)
+	}
+	return
+}
+
+// solver returns the solver of the named group, creating it from s if needed
+func (pg *paramGroups) solver(s Solver, name string) (retVal Solver, err error) {
+	var ok bool
+	if retVal, ok = pg.solvers[name]; !ok {
+		if retVal, err = copySolver(s, pg.opts[name]); err != nil {
+			return
+		}
+		pg.solvers[name] = retVal
+	}
+	return
+}
+
+// step splits the model into its parameter groups and steps each group with its own copy of the solver s.
+func (pg *paramGroups) step(s Solver, model Nodes) (err error) {
+	names, groups := pg.split(model)
+	for _, name := range names {
+		var solver Solver
+		if solver, err = pg.solver(s, name); err != nil {
+			return
+		}
+
+		if err = solver.Step(groups[name]); err != nil {
+			return errors.Wrapf(err, "Failed to step parameter group %q", name)
+		}
+	}
+	return nil
+}
+
+// copySolver creates a copy of the solver without its parameter groups and caches, and applies the options to it. The gradients
+// are accumulated and clipped by the solver before the model is split, so the copy neither accumulates nor clips them again.
+func copySolver(s Solver, opts []SolverOpt) (retVal Solver, err error) {
+	switch st := s.(type) {
+	case *RMSPropSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *AdamSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *VanillaSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		retVal = &c
+	case *MomentumSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *AdaGradSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *AdamWSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *AMSGradSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		c.maxCache = nil
+		retVal = &c
+	case *AdadeltaSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *AdamaxSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	case *NadamSolver:
+		c := *st
+		c.groups, c.accum, c.maxNorm = nil, 0, 0
+		c.cache = nil
+		retVal = &c
+	default:
+		return nil, errors.Errorf(nyiTypeFail, "parameter groups", s)
+	}
+
+	for _, opt := range opts {
+		opt(retVal)
+	}
+	return
+}
+
+// clipGlobalNorm clips the gradients of the model by their global norm, if maxNorm is set.
+func clipGlobalNorm(model Nodes, maxNorm float64) (err error) {
+	if maxNorm <= 0 {
+		return nil
+	}
+	_, err = ClipGradsByGlobalNorm(model, maxNorm)
+	return
+}
+
+// accumulateGrads is called at the start of every Step. It returns true if the gradients are still being accumulated, in which case the Step
+// should not be applied. When the Step is due, the accumulated gradients of the model are averaged over the number of steps.
+func accumulateGrads(model Nodes, steps int, iter *int) (skip bool, err error) {
+	if steps <= 1 {
+		return false, nil
+	}
+
+	*iter++
+	if *iter < steps {
+		return true, nil
+	}
+	*iter = 0
+
+	for _, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			return false, errors.Errorf("Expected a *dualValue in %v (%x). Got %T instead", n, n.Hashcode(), n.boundTo)
+		}
+
+		switch d := dv.d.(type) {
+		case *tensor.Dense:
+			var perSteps interface{}
+			switch d.Dtype() {
+			case tensor.Float64:
+				perSteps = float64(1) / float64(steps)
+			case tensor.Float32:
+				perSteps = float32(1) / float32(steps)
+			default:
+				return false, errors.Errorf(nyiFail, "accumulateGrads", d.Dtype())
+			}
+
+			if _, err = tensor.Mul(d, perSteps, tensor.UseUnsafe()); err != nil {
+				return false, errors.Wrap(err, pointWiseMulFail)
+			}
+		case *RowSparse:
+			d.scale(1 / float64(steps))
+		case *F64:
+			dv.d, _ = anyToScalar(d.any() / float64(steps))
+		case *F32:
+			dv.d, _ = anyToScalar(d.any() / float32(steps))
+		default:
+			return false, errors.Errorf(nyiTypeFail, "accumulateGrads", dv.d)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,333 @@
+package gorgonia
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unsafe"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+// RowSparse is a gradient where only some rows are nonzero. A typical example is the gradient of an embedding table after an index lookup,
+// where only the looked up rows have a gradient.
+//
+// The AdamSolver (lazy Adam) and the AdaGradSolver (sparse AdaGrad) only update the weights and caches of the rows in a RowSparse gradient.
+// The other solvers step the dense equivalent of the gradient, except for the LBFGSSolver, which does not support row-sparse gradients.
+type RowSparse struct {
+	shape  tensor.Shape
+	rows   []int         // sorted and unique
+	values *tensor.Dense // one row of values for each row in rows
+}
+
+// NewRowSparse creates a row-sparse gradient of a value of the given shape. The ith row of values is the gradient of the rows[i]th row.
+// Repeated rows are summed, so the gradient of a lookup of the same index twice is correct.
+func NewRowSparse(shape tensor.Shape, rows []int, values *tensor.Dense) (*RowSparse, error) {
+	if shape.Dims() < 1 || shape[0] == 0 {
+		return nil, errors.Errorf("Cannot create a row-sparse gradient of shape %v", shape)
+	}
+	cols := shape.TotalSize() / shape[0]
+	if values.Shape().TotalSize() != len(rows)*cols {
+		return nil, errors.Errorf("Expected %d rows of %d values. Got values of shape %v instead", len(rows), cols, values.Shape())
+	}
+	for _, r := range rows {
+		if r < 0 || r >= shape[0] {
+			return nil, errors.Errorf("Row %d is out of range for shape %v", r, shape)
+		}
+	}
+
+	unique := make([]int, len(rows))
+	copy(unique, rows)
+	sort.Ints(unique)
+	var n int
+	for i, r := range unique {
+		if i == 0 || r != unique[n-1] {
+			unique[n] = r
+			n++
+		}
+	}
+	unique = unique[:n]
+
+	valShape := append(tensor.Shape{n}, shape[1:]...)
+	if len(valShape) == 1 {
+		valShape = append(valShape, 1)
+	}
+	summed := tensor.New(tensor.Of(values.Dtype()), tensor.WithShape(valShape...))
+	for i, r := range rows {
+		dst := sort.SearchInts(unique, r) * cols
+		switch data := summed.Data().(type) {
+		case []float64:
+			src := values.Data().([]float64)[i*cols : (i+1)*cols]
+			for j, v := range src {
+				data[dst+j] += v
+			}
+		case []float32:
+			src := values.Data().([]float32)[i*cols : (i+1)*cols]
+			for j, v := range src {
+				data[dst+j] += v
+			}
+		default:
+			return nil, errors.Errorf(nyiFail, "NewRowSparse", values.Dtype())
+		}
+	}
+
+	return &RowSparse{
+		shape:  shape.Clone(),
+		rows:   unique,
+		values: summed,
+	}, nil
+}
+
+// SetRowSparseGrad sets the gradient of n to a row-sparse gradient. See NewRowSparse for the meaning of rows and values.
+//
+// If a tape machine then binds a gradient from the graph to n, the row-sparse gradient is made dense and the gradient is added to it.
+// The lispMachine does not support row-sparse gradients.
+func SetRowSparseGrad(n *Node, rows []int, values *tensor.Dense) error {
+	if n.boundTo == nil {
+		return errors.Errorf("Cannot set the gradient of %v: it has no value", n)
+	}
+
+	dv, ok := n.boundTo.(*dualValue)
+	if !ok {
+		dv = &dualValue{Value: n.boundTo}
+		n.boundTo = dv
+	}
+
+	g, err := NewRowSparse(dv.Value.Shape(), rows, values)
+	if err != nil {
+		return err
+	}
+	dv.d = g
+	return nil
+}
+
+// Rows returns the rows that have a gradient.
+func (g *RowSparse) Rows() []int { return g.rows }
+
+// Values returns the gradients of the rows, one row per row in Rows().
+func (g *RowSparse) Values() *tensor.Dense { return g.values }
+
+// Dense returns the gradient as a *tensor.Dense.
+func (g *RowSparse) Dense() *tensor.Dense {
+	retVal := tensor.New(tensor.Of(g.Dtype()), tensor.WithShape(g.shape.Clone()...))
+	cols := g.cols()
+	for i, r := range g.rows {
+		switch data := retVal.Data().(type) {
+		case []float64:
+			copy(data[r*cols:(r+1)*cols], g.values.Data().([]float64)[i*cols:(i+1)*cols])
+		case []float32:
+			copy(data[r*cols:(r+1)*cols], g.values.Data().([]float32)[i*cols:(i+1)*cols])
+		}
+	}
+	return retVal
+}
+
+// densifyGrad replaces a row-sparse gradient of dv with its dense equivalent, for the solvers that have no row-sparse update, and for
+// the tape machine to add the gradients from the graph to.
+func densifyGrad(dv *dualValue) {
+	if g, ok := dv.d.(*RowSparse); ok {
+		dv.d = g.Dense()
+	}
+}
+
+// Size returns the number of values that are stored, like Data, Pointer and MemSize do. It is not the size of Shape.
+func (g *RowSparse) Size() int { return g.values.Size() }
+
+func (g *RowSparse) Shape() tensor.Shape     { return g.shape.Clone() }
+func (g *RowSparse) Data() interface{}       { return g.values.Data() }
+func (g *RowSparse) Dtype() tensor.Dtype     { return g.values.Dtype() }
+func (g *RowSparse) Uintptr() uintptr        { return g.values.Uintptr() }
+func (g *RowSparse) MemSize() uintptr        { return g.values.MemSize() }
+func (g *RowSparse) Pointer() unsafe.Pointer { return g.values.Pointer() }
+
+func (g *RowSparse) Format(s fmt.State, c rune) {
+	fmt.Fprintf(s, "RowSparse%v rows %v\n", g.shape, g.rows)
+	g.values.Format(s, c)
+}
+
+// Clone clones the gradient.
+func (g *RowSparse) Clone() (interface{}, error) {
+	rows := make([]int, len(g.rows))
+	copy(rows, g.rows)
+	return &RowSparse{
+		shape:  g.shape.Clone(),
+		rows:   rows,
+		values: g.values.Clone().(*tensor.Dense),
+	}, nil
+}
+
+// ZeroValue zeroes the gradients of the rows.
+func (g *RowSparse) ZeroValue() Value {
+	g.values.Zero()
+	return g
+}
+
+func (g *RowSparse) cols() int { return g.shape.TotalSize() / g.shape[0] }
+
+// scale multiplies the gradient by s
+func (g *RowSparse) scale(s float64) {
+	switch data := g.values.Data().(type) {
+	case []float64:
+		for i := range data {
+			data[i] *= s
+		}
+	case []float32:
+		for i := range data {
+			data[i] *= float32(s)
+		}
+	}
+}
+
+// eachRow calls fn with the gradient of every row, and the same row of each of the tensors, which have the shape of the gradient.
+// The rows are passed as float64s, and written back into the tensors after fn returns.
+func (g *RowSparse) eachRow(tensors []*tensor.Dense, fn func(grad []float64, rows [][]float64)) error {
+	for _, t := range tensors {
+		if !t.Shape().Eq(g.shape) {
+			return errors.Errorf("Expected a tensor of shape %v. Got %v instead", g.shape, t.Shape())
+		}
+	}
+
+	cols := g.cols()
+	grad := make([]float64, cols)
+	rows := make([][]float64, len(tensors))
+	for i := range rows {
+		rows[i] = make([]float64, cols)
+	}
+
+	for i, r := range g.rows {
+		if err := copyRow(grad, g.values, i*cols); err != nil {
+			return err
+		}
+		for j, t := range tensors {
+			if err := copyRow(rows[j], t, r*cols); err != nil {
+				return err
+			}
+		}
+
+		fn(grad, rows)
+
+		for j, t := range tensors {
+			switch data := t.Data().(type) {
+			case []float64:
+				copy(data[r*cols:], rows[j])
+			case []float32:
+				for k, v := range rows[j] {
+					data[r*cols+k] = float32(v)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// copyRow copies len(dst) elements of t, starting at offset, into dst
+func copyRow(dst []float64, t *tensor.Dense, offset int) error {
+	switch data := t.Data().(type) {
+	case []float64:
+		copy(dst, data[offset:])
+	case []float32:
+		for i := range dst {
+			dst[i] = float64(data[offset+i])
+		}
+	default:
+		return errors.Errorf(nyiFail, "row-sparse gradients", t.Dtype())
+	}
+	return nil
+}
+
+func signum(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+// sparseCache creates a cache of two zeroed tensors shaped like the weights
+func sparseCache(weights Value) (*dualValue, error) {
+	if _, ok := weights.(*tensor.Dense); !ok {
+		return nil, errors.Errorf(nyiTypeFail, "row-sparse gradients", weights)
+	}
+	v, err := CloneValue(weights)
+	if err != nil {
+		return nil, errors.Wrap(err, cloneFail)
+	}
+	d, err := CloneValue(weights)
+	if err != nil {
+		return nil, errors.Wrap(err, cloneFail)
+	}
+	return &dualValue{Value: ZeroValue(v), d: ZeroValue(d)}, nil
+}
+
+// sparseStep applies the lazy Adam update: only the means and variances of the rows with a gradient are updated.
+func (s *AdamSolver) sparseStep(i int, dv *dualValue, g *RowSparse, correction1, correction2 float64) (err error) {
+	if s.cache[i] == nil {
+		if s.cache[i], err = sparseCache(dv.Value); err != nil {
+			return
+		}
+	}
+	cached := s.cache[i]
+	tensors := []*tensor.Dense{dv.Value.(*tensor.Dense), cached.Value.(*tensor.Dense), cached.d.(*tensor.Dense)}
+
+	err = g.eachRow(tensors, func(grad []float64, rows [][]float64) {
+		w, m, v := rows[0], rows[1], rows[2]
+		for j, gj := range grad {
+			if s.useL1Reg {
+				gj += s.l1reg * signum(w[j])
+			}
+			if s.useL2Reg {
+				gj += s.l2reg * w[j]
+			}
+			if s.batch > 1 {
+				gj /= s.batch
+			}
+			if s.useClip && s.clip > 0 {
+				gj = math.Max(-s.clip, math.Min(s.clip, gj))
+			}
+
+			m[j] = s.beta1*m[j] + (1-s.beta1)*gj
+			v[j] = s.beta2*v[j] + (1-s.beta2)*gj*gj
+			w[j] -= s.eta * (m[j] / correction1) / (math.Sqrt(v[j]/correction2) + s.eps)
+		}
+	})
+	if err != nil {
+		return
+	}
+	ZeroValue(g)
+	return nil
+}
+
+// sparseStep applies the sparse AdaGrad update: only the caches of the rows with a gradient are updated.
+func (s *AdaGradSolver) sparseStep(i int, dv *dualValue, g *RowSparse) (err error) {
+	if s.cache[i] == nil {
+		if s.cache[i], err = sparseCache(dv.Value); err != nil {
+			return
+		}
+	}
+	cached := s.cache[i]
+	tensors := []*tensor.Dense{dv.Value.(*tensor.Dense), cached.Value.(*tensor.Dense)}
+
+	err = g.eachRow(tensors, func(grad []float64, rows [][]float64) {
+		w, c := rows[0], rows[1]
+		for j, gj := range grad {
+			c[j] += gj * gj
+			if s.useClip {
+				gj = math.Max(-s.clip, math.Min(s.clip, gj))
+			}
+
+			upd := -s.eta * gj / math.Sqrt(c[j]+s.eps)
+			if s.useL2Reg {
+				upd -= s.l2reg * w[j]
+			}
+			w[j] += upd
+		}
+	})
+	if err != nil {
+		return
+	}
+	ZeroValue(g)
+	return nil
+}
@@ -0,0 +1,171 @@
+package gorgonia
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestRowSparseSolvers(t *testing.T) {
+	// the first step of both solvers moves each weight with a gradient by about -eta * sign(g)
+	solvers := []struct {
+		mk       func() Solver
+		expected []float64
+	}{
+		{func() Solver { return NewAdamSolver(WithLearnRate(0.1), WithL2Reg(0.01), WithClip(5)) }, []float64{0.9, 1.9, 3, 4, 4.9, 5.9, 7, 8}},
+		{func() Solver { return NewAdaGradSolver() }, []float64{0.999, 1.999, 3, 4, 4.999, 6, 7, 8}},
+	}
+
+	for _, dt := range []tensor.Dtype{Float64, Float32} {
+		for _, sc := range solvers {
+			solver := sc.mk()
+
+			g := NewGraph()
+			w := NewMatrix(g, dt, WithShape(4, 2), WithName("w"))
+			var vals *tensor.Dense
+			if dt == Float64 {
+				w.boundTo = tensor.New(tensor.WithShape(4, 2), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6, 7, 8}))
+				vals = tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{1, -1, 0.5, 0.5, 2, 1}))
+			} else {
+				w.boundTo = tensor.New(tensor.WithShape(4, 2), tensor.WithBacking([]float32{1, 2, 3, 4, 5, 6, 7, 8}))
+				vals = tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float32{1, -1, 0.5, 0.5, 2, 1}))
+			}
+
+			// row 2 is looked up twice
+			if err := SetRowSparseGrad(w, []int{2, 0, 2}, vals); err != nil {
+				t.Fatal(err)
+			}
+			grad := w.boundTo.(*dualValue).d.(*RowSparse)
+			if rows := grad.Rows(); len(rows) != 2 || rows[0] != 0 || rows[1] != 2 {
+				t.Errorf("Expected the rows to be [0 2]. Got %v instead", rows)
+			}
+			if got := extractRow(grad.Dense()); !floatsEqual64([]float64{0.5, 0.5, 0, 0, 3, 0, 0, 0}, got) {
+				t.Errorf("Expected the dense gradient to be [0.5 0.5 0 0 3 0 0 0]. Got %v instead", got)
+			}
+
+			if err := solver.Step(Nodes{w}); err != nil {
+				t.Fatal(err)
+			}
+
+			// only the rows that have a gradient are updated
+			got := extractRow(w.Value().(*tensor.Dense))
+			for i := range got {
+				if math.Abs(got[i]-sc.expected[i]) > 1e-5 {
+					t.Errorf("%T %v: Expected the weights to be %v. Got %v instead", solver, dt, sc.expected, got)
+					break
+				}
+			}
+			if got := extractRow(grad.Values()); !floatsEqual64([]float64{0, 0, 0, 0}, got) {
+				t.Errorf("%T %v: Expected the gradient to be zeroed. Got %v instead", solver, dt, got)
+			}
+
+			// the next step only touches row 3, even though the caches of rows 0 and 2 are not zero
+			one := tensor.New(tensor.WithShape(1, 2), tensor.WithBacking([]float64{1, 1}))
+			if dt == Float32 {
+				one = tensor.New(tensor.WithShape(1, 2), tensor.WithBacking([]float32{1, 1}))
+			}
+			if err := SetRowSparseGrad(w, []int{3}, one); err != nil {
+				t.Fatal(err)
+			}
+			if err := solver.Step(Nodes{w}); err != nil {
+				t.Fatal(err)
+			}
+			after := extractRow(w.Value().(*tensor.Dense))
+			if !floatsEqual64(got[:6], after[:6]) {
+				t.Errorf("%T %v: Expected rows 0 to 2 to be untouched by the second step. Got %v, was %v", solver, dt, after, got)
+			}
+			if after[6] >= 7 || after[7] >= 8 {
+				t.Errorf("%T %v: Expected row 3 to decrease. Got %v", solver, dt, after[6:])
+			}
+		}
+	}
+
+	// errors
+	if _, err := NewRowSparse(tensor.Shape{4, 2}, []int{4}, tensor.New(tensor.WithShape(1, 2), tensor.WithBacking([]float64{1, 1}))); err == nil {
+		t.Error("Expected an error for an out of range row")
+	}
+	if _, err := NewRowSparse(tensor.Shape{4, 2}, []int{0, 1}, tensor.New(tensor.WithShape(1, 2), tensor.WithBacking([]float64{1, 1}))); err == nil {
+		t.Error("Expected an error for mismatched values")
+	}
+}
+
+func TestRowSparseDensified(t *testing.T) {
+	// the solvers without a row-sparse update step the dense equivalent of the gradient
+	solvers := []func() Solver{
+		func() Solver { return NewRMSPropSolver() },
+		func() Solver { return NewVanillaSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewMomentumSolver() },
+		func() Solver { return NewAdamWSolver() },
+		func() Solver { return NewAMSGradSolver() },
+		func() Solver { return NewAdadeltaSolver() },
+		func() Solver { return NewAdamaxSolver() },
+		func() Solver { return NewNadamSolver() },
+	}
+
+	vals := tensor.New(tensor.WithShape(2, 2), tensor.WithBacking([]float64{1, -1, 0.5, 2}))
+	for _, mk := range solvers {
+		g := NewGraph()
+		sparse := NewMatrix(g, Float64, WithShape(3, 2), WithName("sparse"), WithValue(tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6}))))
+		dense := NewMatrix(g, Float64, WithShape(3, 2), WithName("dense"), WithValue(tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6}))))
+		if err := SetRowSparseGrad(sparse, []int{2, 0}, vals); err != nil {
+			t.Fatal(err)
+		}
+		dense.boundTo = &dualValue{Value: dense.boundTo, d: tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{0.5, 2, 0, 0, 1, -1}))}
+
+		ss, ds := mk(), mk()
+		if err := ss.Step(Nodes{sparse}); err != nil {
+			t.Fatalf("%T: %v", ss, err)
+		}
+		if err := ds.Step(Nodes{dense}); err != nil {
+			t.Fatalf("%T: %v", ds, err)
+		}
+		if !ValueClose(dense.Value(), sparse.Value()) {
+			t.Errorf("%T: Expected the weights to be %v. Got %v instead", ss, dense.Value(), sparse.Value())
+		}
+	}
+}
+
+func TestRowSparseBindDualValues(t *testing.T) {
+	g := NewGraph()
+	w := NewMatrix(g, Float64, WithShape(3, 2), WithName("w"), WithValue(tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{1, 2, 3, 4, 5, 6}))))
+	cost := Must(Sum(w))
+	if _, err := Grad(cost, w); err != nil {
+		t.Fatal(err)
+	}
+
+	vals := tensor.New(tensor.WithShape(1, 2), tensor.WithBacking([]float64{1, -1}))
+	if err := SetRowSparseGrad(w, []int{1}, vals); err != nil {
+		t.Fatal(err)
+	}
+	if size := w.boundTo.(*dualValue).d.Size(); size != 2 {
+		t.Errorf("Expected the size of the gradient to be the 2 values stored. Got %d instead", size)
+	}
+
+	// the gradient of the graph is added to the dense equivalent of the row-sparse gradient
+	m := NewTapeMachine(g, BindDualValues(w))
+	if err := m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	grad, err := w.Grad()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extractRow(grad.(*tensor.Dense)); !floatsEqual64([]float64{1, 1, 2, 0, 1, 1}, got) {
+		t.Errorf("Expected the gradient to be [1 1 2 0 1 1]. Got %v instead", got)
+	}
+}
+
+func extractRow(t tensor.Tensor) []float64 {
+	switch data := t.Data().(type) {
+	case []float64:
+		return data
+	case []float32:
+		retVal := make([]float64, len(data))
+		for i, v := range data {
+			retVal[i] = float64(v)
+		}
+		return retVal
+	}
+	return nil
+}
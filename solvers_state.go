@@ -0,0 +1,415 @@
+package gorgonia
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+// solverState is the serialized state of a solver. The cached values are keyed by the names of the nodes, so that the state may be loaded into
+// a solver for the same model built by another process.
+type solverState struct {
+	Kind      string // the type of the solver
+	Iter      int
+	AccumIter int
+	SchedStep int
+
+	Caches map[string][]savedValue
+	Groups map[string]*solverState
+
+	// LBFGSSolver
+	Names    []string
+	S, Y     [][]float64
+	Rho      []float64
+	NumEvals int
+}
+
+// savedValue is a serialized Value
+type savedValue struct {
+	Shape  []int
+	Scalar bool
+	F64    []float64
+	F32    []float32
+}
+
+// solverRefs points to the state of a solver
+type solverRefs struct {
+	iter      *int
+	accumIter *int
+	cache     *[]*dualValue
+	extra     *[]Value // any additional cached value per node
+	sched     *lrSchedule
+	groups    **paramGroups
+}
+
+func (s *RMSPropSolver) refs() solverRefs {
+	return solverRefs{accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *AdamSolver) refs() solverRefs {
+	return solverRefs{iter: &s.iter, accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *VanillaSolver) refs() solverRefs {
+	return solverRefs{accumIter: &s.accumIter, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *MomentumSolver) refs() solverRefs {
+	return solverRefs{accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *AdaGradSolver) refs() solverRefs {
+	return solverRefs{accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *AdamWSolver) refs() solverRefs {
+	return solverRefs{iter: &s.iter, accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *AMSGradSolver) refs() solverRefs {
+	return solverRefs{iter: &s.iter, accumIter: &s.accumIter, cache: &s.cache, extra: &s.maxCache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *AdadeltaSolver) refs() solverRefs {
+	return solverRefs{accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *AdamaxSolver) refs() solverRefs {
+	return solverRefs{iter: &s.iter, accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+func (s *NadamSolver) refs() solverRefs {
+	return solverRefs{iter: &s.iter, accumIter: &s.accumIter, cache: &s.cache, sched: &s.sched, groups: &s.groups}
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *RMSPropSolver) SaveState(w io.Writer, model Nodes) error {
+	return saveSolverState(w, s, model)
+}
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *RMSPropSolver) LoadState(r io.Reader, model Nodes) error {
+	return loadSolverState(r, s, model)
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *AdamSolver) SaveState(w io.Writer, model Nodes) error { return saveSolverState(w, s, model) }
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *AdamSolver) LoadState(r io.Reader, model Nodes) error { return loadSolverState(r, s, model) }
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *VanillaSolver) SaveState(w io.Writer, model Nodes) error {
+	return saveSolverState(w, s, model)
+}
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *VanillaSolver) LoadState(r io.Reader, model Nodes) error {
+	return loadSolverState(r, s, model)
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *MomentumSolver) SaveState(w io.Writer, model Nodes) error {
+	return saveSolverState(w, s, model)
+}
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *MomentumSolver) LoadState(r io.Reader, model Nodes) error {
+	return loadSolverState(r, s, model)
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *AdaGradSolver) SaveState(w io.Writer, model Nodes) error {
+	return saveSolverState(w, s, model)
+}
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *AdaGradSolver) LoadState(r io.Reader, model Nodes) error {
+	return loadSolverState(r, s, model)
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *AdamWSolver) SaveState(w io.Writer, model Nodes) error { return saveSolverState(w, s, model) }
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *AdamWSolver) LoadState(r io.Reader, model Nodes) error { return loadSolverState(r, s, model) }
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *AMSGradSolver) SaveState(w io.Writer, model Nodes) error {
+	return saveSolverState(w, s, model)
+}
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *AMSGradSolver) LoadState(r io.Reader, model Nodes) error {
+	return loadSolverState(r, s, model)
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *AdadeltaSolver) SaveState(w io.Writer, model Nodes) error {
+	return saveSolverState(w, s, model)
+}
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *AdadeltaSolver) LoadState(r io.Reader, model Nodes) error {
+	return loadSolverState(r, s, model)
+}
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *AdamaxSolver) SaveState(w io.Writer, model Nodes) error { return saveSolverState(w, s, model) }
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *AdamaxSolver) LoadState(r io.Reader, model Nodes) error { return loadSolverState(r, s, model) }
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *NadamSolver) SaveState(w io.Writer, model Nodes) error { return saveSolverState(w, s, model) }
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *NadamSolver) LoadState(r io.Reader, model Nodes) error { return loadSolverState(r, s, model) }
+
+// SaveState writes the state of the solver for the given model to w. See saveSolverState.
+func (s *LBFGSSolver) SaveState(w io.Writer, model Nodes) error { return saveSolverState(w, s, model) }
+
+// LoadState reads the state of the solver for the given model from r. See loadSolverState.
+func (s *LBFGSSolver) LoadState(r io.Reader, model Nodes) error { return loadSolverState(r, s, model) }
+
+// saveSolverState gob-encodes the state of the solver: the caches (such as Adam's moments), the step counters, the position of the learn rate scheduler,
+// and the state of the solvers of the parameter groups. The model must be the one that was passed to Step. The cached values of each node are keyed
+// by the name of the node, so every node of the model must have been created with a unique name (see WithName).
+//
+// The hyperparameters of the solver and the state of the LRScheduler itself are not saved.
+func saveSolverState(w io.Writer, s Solver, model Nodes) error {
+	state, err := encodeSolverState(s, model)
+	if err != nil {
+		return err
+	}
+	return gob.NewEncoder(w).Encode(state)
+}
+
+// loadSolverState decodes the state written by saveSolverState into the solver. The solver has to be of the same type as the one that was saved,
+// and the model has to be made of nodes with the same names. Nodes of the model without a saved state start with fresh caches.
+func loadSolverState(r io.Reader, s Solver, model Nodes) error {
+	state := new(solverState)
+	if err := gob.NewDecoder(r).Decode(state); err != nil {
+		return errors.Wrap(err, "Failed to decode solver state")
+	}
+	return decodeSolverState(state, s, model)
+}
+
+func encodeSolverState(s Solver, model Nodes) (retVal *solverState, err error) {
+	names, err := nodeNames(model)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal = &solverState{Kind: fmt.Sprintf("%T", s)}
+	if lb, ok := s.(*LBFGSSolver); ok {
+		retVal.Names = names
+		retVal.S, retVal.Y, retVal.Rho = lb.s, lb.y, lb.rho
+		retVal.NumEvals = lb.evals
+		retVal.SchedStep = lb.sched.step
+		return
+	}
+
+	st, ok := s.(interface{ refs() solverRefs })
+	if !ok {
+		return nil, errors.Errorf(nyiTypeFail, "saving the solver state", s)
+	}
+	refs := st.refs()
+
+	// the gradients are accumulated by the solver before the model is split into its parameter groups
+	if refs.iter != nil {
+		retVal.Iter = *refs.iter
+	}
+	retVal.AccumIter = *refs.accumIter
+	retVal.SchedStep = refs.sched.step
+
+	if pg := *refs.groups; pg != nil {
+		retVal.Groups = make(map[string]*solverState)
+		gnames, groups := pg.split(model)
+		for _, gname := range gnames {
+			solver, ok := pg.solvers[gname]
+			if !ok {
+				continue // the group has not taken any steps yet
+			}
+			if retVal.Groups[gname], err = encodeSolverState(solver, groups[gname]); err != nil {
+				return nil, errors.Wrapf(err, "Parameter group %q", gname)
+			}
+		}
+		return
+	}
+
+	if refs.cache == nil {
+		return
+	}
+	retVal.Caches = make(map[string][]savedValue)
+	for i, cached := range *refs.cache {
+		if cached == nil || i >= len(names) {
+			continue
+		}
+
+		vals := []Value{cached.Value, cached.d}
+		if refs.extra != nil && i < len(*refs.extra) {
+			vals = append(vals, (*refs.extra)[i])
+		}
+
+		saved := make([]savedValue, len(vals))
+		for j, v := range vals {
+			if saved[j], err = saveValue(v); err != nil {
+				return nil, errors.Wrapf(err, "Failed to save the state of %v", names[i])
+			}
+		}
+		retVal.Caches[names[i]] = saved
+	}
+	return
+}
+
+func decodeSolverState(state *solverState, s Solver, model Nodes) (err error) {
+	if kind := fmt.Sprintf("%T", s); kind != state.Kind {
+		return errors.Errorf("Cannot load the state of a %v into a %v", state.Kind, kind)
+	}
+
+	names, err := nodeNames(model)
+	if err != nil {
+		return err
+	}
+
+	if lb, ok := s.(*LBFGSSolver); ok {
+		if len(names) != len(state.Names) {
+			return errors.Errorf("Expected a model of %d nodes. Got %d instead", len(state.Names), len(names))
+		}
+		for i, name := range names {
+			if name != state.Names[i] {
+				return errors.Errorf("Expected node %d of the model to be %q. Got %q instead", i, state.Names[i], name)
+			}
+		}
+		lb.s, lb.y, lb.rho = state.S, state.Y, state.Rho
+		lb.evals = state.NumEvals
+		lb.sched.step = state.SchedStep
+		return nil
+	}
+
+	st, ok := s.(interface{ refs() solverRefs })
+	if !ok {
+		return errors.Errorf(nyiTypeFail, "loading the solver state", s)
+	}
+	refs := st.refs()
+
+	if refs.iter != nil {
+		*refs.iter = state.Iter
+	}
+	*refs.accumIter = state.AccumIter
+	refs.sched.step = state.SchedStep
+
+	if pg := *refs.groups; pg != nil {
+		gnames, groups := pg.split(model)
+		for _, gname := range gnames {
+			gstate, ok := state.Groups[gname]
+			if !ok {
+				continue
+			}
+			var solver Solver
+			if solver, err = pg.solver(s, gname); err != nil {
+				return
+			}
+			if err = decodeSolverState(gstate, solver, groups[gname]); err != nil {
+				return errors.Wrapf(err, "Parameter group %q", gname)
+			}
+		}
+		return nil
+	}
+
+	if refs.cache == nil {
+		return nil
+	}
+	cache := make([]*dualValue, len(model))
+	var extra []Value
+	if refs.extra != nil {
+		extra = make([]Value, len(model))
+	}
+	for i, name := range names {
+		saved, ok := state.Caches[name]
+		if !ok {
+			continue
+		}
+
+		vals := make([]Value, len(saved))
+		for j, sv := range saved {
+			if vals[j], err = sv.value(); err != nil {
+				return errors.Wrapf(err, "Failed to load the state of %v", name)
+			}
+		}
+		if len(vals) < 2 || (extra != nil && len(vals) < 3) {
+			return errors.Errorf("Expected more cached values for %v. Got %d", name, len(vals))
+		}
+
+		dv := borrowDV()
+		dv.Value, dv.d = vals[0], vals[1]
+		cache[i] = dv
+		if extra != nil {
+			extra[i] = vals[2]
+		}
+	}
+	*refs.cache = cache
+	if refs.extra != nil {
+		*refs.extra = extra
+	}
+	return nil
+}
+
+// nodeNames returns the names of the nodes, which must be unique
+func nodeNames(model Nodes) ([]string, error) {
+	names := make([]string, len(model))
+	seen := make(map[string]struct{})
+	for i, n := range model {
+		if n.name == "" {
+			return nil, errors.Errorf("Node %v has no name. The nodes of the model need unique names to save the solver state", n)
+		}
+		if _, ok := seen[n.name]; ok {
+			return nil, errors.Errorf("More than one node of the model is named %q", n.name)
+		}
+		seen[n.name] = struct{}{}
+		names[i] = n.name
+	}
+	return names, nil
+}
+
+func saveValue(v Value) (retVal savedValue, err error) {
+	switch vt := v.(type) {
+	case *tensor.Dense:
+		retVal.Shape = vt.Shape().Clone()
+		switch data := vt.Data().(type) {
+		case []float64:
+			retVal.F64 = append([]float64(nil), data...)
+		case []float32:
+			retVal.F32 = append([]float32(nil), data...)
+		default:
+			return retVal, errors.Errorf(nyiFail, "saveValue", vt.Dtype())
+		}
+	case *F64:
+		retVal.Scalar = true
+		retVal.F64 = []float64{vt.any()}
+	case *F32:
+		retVal.Scalar = true
+		retVal.F32 = []float32{vt.any()}
+	default:
+		return retVal, errors.Errorf(nyiTypeFail, "saveValue", v)
+	}
+	return
+}
+
+func (sv savedValue) value() (Value, error) {
+	switch {
+	case sv.Scalar && len(sv.F64) == 1:
+		return newF64(sv.F64[0]), nil
+	case sv.Scalar && len(sv.F32) == 1:
+		return newF32(sv.F32[0]), nil
+	case sv.F64 != nil:
+		return tensor.New(tensor.WithShape(sv.Shape...), tensor.WithBacking(sv.F64)), nil
+	case sv.F32 != nil:
+		return tensor.New(tensor.WithShape(sv.Shape...), tensor.WithBacking(sv.F32)), nil
+	}
+	return nil, errors.New("Empty saved value")
+}
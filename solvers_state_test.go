@@ -0,0 +1,162 @@
+package gorgonia
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+// stateModel creates a named model with a matrix and a scalar, with their gradients set.
+func stateModel(dt tensor.Dtype) Nodes {
+	g := NewGraph()
+	var w, b *Node
+	switch dt {
+	case Float64:
+		w = NewMatrix(g, dt, WithName("w"), WithShape(2, 2), WithValue(tensor.New(tensor.WithShape(2, 2), tensor.WithBacking([]float64{1, 2, 3, 4}))))
+		b = NewScalar(g, dt, WithName("b"), WithValue(0.5), WithGroupName("bias"))
+	case Float32:
+		w = NewMatrix(g, dt, WithName("w"), WithShape(2, 2), WithValue(tensor.New(tensor.WithShape(2, 2), tensor.WithBacking([]float32{1, 2, 3, 4}))))
+		b = NewScalar(g, dt, WithName("b"), WithValue(float32(0.5)), WithGroupName("bias"))
+	}
+	model := Nodes{w, b}
+	setStateGrads(model)
+	return model
+}
+
+func setStateGrads(model Nodes) {
+	for _, n := range model {
+		dv, ok := n.boundTo.(*dualValue)
+		if !ok {
+			dv = dvUnit0(n.boundTo)
+			n.boundTo = dv
+		}
+		switch v := dv.Value.(type) {
+		case *tensor.Dense:
+			d := v.Clone().(*tensor.Dense)
+			switch data := d.Data().(type) {
+			case []float64:
+				for i := range data {
+					data[i] = float64(i) - 1.5
+				}
+			case []float32:
+				for i := range data {
+					data[i] = float32(i) - 1.5
+				}
+			}
+			dv.d = d
+		case *F64:
+			dv.d = newF64(-0.25)
+		case *F32:
+			dv.d = newF32(-0.25)
+		}
+	}
+}
+
+func TestSolverState(t *testing.T) {
+	solvers := []func() Solver{
+		func() Solver { return NewRMSPropSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewAdamSolver(WithLearnRate(0.1), WithLRScheduler(NewExponentialDecay(0.9))) },
+		func() Solver { return NewVanillaSolver(WithLearnRate(0.1), WithAccumulationSteps(2)) },
+		func() Solver { return NewMomentumSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewAdaGradSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewAdamWSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewAMSGradSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewAdadeltaSolver() },
+		func() Solver { return NewAdamaxSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewNadamSolver(WithLearnRate(0.1)) },
+		func() Solver { return NewAdamSolver(WithParamGroup("bias", WithLearnRate(0.01))) },
+		// saved mid-accumulation
+		func() Solver {
+			return NewAdamSolver(WithAccumulationSteps(2), WithLRScheduler(NewStepDecay(1, 0.5)), WithParamGroup("bias", WithLearnRate(0.01)))
+		},
+	}
+
+	type saveLoader interface {
+		SaveState(w io.Writer, model Nodes) error
+		LoadState(r io.Reader, model Nodes) error
+	}
+
+	for _, dt := range []tensor.Dtype{Float64, Float32} {
+		for _, create := range solvers {
+			// train one solver for 3 steps
+			orig := create()
+			model := stateModel(dt)
+			for i := 0; i < 3; i++ {
+				if err := orig.Step(model); err != nil {
+					t.Fatalf("%T: %v", orig, err)
+				}
+				setStateGrads(model)
+			}
+
+			var buf bytes.Buffer
+			if err := orig.(saveLoader).SaveState(&buf, model); err != nil {
+				t.Fatalf("%T: %v", orig, err)
+			}
+
+			// a fresh model with the same weights, and a fresh solver
+			resumed := create()
+			model2 := stateModel(dt)
+			for i, n := range model2 {
+				v, _ := CloneValue(model[i].Value())
+				n.boundTo.(*dualValue).Value = v
+			}
+			if err := resumed.(saveLoader).LoadState(&buf, model2); err != nil {
+				t.Fatalf("%T: %v", resumed, err)
+			}
+
+			for i := 0; i < 3; i++ {
+				if err := orig.Step(model); err != nil {
+					t.Fatal(err)
+				}
+				if err := resumed.Step(model2); err != nil {
+					t.Fatal(err)
+				}
+				setStateGrads(model)
+				setStateGrads(model2)
+			}
+
+			for i := range model {
+				if !ValueEq(model[i].Value(), model2[i].Value()) {
+					t.Errorf("%T %v: Expected the resumed training to give %v. Got %v instead", orig, dt, model[i].Value(), model2[i].Value())
+				}
+			}
+		}
+	}
+
+	// mismatched solvers
+	model := stateModel(Float64)
+	adam := NewAdamSolver()
+	if err := adam.Step(model); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := adam.SaveState(&buf, model); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRMSPropSolver().LoadState(&buf, model); err == nil {
+		t.Error("Expected an error when loading the state of an AdamSolver into an RMSPropSolver")
+	}
+
+	// L-BFGS keeps its correction pairs
+	lb := NewLBFGSSolver(nil)
+	lb.s, lb.y, lb.rho = [][]float64{{1, 2, 3, 4, 5}}, [][]float64{{5, 4, 3, 2, 1}}, []float64{0.5}
+	buf.Reset()
+	if err := lb.SaveState(&buf, model); err != nil {
+		t.Fatal(err)
+	}
+	lb2 := NewLBFGSSolver(nil)
+	if err := lb2.LoadState(&buf, model); err != nil {
+		t.Fatal(err)
+	}
+	if !floatsEqual64(lb.s[0], lb2.s[0]) || !floatsEqual64(lb.y[0], lb2.y[0]) || lb2.rho[0] != 0.5 {
+		t.Errorf("Expected the correction pairs to be restored. Got %v %v %v", lb2.s, lb2.y, lb2.rho)
+	}
+
+	// unnamed nodes
+	unnamed := Nodes{NewScalar(NewGraph(), Float64, WithValue(1.0))}
+	if err := adam.SaveState(&buf, unnamed); err == nil {
+		t.Error("Expected an error when saving the state of nodes without names")
+	}
+}
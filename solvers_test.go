@@ -153,3 +153,464 @@ func TestRMSPropSolver(t *testing.T) {
 	manualRMSProp32(t, s, model)
 
 }
+
+func TestSolverGradAccumulation(t *testing.T) {
+	assert := assert.New(t)
+	g := NewGraph()
+	x := NewMatrix(g, Float64, WithShape(2, 2), WithName("x"), WithInit(Zeroes()))
+	w := NewMatrix(g, Float64, WithShape(2, 2), WithName("w"), WithInit(ValuesOf(1.0)))
+	cost := Must(Sum(Must(HadamardProd(x, w))))
+	if _, err := Grad(cost, w); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g, WithGradAccumulation(2))
+	s := NewVanillaSolver(WithLearnRate(1), WithAccumulationSteps(2))
+	model := Nodes{w}
+
+	microBatches := [][]float64{
+		{1, 2, 3, 4},
+		{3, 4, 5, 6},
+		{1, 1, 1, 1},
+	}
+	for i, mb := range microBatches {
+		Let(x, tensor.New(tensor.WithShape(2, 2), tensor.WithBacking(mb)))
+		if err := m.RunAll(); err != nil {
+			t.Fatalf("Micro-batch %d: %v", i, err)
+		}
+		m.Reset()
+
+		grad, _ := w.Grad()
+		switch i {
+		case 0:
+			assert.Equal([]float64{1, 2, 3, 4}, grad.Data())
+		case 1:
+			assert.Equal([]float64{4, 6, 8, 10}, grad.Data())
+		case 2:
+			assert.Equal([]float64{1, 1, 1, 1}, grad.Data())
+		}
+
+		if err := s.Step(model); err != nil {
+			t.Fatalf("Micro-batch %d: %v", i, err)
+		}
+
+		switch i {
+		case 0:
+			assert.Equal([]float64{1, 1, 1, 1}, w.Value().Data(), "Weights should not be updated while accumulating")
+		case 1:
+			assert.Equal([]float64{-1, -2, -3, -4}, w.Value().Data())
+			assert.Equal([]float64{0, 0, 0, 0}, grad.Data(), "Gradients should be cleared after the step")
+		}
+	}
+}
+
+func TestMomentumSolver(t *testing.T) {
+	for _, nesterov := range []bool{false, true} {
+		opts := []SolverOpt{WithLearnRate(0.01), WithMomentum(0.9), WithL2Reg(0.001), WithClip(5)}
+		if nesterov {
+			opts = append(opts, WithNesterov())
+		}
+
+		// float64
+		s := NewMomentumSolver(opts...)
+		model := tf64Node()
+		backingV := model[0].Value().Data().([]float64)
+		grad0, _ := model[0].Grad()
+		backingD := grad0.Data().([]float64)
+		grads := []float64{0.5, -10, 10, 0.5}
+
+		correct := []float64{1, 2, 3, 4}
+		velocity := make([]float64, 4)
+		for i := 0; i < 5; i++ {
+			copy(backingD, grads)
+			for j, w := range correct {
+				g := clampFloat64(grads[j]+s.l2reg*w, -s.clip, s.clip)
+				velocity[j] = s.momentum*velocity[j] + g
+				upd := velocity[j]
+				if nesterov {
+					upd = g + s.momentum*velocity[j]
+				}
+				correct[j] = w - s.eta*upd
+			}
+
+			if err := s.Step(model); err != nil {
+				t.Fatal(err)
+			}
+			if !floatsEqual64(correct, backingV) {
+				t.Errorf("Nesterov %t, iteration %d: Expected weights %v. Got %v instead", nesterov, i, correct, backingV)
+			}
+			if !floatsEqual64(velocity, s.cache[0].Value.Data().([]float64)) {
+				t.Errorf("Nesterov %t, iteration %d: Expected velocities %v. Got %v instead", nesterov, i, velocity, s.cache[0].Value.Data())
+			}
+		}
+
+		// float32
+		s = NewMomentumSolver(opts...)
+		model = tf32Node()
+		backingV32 := model[0].Value().Data().([]float32)
+		grad0, _ = model[0].Grad()
+		backingD32 := grad0.Data().([]float32)
+		grads32 := []float32{0.5, -10, 10, 0.5}
+
+		correct32 := []float32{1, 2, 3, 4}
+		velocity32 := make([]float32, 4)
+		momentum, eta, l2reg, clip := float32(s.momentum), float32(s.eta), float32(s.l2reg), float32(s.clip)
+		for i := 0; i < 5; i++ {
+			copy(backingD32, grads32)
+			for j, w := range correct32 {
+				g := clampFloat32(grads32[j]+l2reg*w, -clip, clip)
+				velocity32[j] = momentum*velocity32[j] + g
+				upd := velocity32[j]
+				if nesterov {
+					upd = g + momentum*velocity32[j]
+				}
+				correct32[j] = w - eta*upd
+			}
+
+			if err := s.Step(model); err != nil {
+				t.Fatal(err)
+			}
+			if !floatsEqual32(correct32, backingV32) {
+				t.Errorf("Nesterov %t, iteration %d: Expected weights %v. Got %v instead", nesterov, i, correct32, backingV32)
+			}
+		}
+	}
+
+	// scalars
+	g := NewGraph()
+	x := NewScalar(g, Float64, WithName("x"), WithValue(2.0))
+	dv := dvUnit0(x.Value())
+	dv.d, _ = anyToScalar(1.0)
+	x.boundTo = dv
+
+	s := NewMomentumSolver(WithLearnRate(0.1), WithMomentum(0.5), WithNesterov())
+	for i := 0; i < 2; i++ {
+		if err := s.Step(Nodes{x}); err != nil {
+			t.Fatal(err)
+		}
+		dv.d, _ = anyToScalar(1.0)
+	}
+	// v1 = 1, w1 = 2 - 0.1*(1 + 0.5*1) = 1.85
+	// v2 = 1.5, w2 = 1.85 - 0.1*(1 + 0.5*1.5) = 1.675
+	if got := extractF64(x.Value()); math.Abs(got-1.675) > 1e-10 {
+		t.Errorf("Expected 1.675. Got %v instead", got)
+	}
+
+	// a clip of 0 does not clip, like it does for tensors
+	dv.d, _ = anyToScalar(1.0)
+	s = NewMomentumSolver(WithLearnRate(0.1), WithMomentum(0.5), WithClip(0))
+	if err := s.Step(Nodes{x}); err != nil {
+		t.Fatal(err)
+	}
+	if got := extractF64(x.Value()); math.Abs(got-1.575) > 1e-10 {
+		t.Errorf("Expected 1.575. Got %v instead", got)
+	}
+}
+
+func TestElementwiseSolvers(t *testing.T) {
+	b1, b2, eps, eta := 0.9, 0.999, 1e-8, 0.01
+
+	// each reference returns the updates of a single element, given its gradient at every iteration
+	type ref func() func(w, g float64, iter int) float64
+	adamLike := func(update func(m, v, g float64, iter int) float64, amsgrad bool) ref {
+		return func() func(w, g float64, iter int) float64 {
+			var m, v, vmax float64
+			return func(w, g float64, iter int) float64 {
+				m = b1*m + (1-b1)*g
+				v = b2*v + (1-b2)*g*g
+				vmax = math.Max(vmax, v)
+				if amsgrad {
+					return w - update(m, vmax, g, iter)
+				}
+				return w - update(m, v, g, iter)
+			}
+		}
+	}
+	adam := func(m, v, g float64, iter int) float64 {
+		mHat := m / (1 - math.Pow(b1, float64(iter)))
+		vHat := v / (1 - math.Pow(b2, float64(iter)))
+		return eta * mHat / (math.Sqrt(vHat) + eps)
+	}
+
+	cases := []struct {
+		name   string
+		solver func() Solver
+		ref    ref
+	}{
+		{"AdamW", func() Solver {
+			return NewAdamWSolver(WithLearnRate(eta), WithWeightDecay(0.1))
+		}, func() func(w, g float64, iter int) float64 {
+			upd := adamLike(adam, false)()
+			return func(w, g float64, iter int) float64 {
+				return upd(w, g, iter) - eta*0.1*w
+			}
+		}},
+		{"AMSGrad", func() Solver { return NewAMSGradSolver(WithLearnRate(eta)) }, adamLike(adam, true)},
+		{"Nadam", func() Solver { return NewNadamSolver(WithLearnRate(eta)) }, adamLike(func(m, v, g float64, iter int) float64 {
+			mHat := b1*m/(1-math.Pow(b1, float64(iter+1))) + (1-b1)*g/(1-math.Pow(b1, float64(iter)))
+			vHat := v / (1 - math.Pow(b2, float64(iter)))
+			return eta * mHat / (math.Sqrt(vHat) + eps)
+		}, false)},
+		{"Adamax", func() Solver { return NewAdamaxSolver(WithLearnRate(eta)) }, func() func(w, g float64, iter int) float64 {
+			var m, u float64
+			return func(w, g float64, iter int) float64 {
+				m = b1*m + (1-b1)*g
+				u = math.Max(b2*u, math.Abs(g))
+				return w - eta/(1-math.Pow(b1, float64(iter)))*m/(u+eps)
+			}
+		}},
+		{"Adadelta", func() Solver { return NewAdadeltaSolver(WithRho(0.9)) }, func() func(w, g float64, iter int) float64 {
+			var eg, edx float64
+			return func(w, g float64, iter int) float64 {
+				eg = 0.9*eg + 0.1*g*g
+				dx := -math.Sqrt(edx+1e-6) / math.Sqrt(eg+1e-6) * g
+				edx = 0.9*edx + 0.1*dx*dx
+				return w + dx
+			}
+		}},
+	}
+
+	grads := []float64{0.5, -10, 10, 0.5}
+	for _, c := range cases {
+		// float64
+		s := c.solver()
+		model := tf64Node()
+		backingV := model[0].Value().Data().([]float64)
+		grad0, _ := model[0].Grad()
+		backingD := grad0.Data().([]float64)
+
+		correct := []float64{1, 2, 3, 4}
+		refs := make([]func(w, g float64, iter int) float64, len(correct))
+		for j := range refs {
+			refs[j] = c.ref()
+		}
+
+		for i := 1; i <= 5; i++ {
+			copy(backingD, grads)
+			for j, w := range correct {
+				correct[j] = refs[j](w, grads[j], i)
+			}
+			if err := s.Step(model); err != nil {
+				t.Fatalf("%v: %v", c.name, err)
+			}
+			if !floatsEqual64(correct, backingV) {
+				t.Errorf("%v, iteration %d: Expected %v. Got %v instead", c.name, i, correct, backingV)
+			}
+		}
+
+		// float32
+		s = c.solver()
+		model = tf32Node()
+		backingV32 := model[0].Value().Data().([]float32)
+		grad0, _ = model[0].Grad()
+		backingD32 := grad0.Data().([]float32)
+
+		correct = []float64{1, 2, 3, 4}
+		for j := range refs {
+			refs[j] = c.ref()
+		}
+		for i := 1; i <= 5; i++ {
+			for j, g := range grads {
+				backingD32[j] = float32(g)
+			}
+			for j, w := range correct {
+				correct[j] = refs[j](w, grads[j], i)
+			}
+			if err := s.Step(model); err != nil {
+				t.Fatalf("%v: %v", c.name, err)
+			}
+			for j, w := range correct {
+				if math.Abs(w-float64(backingV32[j])) > 1e-4 {
+					t.Errorf("%v, iteration %d: Expected %v. Got %v instead", c.name, i, correct, backingV32)
+					break
+				}
+			}
+		}
+
+		// scalars
+		s = c.solver()
+		x := NewScalar(NewGraph(), Float64, WithValue(1.0))
+		dv := dvUnit0(x.Value())
+		x.boundTo = dv
+		upd := c.ref()
+		w := 1.0
+		for i := 1; i <= 3; i++ {
+			dv.d, _ = anyToScalar(0.5)
+			w = upd(w, 0.5, i)
+			if err := s.Step(Nodes{x}); err != nil {
+				t.Fatalf("%v: %v", c.name, err)
+			}
+		}
+		if got := extractF64(x.Value()); math.Abs(got-w) > 1e-10 {
+			t.Errorf("%v: Expected the scalar to be %v. Got %v instead", c.name, w, got)
+		}
+
+		// the L1 penalty of a zero weight is zero
+		s = c.solver()
+		WithL1Reg(0.5)(s)
+		model = tf64Node()
+		backingV = model[0].Value().Data().([]float64)
+		grad0, _ = model[0].Grad()
+		backingD = grad0.Data().([]float64)
+		backingV[0] = 0
+		for i := 1; i <= 3; i++ {
+			backingD[0] = 0
+			if err := s.Step(model); err != nil {
+				t.Fatalf("%v: %v", c.name, err)
+			}
+		}
+		if backingV[0] != 0 {
+			t.Errorf("%v: Expected a zero weight with a zero gradient to stay zero with L1 regularization. Got %v", c.name, backingV[0])
+		}
+	}
+}
+
+func TestLBFGSSolver(t *testing.T) {
+	// Rosenbrock's function: (1-x)² + 100(y-x²)². The minimum is at (1, 1)
+	g := NewGraph()
+	x := NewScalar(g, Float64, WithName("x"), WithValue(-1.2))
+	y := NewScalar(g, Float64, WithName("y"), WithValue(1.0))
+
+	a := Must(Sub(NewConstant(1.0), x))
+	b := Must(Sub(y, Must(HadamardProd(x, x))))
+	cost := Must(Add(Must(HadamardProd(a, a)), Must(HadamardProd(NewConstant(100.0), Must(HadamardProd(b, b))))))
+	if _, err := Grad(cost, x, y); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewTapeMachine(g, BindDualValues(x, y))
+	closure := func() (float64, error) {
+		m.Reset()
+		if err := m.RunAll(); err != nil {
+			return 0, err
+		}
+		return extractF64(cost.Value()), nil
+	}
+
+	s := NewLBFGSSolver(closure, WithMaxIterations(100))
+	if err := s.Step(Nodes{x, y}); err != nil {
+		t.Fatal(err)
+	}
+
+	xv, yv := extractF64(x.Value()), extractF64(y.Value())
+	if math.Abs(xv-1) > 1e-4 || math.Abs(yv-1) > 1e-4 {
+		t.Errorf("Expected the minimum to be at (1, 1). Got (%v, %v) after %d evaluations", xv, yv, s.evals)
+	}
+	if s.evals > 200 {
+		t.Errorf("Expected L-BFGS to converge in fewer evaluations. Took %d", s.evals)
+	}
+	if grad, _ := x.Grad(); extractF64(grad) != 0 {
+		t.Errorf("Expected the gradients to be cleared after the step. Got %v", grad)
+	}
+
+	// least squares fit of y = 3x + 2 in float32
+	g = NewGraph()
+	xs := NewVector(g, Float32, WithName("xs"), WithShape(5), WithValue(tensor.New(tensor.WithBacking([]float32{0, 1, 2, 3, 4}))))
+	ys := NewVector(g, Float32, WithName("ys"), WithShape(5), WithValue(tensor.New(tensor.WithBacking([]float32{2, 5, 8, 11, 14}))))
+	w := NewVector(g, Float32, WithName("w"), WithShape(2), WithValue(tensor.New(tensor.WithBacking([]float32{0, 0}))))
+
+	slope := Must(Slice(w, S(0)))
+	intercept := Must(Slice(w, S(1)))
+	pred := Must(Add(Must(HadamardProd(xs, slope)), intercept))
+	diff := Must(Sub(pred, ys))
+	loss := Must(Sum(Must(HadamardProd(diff, diff))))
+	if _, err := Grad(loss, w); err != nil {
+		t.Fatal(err)
+	}
+
+	m = NewTapeMachine(g, BindDualValues(w))
+	s = NewLBFGSSolver(func() (float64, error) {
+		m.Reset()
+		if err := m.RunAll(); err != nil {
+			return 0, err
+		}
+		return float64(loss.Value().Data().(float32)), nil
+	})
+	if err := s.Step(Nodes{w}); err != nil {
+		t.Fatal(err)
+	}
+
+	fitted := w.Value().Data().([]float32)
+	if math.Abs(float64(fitted[0]-3)) > 1e-3 || math.Abs(float64(fitted[1]-2)) > 1e-3 {
+		t.Errorf("Expected the fit to be [3 2]. Got %v after %d evaluations", fitted, s.evals)
+	}
+}
+
+func TestWithParamGroup(t *testing.T) {
+	g := NewGraph()
+	w := NewScalar(g, Float64, WithName("w"), WithValue(2.0))
+	b := NewScalar(g, Float64, WithName("b"), WithValue(2.0), WithGroupName("bias"))
+	p := NewScalar(g, Float64, WithName("p"), WithValue(2.0), WithGroupName("pretrained"))
+	model := Nodes{w, b, p}
+	setGrads := func() {
+		for _, n := range model {
+			dv, ok := n.boundTo.(*dualValue)
+			if !ok {
+				dv = dvUnit0(n.boundTo)
+				n.boundTo = dv
+			}
+			dv.d, _ = anyToScalar(1.0)
+		}
+	}
+
+	s := NewVanillaSolver(WithLearnRate(1), WithL2Reg(0.5), WithParamGroup("bias", WithL2Reg(0)), WithParamGroup("pretrained", WithLearnRate(0.1)))
+	setGrads()
+	if err := s.Step(model); err != nil {
+		t.Fatal(err)
+	}
+
+	// w: 2 - 1*(1 + 0.5*2)
+	// b: 2 - 1*1
+	// p: 2 - 0.1*(1 + 0.5*2)
+	for i, expected := range []float64{0, 1, 1.8} {
+		if got := extractF64(model[i].Value()); math.Abs(got-expected) > 1e-10 {
+			t.Errorf("Expected %v to be %v. Got %v instead", model[i], expected, got)
+		}
+	}
+	if len(s.groups.solvers) != 3 {
+		t.Errorf("Expected 3 solvers for the groups. Got %d", len(s.groups.solvers))
+	}
+
+	// each group keeps its own caches
+	a := NewAdamSolver(WithParamGroup("bias", WithLearnRate(0.1)))
+	for i := 0; i < 2; i++ {
+		setGrads()
+		if err := a.Step(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, solver := range a.groups.solvers {
+		as := solver.(*AdamSolver)
+		if as.iter != 2 {
+			t.Errorf("Group %q: Expected 2 iterations. Got %d", name, as.iter)
+		}
+		if name == "bias" && (len(as.cache) != 1 || as.eta != 0.1) {
+			t.Errorf("Group %q: Expected a cache of 1 node and a learn rate of 0.1. Got %d and %v", name, len(as.cache), as.eta)
+		}
+	}
+	if a.iter != 0 || a.cache != nil {
+		t.Error("Expected the solver with parameter groups not to step by itself")
+	}
+
+	// the gradients are clipped by the global norm of the whole model, not of each group
+	for _, n := range model {
+		n.boundTo = dvUnit0(newF64(2.0))
+	}
+	model[0].boundTo.(*dualValue).d, _ = anyToScalar(3.0)
+	model[1].boundTo.(*dualValue).d, _ = anyToScalar(4.0)
+	model[2].boundTo.(*dualValue).d, _ = anyToScalar(0.0)
+	c := NewVanillaSolver(WithLearnRate(1), WithGlobalNormClip(1), WithParamGroup("bias"))
+	if err := c.Step(model); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []float64{1.4, 1.2, 2} {
+		if got := extractF64(model[i].Value()); math.Abs(got-expected) > 1e-10 {
+			t.Errorf("Expected %v to be %v. Got %v instead", model[i], expected, got)
+		}
+	}
+	for name, solver := range c.groups.solvers {
+		if vs := solver.(*VanillaSolver); vs.maxNorm != 0 {
+			t.Errorf("Group %q: Expected the copy of the solver not to clip the gradients again. Got a maximum norm of %v", name, vs.maxNorm)
+		}
+	}
+}
@@ -178,7 +178,12 @@ func logicalSize(s tensor.Shape) int {
 	return s.TotalSize()
 }
 
+// calcMemSize returns the number of bytes a value of the given dtype and shape takes up. Shapes with symbolic dimensions have no
+// size until the dimensions are known at run time, so their size is 0.
 func calcMemSize(dt tensor.Dtype, s tensor.Shape) int64 {
+	if hasDynDims(s) {
+		return 0
+	}
 	var elemSize int64
 	if s.IsScalar() {
 		elemSize = 1
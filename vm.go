@@ -2,8 +2,10 @@ package gorgonia
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"os"
+	"runtime"
 )
 
 // VM represents a structure that can execute a graph or program. There are two VMs (both unexported):
@@ -22,6 +24,17 @@ type VM interface {
 	Reset()
 }
 
+// ContextVM is a VM that can be stopped by a context. Both VMs of this package are ContextVMs.
+type ContextVM interface {
+	VM
+	RunAllContext(ctx context.Context) error
+}
+
+var (
+	_ ContextVM = &tapeMachine{}
+	_ ContextVM = &lispMachine{}
+)
+
 const (
 	fwdOnly byte = iota
 	bwdOnly
@@ -129,6 +142,52 @@ func WithNaNWatch() VMOpt {
 	return f
 }
 
+// ExecHooks are functions that a VM calls around the execution of the op of every node, for example to collect statistics of the
+// activations, or to calibrate quantization. Any of the functions may be nil. The values passed to the hooks are owned by the VM: they
+// may be overwritten once the hook returns, so clone the values that have to be kept.
+//
+// The *tapeMachine computes the gradients with ops too, so BeforeOp and AfterOp are also called for the nodes of the gradients.
+// The *lispMachine calls BeforeBackward and AfterBackward around the differentiation of every node instead.
+type ExecHooks struct {
+	BeforeOp func(n *Node, inputs []Value) // called with the values of the inputs of n, before its op is executed
+	AfterOp  func(n *Node, out Value)      // called with the value of n, after its op is executed
+
+	BeforeBackward func(n *Node, grad Value)         // called with the gradient of n, before the gradients of its inputs are computed
+	AfterBackward  func(n *Node, inputGrads []Value) // called with the gradients of the inputs of n, after they are computed
+}
+
+// WithHooks creates a VM that calls the hooks around the execution of the op of every node. When a *tapeMachine executes its
+// instructions in parallel (see WithParallelExecution), or has replicas, the hooks may be called concurrently.
+func WithHooks(h ExecHooks) VMOpt {
+	f := func(m VM) {
+		switch v := m.(type) {
+		case *lispMachine:
+			v.hooks = &h
+		case *tapeMachine:
+			v.hooks = &h
+		default:
+			panic(nyi("WithHooks", v))
+		}
+	}
+	return f
+}
+
+// WithBreakpoints creates a VM that stops when any of the breakpoints breaks. The run may then be inspected and continued - see the
+// Step, Inspect and SetBreakpoints methods of the VMs. A *tapeMachine with breakpoints executes its instructions sequentially.
+func WithBreakpoints(bps ...Breakpoint) VMOpt {
+	f := func(m VM) {
+		switch v := m.(type) {
+		case *lispMachine:
+			v.breakpoints = append(v.breakpoints, bps...)
+		case *tapeMachine:
+			v.breakpoints = append(v.breakpoints, bps...)
+		default:
+			panic(nyi("WithBreakpoints", v))
+		}
+	}
+	return f
+}
+
 // WithInfWatch creates a VM that will watch for Infs when executing. It watches for +Inf, -Inf and Inf. No choice there. This slows the execution down.
 func WithInfWatch() VMOpt {
 	f := func(m VM) {
@@ -249,6 +308,97 @@ func BindDualValues(nodes ...*Node) VMOpt {
 	return f
 }
 
+// WithGradAccumulation creates a VM that sums the gradients into the *dualValue derivatives of the input nodes over n calls of RunAll(),
+// allowing for effective batch sizes larger than what fits in a single pass. The derivatives are cleared when the first RunAll() of
+// the next n calls starts, if a Solver hasn't already cleared them.
+//
+// For *tapeMachine this also turns on the binding of dual values (see BindDualValues). Use it with a Solver created with WithAccumulationSteps(n).
+func WithGradAccumulation(n int) VMOpt {
+	f := func(m VM) {
+		if n < 1 {
+			n = 1
+		}
+		switch v := m.(type) {
+		case *lispMachine:
+			v.gradAccum = n
+		case *tapeMachine:
+			v.doBindDV()
+			v.gradAccum = n
+		default:
+			panic(nyi("WithGradAccumulation", v))
+		}
+	}
+	return f
+}
+
+// WithParallelExecution creates a *tapeMachine that executes the independent instructions of the program with a pool of workers goroutines.
+// The instructions are ordered by the registers they read and write, so the results are the same as the results of a sequential execution.
+// If workers is less than 1, runtime.GOMAXPROCS(0) workers are used.
+//
+// The machine executes the instructions sequentially when it logs, when it is profiled, or when the program uses devices other than the CPU.
+func WithParallelExecution(workers int) VMOpt {
+	f := func(m VM) {
+		if workers < 1 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		switch v := m.(type) {
+		case *tapeMachine:
+			v.workers = workers
+		default:
+			// no op
+		}
+	}
+	return f
+}
+
+// WithProfiling records the wall time, the heap allocations and the number of calls of every instruction executed by a *tapeMachine.
+// The costs are read with the Profile method of the machine, and may be exported to pprof or to the Chrome trace event format.
+// The costs of the instructions are running totals, which take a fixed amount of memory. The trace of the executions keeps only the last
+// 65536 of them, so a profiled machine may be run for as long as needed. Call Reset on the Profile to start over.
+// This option is only for *tapeMachine. Try it on any other VMs and it will panic.
+func WithProfiling() VMOpt {
+	f := func(m VM) {
+		switch v := m.(type) {
+		case *tapeMachine:
+			v.prof = newProfile()
+		default:
+			panic(nyi("WithProfiling", v))
+		}
+	}
+	return f
+}
+
+// WithMemoryPlanning creates a *tapeMachine that places the values computed by its program in a single arena, which is allocated once,
+// when the machine is created. The offsets of the values are planned from their live intervals, so values that are not live at the same
+// time share memory. Use the MemoryPlan method of the machine to find out the size of the arena.
+//
+// The values of the nodes are views of the arena, and are overwritten by the next run. The values of the intermediate nodes may be overwritten
+// during the run, once they are no longer needed; the values of the roots of the graph and of the gradients may be read after the run.
+// Clone the values that have to be kept.
+//
+// When the instructions are executed in parallel, the values do not share memory.
+// This option is only for *tapeMachine, and only for programs that run on the CPU.
+func WithMemoryPlanning() VMOpt {
+	f := func(m VM) {
+		switch v := m.(type) {
+		case *tapeMachine:
+			v.planMem = true
+		default:
+			// no op
+		}
+	}
+	return f
+}
+
+// zeroGrads clears the derivatives of the nodes that are bound to a *dualValue.
+func zeroGrads(nodes Nodes) {
+	for _, n := range nodes {
+		if dv, ok := n.boundTo.(*dualValue); ok && dv.d != nil {
+			dv.d = ZeroValue(dv.d)
+		}
+	}
+}
+
 // WithPrecompiled is an option to pass in compiled programs.
 // This is useful for users who use the CompileFunction function
 func WithPrecompiled(prog *program, locMap map[*Node]register) VMOpt {
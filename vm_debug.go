@@ -0,0 +1,261 @@
+package gorgonia
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+// Breakpoint decides when a VM stops. The breakpoints of a VM are checked after every node is computed, and after the gradients of its
+// inputs are computed by a *lispMachine that is backpropagating. When a breakpoint breaks, RunAll returns a *BreakpointError.
+type Breakpoint interface {
+	// Break returns true if the VM should stop after computing v, the value of n (or its gradient, when a *lispMachine is backpropagating).
+	Break(n *Node, v Value) bool
+	fmt.Stringer
+}
+
+type nodeBreakpoint string
+
+// BreakAtNode creates a breakpoint that stops the VM after the node with the given name is computed.
+func BreakAtNode(name string) Breakpoint { return nodeBreakpoint(name) }
+
+func (bp nodeBreakpoint) Break(n *Node, v Value) bool { return n.name == string(bp) }
+func (bp nodeBreakpoint) String() string              { return fmt.Sprintf("breakpoint at node %q", string(bp)) }
+
+type opBreakpoint string
+
+// BreakAtOp creates a breakpoint that stops the VM after every node whose op is the given op. The op is either the String() of an op
+// (for example "+" or "tanh"), or the name of the type of the op (for example "elemBinOp" or "sumOp").
+func BreakAtOp(op string) Breakpoint { return opBreakpoint(op) }
+
+func (bp opBreakpoint) Break(n *Node, v Value) bool {
+	return n.op != nil && (n.op.String() == string(bp) || typeName(n.op) == string(bp))
+}
+func (bp opBreakpoint) String() string { return fmt.Sprintf("breakpoint at op %q", string(bp)) }
+
+type condBreakpoint struct {
+	name string
+	cond func(n *Node, v Value) bool
+}
+
+// BreakIf creates a conditional breakpoint that stops the VM when cond returns true. The name describes the breakpoint in errors.
+func BreakIf(name string, cond func(n *Node, v Value) bool) Breakpoint {
+	return condBreakpoint{name, cond}
+}
+
+// BreakOnNaN creates a breakpoint that stops the VM when a value with a NaN is computed. Unlike WithNaNWatch, the run may be continued.
+func BreakOnNaN() Breakpoint {
+	return BreakIf("NaN", func(n *Node, v Value) bool { return isFloat(v) && hasNaN(v) })
+}
+
+// BreakOnInf creates a breakpoint that stops the VM when a value with an Inf is computed. Unlike WithInfWatch, the run may be continued.
+func BreakOnInf() Breakpoint {
+	return BreakIf("Inf", func(n *Node, v Value) bool { return isFloat(v) && hasInf(v) })
+}
+
+func (bp condBreakpoint) Break(n *Node, v Value) bool { return bp.cond(n, v) }
+func (bp condBreakpoint) String() string              { return fmt.Sprintf("breakpoint %q", bp.name) }
+
+// isFloat returns true if v is a value that hasNaN and hasInf can check
+func isFloat(v Value) bool {
+	switch vt := v.(type) {
+	case *F64, *F32, *dualValue:
+		return true
+	case tensor.Tensor:
+		return vt.Dtype() == tensor.Float64 || vt.Dtype() == tensor.Float32
+	}
+	return false
+}
+
+// typeName is the name of the type of an op, without the package
+func typeName(op Op) string {
+	name := fmt.Sprintf("%T", op)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// breakAt returns a *BreakpointError for the first of the breakpoints that breaks, or nil if none does
+func breakAt(bps []Breakpoint, n *Node, v Value, instr int, backward bool) *BreakpointError {
+	for _, bp := range bps {
+		if bp.Break(n, v) {
+			return &BreakpointError{
+				Breakpoint: bp,
+				Node:       n,
+				Value:      v,
+				Instr:      instr,
+				Backward:   backward,
+			}
+		}
+	}
+	return nil
+}
+
+/* tapeMachine */
+
+// SetBreakpoints replaces the breakpoints of the machine. Calling it without breakpoints removes them all.
+func (m *tapeMachine) SetBreakpoints(bps ...Breakpoint) { m.breakpoints = bps }
+
+// checkBreakpoints checks the breakpoints after the instruction at pc computes the value of a node
+func (m *tapeMachine) checkBreakpoints(pc int, instr tapeInstr) *BreakpointError {
+	if len(m.breakpoints) == 0 {
+		return nil
+	}
+	switch instr.(type) {
+	case *execOp, loadArg:
+	default:
+		return nil
+	}
+	n, ok := m.p.g.Node(instr.ID()).(*Node)
+	if !ok {
+		return nil
+	}
+	return breakAt(m.breakpoints, n, m.getValue(instr.writes()), pc, false)
+}
+
+// Step executes the next instruction of the program, and returns the node that the instruction is tied to, if any. Breakpoints are
+// not checked. Stepping through a program that has been run to the end does nothing; call Reset() to step through it again.
+//
+// Step executes the instruction on the calling goroutine, so it is only supported for programs that run on the CPU.
+func (m *tapeMachine) Step() (n *Node, err error) {
+	if m.p.gpulocs > 0 || m.b != nil {
+		return nil, errors.Errorf(nyiFail, "Step", "programs that use devices other than the CPU")
+	}
+	if m.Done() {
+		return nil, nil
+	}
+	if m.pc == 0 {
+		m.accumulateGrads()
+		if err = m.resolveDims(); err != nil {
+			return nil, err
+		}
+	}
+
+	instr := m.p.instructions[m.pc]
+	if id := instr.ID(); id >= 0 {
+		n, _ = m.p.g.Node(id).(*Node)
+	}
+	if err = m.exec(m.pc, instr); err != nil {
+		return n, m.execError(m.pc, instr, err)
+	}
+	if err = m.watchValue(instr); err != nil {
+		return n, err
+	}
+	m.pc++
+	return n, nil
+}
+
+// Done returns true if every instruction of the program has been executed.
+func (m *tapeMachine) Done() bool { return m.pc >= len(m.p.instructions) }
+
+// Inspect returns the value in the register of the node. The value is the one computed by the instructions executed so far, so it may be
+// the value computed by the previous run, or nil if the node has never been computed.
+func (m *tapeMachine) Inspect(n *Node) (Value, error) {
+	reg, ok := m.locMap[n]
+	if !ok {
+		return nil, errors.Errorf("Node %v is not computed by this program", n)
+	}
+	return m.getValue(reg), nil
+}
+
+/* lispMachine */
+
+// SetBreakpoints replaces the breakpoints of the machine. Calling it without breakpoints removes them all.
+func (m *lispMachine) SetBreakpoints(bps ...Breakpoint) { m.breakpoints = bps }
+
+// breakForward checks the breakpoints after the node at m.fwd is computed
+func (m *lispMachine) breakForward() *BreakpointError {
+	if len(m.breakpoints) == 0 {
+		return nil
+	}
+	n := m.sorted[m.fwd]
+	if n.isStmt {
+		return nil
+	}
+	return breakAt(m.breakpoints, n, n.Value(), m.fwd, false)
+}
+
+// breakBackward checks the breakpoints after the gradients of the inputs of the differentiation instruction at m.bwd are computed
+func (m *lispMachine) breakBackward() *BreakpointError {
+	if len(m.breakpoints) == 0 {
+		return nil
+	}
+	for _, in := range m.q[m.bwd].inputs {
+		var d Value
+		if dv, ok := in.boundTo.(*dualValue); ok {
+			d = dv.d
+		}
+		if brk := breakAt(m.breakpoints, in, d, m.bwd, true); brk != nil {
+			return brk
+		}
+	}
+	return nil
+}
+
+// Step computes the next node, or, once every node is computed, executes the next differentiation instruction. It returns the node that
+// was computed, or the output of the differentiation instruction. Breakpoints are not checked.
+//
+// Step executes the node on the calling goroutine, so it is only supported for nodes on the CPU.
+func (m *lispMachine) Step() (n *Node, err error) {
+	if m.Done() {
+		return nil, nil
+	}
+	if !m.stopped {
+		if err = m.checkRoots(); err != nil {
+			return nil, errors.Wrap(err, "Could not checkRoots()")
+		}
+		m.accumulateGrads()
+		m.bwdStarted = false
+		m.stopped = true
+	}
+
+	if m.runFwd() && m.fwd < len(m.sorted) {
+		n = m.sorted[m.fwd]
+		if n.Device() != CPU {
+			return n, errors.Errorf(nyiFail, "Step", "nodes on devices other than the CPU")
+		}
+		if err = m.execForward(); err != nil {
+			return n, m.execError(m.fwd, false, err)
+		}
+		m.fwd++
+	} else {
+		if !m.bwdStarted {
+			m.bwd = len(m.q) - 1
+			m.bwdStarted = true
+		}
+		n = m.q[m.bwd].output
+		if err = m.execBackward(); err != nil {
+			return n, m.execError(m.bwd, true, err)
+		}
+		m.bwd--
+	}
+
+	if m.Done() {
+		m.stopped = false
+		m.q = nil
+	}
+	return n, nil
+}
+
+// Done returns true if every node has been computed, and, if the machine backpropagates, every differentiation instruction has been
+// executed.
+func (m *lispMachine) Done() bool {
+	if m.runFwd() && m.fwd < len(m.sorted) {
+		return false
+	}
+	if !m.runBwd() {
+		return true
+	}
+	if !m.bwdStarted {
+		return len(m.q) == 0
+	}
+	return m.bwd < 0
+}
+
+// Inspect returns the value bound to the node, or nil if the node has not been computed yet.
+func (m *lispMachine) Inspect(n *Node) (Value, error) {
+	if !m.g.Has(n) {
+		return nil, errors.Errorf("Node %v does not exist in this graph", n)
+	}
+	return n.Value(), nil
+}
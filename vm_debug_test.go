@@ -0,0 +1,144 @@
+package gorgonia
+
+import (
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+// nanGraph is a graph where the log of a negative number is a NaN
+func nanGraph() (g *ExprGraph, logx, cost *Node) {
+	g = NewGraph()
+	x := NewVector(g, Float64, WithShape(3), WithName("x"), WithValue(tensor.New(tensor.WithBacking([]float64{1, -1, 2}))))
+	logx = Must(Log(x))
+	WithName("logx")(logx)
+	cost = Must(Sum(Must(Square(logx))))
+	return
+}
+
+func TestTapeMachineBreakpoints(t *testing.T) {
+	g, _, _, cost := wideGraph(2, true)
+	m := NewTapeMachine(g, WithBreakpoints(BreakAtOp("tanh")), WithParallelExecution(2))
+
+	var stops int
+	for {
+		err := m.RunAll()
+		if err == nil {
+			break
+		}
+		brk, ok := err.(*BreakpointError)
+		if !ok {
+			t.Fatal(err)
+		}
+		if brk.Node.op.String() != "tanh" {
+			t.Errorf("Expected to stop after a tanh. Stopped after %v instead", brk.Node)
+		}
+		v, err := m.Inspect(brk.Node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != brk.Value {
+			t.Errorf("Expected Inspect to return the value of %v", brk.Node)
+		}
+		stops++
+	}
+	if stops != 2 {
+		t.Errorf("Expected to stop at both tanhs. Stopped %d times", stops)
+	}
+
+	g2, _, _, cost2 := wideGraph(2, true)
+	m2 := NewTapeMachine(g2)
+	if err := m2.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	if !ValueEq(cost.Value(), cost2.Value()) {
+		t.Errorf("Expected the continued run to compute %v. Got %v instead", cost2.Value(), cost.Value())
+	}
+
+	// NaNs
+	g, logx, cost := nanGraph()
+	m = NewTapeMachine(g, WithBreakpoints(BreakOnNaN()))
+	err := m.RunAll()
+	brk, ok := err.(*BreakpointError)
+	if !ok {
+		t.Fatalf("Expected a *BreakpointError. Got %v instead", err)
+	}
+	if brk.Node != logx {
+		t.Errorf("Expected the first NaN to be in %v. Got %v instead", logx, brk.Node)
+	}
+
+	// stepping
+	m.SetBreakpoints()
+	m.Reset()
+	var steps int
+	for !m.Done() {
+		if _, err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+		steps++
+	}
+	if steps != len(m.p.instructions) {
+		t.Errorf("Expected %d steps. Got %d instead", len(m.p.instructions), steps)
+	}
+	if v, _ := m.Inspect(cost); v == nil || !hasNaN(v) {
+		t.Errorf("Expected the cost to be NaN. Got %v", v)
+	}
+}
+
+func TestLispMachineBreakpoints(t *testing.T) {
+	g, _, ws, cost := wideGraph(2, false)
+	m := NewLispMachine(g, WithBreakpoints(BreakAtNode(ws[0].Name())))
+
+	var fwd, bwd int
+	for {
+		err := m.RunAll()
+		if err == nil {
+			break
+		}
+		brk, ok := err.(*BreakpointError)
+		if !ok {
+			t.Fatal(err)
+		}
+		if brk.Node != ws[0] {
+			t.Errorf("Expected to stop at %v. Stopped at %v instead", ws[0], brk.Node)
+		}
+		if brk.Backward {
+			bwd++
+		} else {
+			fwd++
+		}
+	}
+	if fwd != 1 || bwd != 1 {
+		t.Errorf("Expected to stop once forwards and once backwards. Got %d and %d", fwd, bwd)
+	}
+
+	g2, _, ws2, cost2 := wideGraph(2, false)
+	if err := NewLispMachine(g2).RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	if !ValueEq(cost.Value(), cost2.Value()) {
+		t.Errorf("Expected the continued run to compute %v. Got %v instead", cost2.Value(), cost.Value())
+	}
+	for i := range ws {
+		grad, _ := ws[i].Grad()
+		grad2, _ := ws2[i].Grad()
+		if !ValueEq(grad, grad2) {
+			t.Errorf("Expected the gradient of %v to be the same in the continued run", ws[i])
+		}
+	}
+
+	// stepping
+	g, _, _, cost = wideGraph(2, false)
+	m = NewLispMachine(g)
+	for !m.Done() {
+		if _, err := m.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !ValueEq(cost.Value(), cost2.Value()) {
+		t.Errorf("Expected stepping to compute %v. Got %v instead", cost2.Value(), cost.Value())
+	}
+	if v, err := m.Inspect(cost); err != nil || v != cost.Value() {
+		t.Errorf("Expected Inspect to return the value of the cost. Got %v, %v", v, err)
+	}
+}
@@ -2,6 +2,7 @@ package gorgonia
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -27,6 +28,16 @@ type lispMachine struct {
 	fwd    int
 	bwd    int
 
+	// gradient accumulation
+	gradAccum int // number of runs to accumulate gradients over
+	runs      int // number of runs since the machine was created
+
+	// debugging
+	hooks       *ExecHooks
+	breakpoints []Breakpoint
+	stopped     bool // true if a run was stopped by a breakpoint or is being stepped through
+	bwdStarted  bool // true if the backpropagation of the current run has started
+
 	// logging stuff
 	watchlist Nodes
 	logger    *log.Logger
@@ -102,17 +113,32 @@ func (m *lispMachine) Reset() {
 	m.bwd = len(m.q) - 1
 }
 
-func (m *lispMachine) RunAll() (err error) {
+// RunAll runs the graph forwards, and then backwards, as configured.
+func (m *lispMachine) RunAll() (err error) { return m.RunAllContext(context.Background()) }
+
+// RunAllContext runs the graph, checking ctx before every node, forwards and backwards. If ctx is done, the execution stops, and a
+// *CanceledError with the node that would have been executed next is returned.
+//
+// If a breakpoint stops the execution, a *BreakpointError is returned, and calling RunAll again continues the run.
+func (m *lispMachine) RunAllContext(ctx context.Context) (err error) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
-	if err = m.checkRoots(); err != nil {
-		return errors.Wrap(err, "Could not checkRoots()")
+	if !m.stopped {
+		if err = m.checkRoots(); err != nil {
+			return errors.Wrap(err, "Could not checkRoots()")
+		}
+
+		m.accumulateGrads()
+		m.bwdStarted = false
 	}
+	m.stopped = false
 
 	if m.runBwd() {
 		defer func() {
-			m.q = nil // this needs to be nil'd or else there would still be references to m. Then there won't be any garbage being collected
+			if !m.stopped {
+				m.q = nil // this needs to be nil'd or else there would still be references to m. Then there won't be any garbage being collected
+			}
 		}()
 	}
 
@@ -126,7 +152,7 @@ func (m *lispMachine) RunAll() (err error) {
 	errChan := make(chan error)
 	doneChan := make(chan struct{})
 
-	go m.runall(errChan, doneChan)
+	go m.runall(ctx, errChan, doneChan)
 	for {
 		select {
 		case synchronous := <-workAvailable:
@@ -159,12 +185,8 @@ func (m *lispMachine) RunAll() (err error) {
 				syncChan <- struct{}{}
 			}
 		case err = <-errChan:
-			if m.fwd < len(m.sorted) {
-				err = vmContextualError{
-					error: errors.Wrapf(err, "Running Node: %v", m.sorted[m.fwd]),
-					node:  m.sorted[m.fwd],
-					instr: m.fwd,
-				}
+			switch err.(type) {
+			case *CanceledError, *BreakpointError, *ExecError:
 				return
 			}
 			return errors.Wrap(err, "RunAll")
@@ -180,6 +202,27 @@ func (m *lispMachine) RunAll() (err error) {
 	return nil
 }
 
+// accumulateGrads is called at the start of every RunAll(). When a new window of gradient accumulation begins, the derivatives of the input nodes are cleared.
+func (m *lispMachine) accumulateGrads() {
+	if m.gradAccum == 0 {
+		return
+	}
+	if m.runs > 0 {
+		m.fwd = 0 // a machine that accumulates gradients executes the whole graph again on every run
+	}
+
+	if m.runs > 0 && m.runs%m.gradAccum == 0 {
+		var inputs Nodes
+		for _, n := range m.sorted {
+			if n.isInput() {
+				inputs = append(inputs, n)
+			}
+		}
+		zeroGrads(inputs)
+	}
+	m.runs++
+}
+
 func (m *lispMachine) UnbindAll() {
 	// if m.dealloc() {
 	for _, n := range m.sorted {
@@ -247,18 +290,26 @@ func (m *lispMachine) prepGraph() (err error) {
 	return
 }
 
-func (m *lispMachine) runall(errChan chan error, doneChan chan struct{}) {
-	var err error
+func (m *lispMachine) runall(ctx context.Context, errChan chan error, doneChan chan struct{}) {
 	if !m.runFwd() {
 		goto backward
 	}
 
-	for err = nil; err == nil && m.fwd < len(m.sorted); m.fwd++ {
-		err = m.forward()
-	}
-
-	if err != nil {
-		errChan <- err
+	for ; m.fwd < len(m.sorted); m.fwd++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			errChan <- &CanceledError{Node: m.sorted[m.fwd], Instr: m.fwd, Err: ctxErr}
+			return
+		}
+		if err := m.execForward(); err != nil {
+			errChan <- m.execError(m.fwd, false, err)
+			return
+		}
+		if brk := m.breakForward(); brk != nil {
+			m.fwd++
+			m.stopped = true
+			errChan <- brk
+			return
+		}
 	}
 
 	// send a synchronous signal, do all (if any) CUDA work before continuing with backprop
@@ -270,19 +321,89 @@ backward:
 		return
 	}
 
-	if m.bwd < 0 {
+	if !m.bwdStarted {
 		m.bwd = len(m.q) - 1
+		m.bwdStarted = true
 	}
 
-	for err = nil; err == nil && m.bwd >= 0; m.bwd-- {
-		err = m.backward()
-	}
-	if err != nil {
-		errChan <- err
+	for ; m.bwd >= 0; m.bwd-- {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			errChan <- &CanceledError{Node: m.q[m.bwd].output, Instr: m.bwd, Backward: true, Err: ctxErr}
+			return
+		}
+		if err := m.execBackward(); err != nil {
+			errChan <- m.execError(m.bwd, true, err)
+			return
+		}
+		if brk := m.breakBackward(); brk != nil {
+			m.bwd--
+			m.stopped = true
+			errChan <- brk
+			return
+		}
 	}
 	doneChan <- struct{}{}
 }
 
+// execForward computes the node at m.fwd, calling the hooks if its op is executed
+func (m *lispMachine) execForward() (err error) {
+	n := m.sorted[m.fwd]
+	if m.hooks == nil || n.isArg() || n.isStmt {
+		return m.forward()
+	}
+
+	if m.hooks.BeforeOp != nil {
+		inputs := make([]Value, len(n.children))
+		for i, child := range n.children {
+			inputs[i] = child.Value()
+		}
+		m.hooks.BeforeOp(n, inputs)
+	}
+	if err = m.forward(); err == nil && m.hooks.AfterOp != nil {
+		m.hooks.AfterOp(n, n.Value())
+	}
+	return
+}
+
+// execBackward executes the differentiation instruction at m.bwd, calling the hooks
+func (m *lispMachine) execBackward() (err error) {
+	if m.hooks == nil || m.bwd < 0 || m.bwd >= len(m.q) {
+		return m.backward()
+	}
+
+	instr := m.q[m.bwd]
+	if m.hooks.BeforeBackward != nil {
+		grad, _ := instr.output.Grad()
+		m.hooks.BeforeBackward(instr.output, grad)
+	}
+	if err = m.backward(); err == nil && m.hooks.AfterBackward != nil {
+		grads := make([]Value, len(instr.inputs))
+		for i, in := range instr.inputs {
+			grads[i], _ = in.Grad()
+		}
+		m.hooks.AfterBackward(instr.output, grads)
+	}
+	return
+}
+
+// execError creates the error for a failure to compute the ith node, or to execute the ith differentiation instruction if backward
+func (m *lispMachine) execError(i int, backward bool, err error) *ExecError {
+	var n *Node
+	var op Op
+	var children Nodes
+	if backward {
+		n, op, children = m.q[i].output, m.q[i].ADOp, m.q[i].inputs
+	} else {
+		n = m.sorted[i]
+		op, children = n.op, n.children
+	}
+	inputs := make([]Value, len(children))
+	for j, child := range children {
+		inputs[j] = child.Value()
+	}
+	return newExecError(n, op, children, inputs, i, backward, err)
+}
+
 func (m *lispMachine) forward() (err error) {
 	if m.fwd < 0 {
 		return nil // or err?
@@ -393,7 +514,7 @@ func (m *lispMachine) forward() (err error) {
 			machineLogf("dvBindVar")
 			m.logf("dvBindVar")
 			if output, err = dvBindVar(op, inputs); err != nil {
-
+				return errors.Wrapf(err, execFail, op, n)
 			}
 			if err = n.bind(output); err != nil {
 				return errors.Wrap(err, bindFail)
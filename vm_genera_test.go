@@ -2,6 +2,7 @@ package gorgonia
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"runtime"
 	"testing"
@@ -213,3 +214,94 @@ func TestLispMachineRepeatedRuns(t *testing.T) {
 	}
 
 }
+
+func TestLispMachineGradAccumulation(t *testing.T) {
+	g := NewGraph()
+	x := NewMatrix(g, Float64, WithShape(2, 2), WithName("x"), WithInit(Zeroes()))
+	w := NewMatrix(g, Float64, WithShape(2, 2), WithName("w"), WithInit(RangedFrom(1)))
+	Must(Sum(Must(Square(Must(HadamardProd(x, w)))))) // ∂/∂w = 2 * x² * w
+
+	microBatches := [][]float64{
+		{1, 2, 3, 4},
+		{3, 4, 5, 6},
+		{-1, 1, -2, 2},
+		{1, 1, 1, 1},
+	}
+	const n = 2
+
+	// the gradient of each micro-batch on its own
+	perBatch := make([][]float64, len(microBatches))
+	for i, mb := range microBatches {
+		zeroGrads(Nodes{x, w})
+		Let(x, tensor.New(tensor.WithShape(2, 2), tensor.WithBacking(mb)))
+		if err := NewLispMachine(g).RunAll(); err != nil {
+			t.Fatalf("Micro-batch %d: %v", i, err)
+		}
+		grad, err := w.Grad()
+		if err != nil {
+			t.Fatal(err)
+		}
+		perBatch[i] = append([]float64(nil), grad.Data().([]float64)...)
+	}
+
+	zeroGrads(Nodes{x, w})
+	m := NewLispMachine(g, WithGradAccumulation(n))
+	expected := make([]float64, 4)
+	for i, mb := range microBatches {
+		if i%n == 0 {
+			expected = make([]float64, 4)
+		}
+		for j, g := range perBatch[i] {
+			expected[j] += g
+		}
+
+		Let(x, tensor.New(tensor.WithShape(2, 2), tensor.WithBacking(mb)))
+		if err := m.RunAll(); err != nil {
+			t.Fatalf("Micro-batch %d: %v", i, err)
+		}
+		grad, err := w.Grad()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := grad.Data().([]float64); !floatsEqual64(expected, got) {
+			t.Errorf("Micro-batch %d: Expected the gradients of the window to add up to %v. Got %v instead", i, expected, got)
+		}
+		if got := w.Value().Data().([]float64); !floatsEqual64([]float64{1, 2, 3, 4}, got) {
+			t.Errorf("Micro-batch %d: Expected the weights to be unchanged. Got %v", i, got)
+		}
+	}
+}
+
+func TestLispMachineRunAllContext(t *testing.T) {
+	g, _, _, cost := wideGraph(2, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := NewLispMachine(g)
+	err := m.RunAllContext(ctx)
+	cerr, ok := err.(*CanceledError)
+	if !ok {
+		t.Fatalf("Expected a *CanceledError. Got %v instead", err)
+	}
+	if cerr.Node == nil || cerr.Backward {
+		t.Errorf("Expected the execution to stop forwards, at a node. Got %v", cerr)
+	}
+
+	// stopped while backpropagating
+	g, _, _, cost = wideGraph(2, false)
+	m = NewLispMachine(g)
+	sorted, err := Sort(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.RunAllContext(&countdownCtx{Context: context.Background(), n: int32(len(sorted) + 1)})
+	if cerr, ok = err.(*CanceledError); !ok {
+		t.Fatalf("Expected a *CanceledError. Got %v instead", err)
+	}
+	if !cerr.Backward || cerr.Node == nil {
+		t.Errorf("Expected the execution to stop backwards, at a node. Got %v", cerr)
+	}
+	if cost.Value() == nil {
+		t.Error("Expected the forward pass to be done")
+	}
+}
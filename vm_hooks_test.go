@@ -0,0 +1,121 @@
+package gorgonia
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestExecHooks(t *testing.T) {
+	build := func() (g *ExprGraph, x, y, z, cost *Node) {
+		g = NewGraph()
+		x = NewVector(g, Float64, WithShape(3), WithName("x"), WithValue(tensor.New(tensor.WithBacking([]float64{1, 2, 3}))))
+		y = NewVector(g, Float64, WithShape(3), WithName("y"), WithValue(tensor.New(tensor.WithBacking([]float64{-4, -5, 6}))))
+		z = Must(Add(x, y))
+		cost = Must(Sum(Must(Rectify(z))))
+		return
+	}
+
+	type record struct {
+		sync.Mutex
+		before, after        map[*Node]int
+		bwdBefore, bwdAfter  map[*Node]int
+		zIn                  []Value
+		zOut                 Value
+		dead                 int // the number of the elements of z that are not activated by the ReLU
+		inputGrads, nilGrads int
+	}
+	hooks := func(r *record, z *Node) ExecHooks {
+		r.before, r.after = make(map[*Node]int), make(map[*Node]int)
+		r.bwdBefore, r.bwdAfter = make(map[*Node]int), make(map[*Node]int)
+		return ExecHooks{
+			BeforeOp: func(n *Node, inputs []Value) {
+				r.Lock()
+				defer r.Unlock()
+				r.before[n]++
+				if n == z {
+					r.zIn = inputs
+				}
+			},
+			AfterOp: func(n *Node, out Value) {
+				r.Lock()
+				defer r.Unlock()
+				r.after[n]++
+				if n == z {
+					r.zOut = out
+					for _, v := range out.Data().([]float64) {
+						if v <= 0 {
+							r.dead++
+						}
+					}
+				}
+			},
+			BeforeBackward: func(n *Node, grad Value) { r.bwdBefore[n]++ },
+			AfterBackward: func(n *Node, inputGrads []Value) {
+				r.bwdAfter[n]++
+				for _, d := range inputGrads {
+					r.inputGrads++
+					if d == nil {
+						r.nilGrads++
+					}
+				}
+			},
+		}
+	}
+	check := func(name string, r *record, z *Node) {
+		if len(r.before) == 0 || len(r.before) != len(r.after) {
+			t.Errorf("%s: Expected the hooks to be called before and after every op. Got %d and %d nodes", name, len(r.before), len(r.after))
+		}
+		for n, c := range r.before {
+			if n.isInput() {
+				t.Errorf("%s: Expected the hooks not to be called for the input %v", name, n)
+			}
+			if c != 1 || r.after[n] != 1 {
+				t.Errorf("%s: Expected the hooks to be called once for %v. Got %d and %d", name, n, c, r.after[n])
+			}
+		}
+		if len(r.zIn) != 2 || r.zIn[0] == nil || r.zIn[1] == nil {
+			t.Errorf("%s: Expected the values of the inputs of %v. Got %v", name, z, r.zIn)
+		}
+		if !ValueClose(r.zOut, tensor.New(tensor.WithBacking([]float64{-3, -3, 9}))) {
+			t.Errorf("%s: Expected the value of %v. Got %v", name, z, r.zOut)
+		}
+		if r.dead != 2 {
+			t.Errorf("%s: Expected 2 dead ReLUs. Got %d", name, r.dead)
+		}
+	}
+
+	for _, opts := range [][]VMOpt{nil, {WithParallelExecution(2)}} {
+		g, x, y, z, cost := build()
+		if _, err := Grad(cost, x, y); err != nil {
+			t.Fatal(err)
+		}
+		r := new(record)
+		m := NewTapeMachine(g, append(opts, WithHooks(hooks(r, z)))...)
+		if err := m.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+		check("tapeMachine", r, z)
+		if len(r.before) <= 3 {
+			t.Errorf("tapeMachine: Expected the hooks to be called for the gradients too. Got %d nodes", len(r.before))
+		}
+		if len(r.bwdBefore) != 0 {
+			t.Errorf("tapeMachine: Expected the backward hooks not to be called")
+		}
+	}
+
+	g, _, _, z, cost := build()
+	r := new(record)
+	m := NewLispMachine(g, WithHooks(hooks(r, z)))
+	if err := m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	check("lispMachine", r, z)
+	if len(r.bwdBefore) == 0 || r.bwdBefore[cost] != 1 || r.bwdAfter[z] != 1 {
+		t.Errorf("lispMachine: Expected the backward hooks to be called once for every node. Got %v and %v", r.bwdBefore, r.bwdAfter)
+	}
+	if r.inputGrads == 0 || r.nilGrads != 0 {
+		t.Errorf("lispMachine: Expected the gradients of the inputs. Got %d of %d nil", r.nilGrads, r.inputGrads)
+	}
+}
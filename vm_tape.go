@@ -2,6 +2,7 @@ package gorgonia
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"runtime"
@@ -25,6 +26,35 @@ type tapeMachine struct {
 	// state stuff, to allow continuation
 	pc int
 
+	// the states of the executions of the control flow ops, kept by each machine so that replicas may run concurrently
+	ctrlStates map[*ctrlFlowDef]*ctrlFlowState
+
+	// gradient accumulation
+	gradAccum int // number of runs to accumulate gradients over
+	runs      int // number of runs since the machine was created
+
+	// symbolic dimensions
+	dynInputs Nodes       // input nodes with symbolic dimensions in their shapes
+	dims      map[int]int // sizes of the symbolic dimensions, resolved at the start of every RunAll
+
+	// parallel execution
+	workers int       // number of goroutines that execute the instructions. 0 or 1 means sequential execution
+	sched   *schedule // built on the first parallel run
+
+	prof *Profile // nil if the machine is not profiled
+
+	breakpoints []Breakpoint
+	hooks       *ExecHooks
+
+	// replicas share the program and the nodes with the machine they were created from
+	replica bool
+	feeds   map[*Node]Value // the values of the input nodes fed to a replica by Run
+
+	// memory planning
+	planMem bool
+	memPlan *memPlan
+	arena   []float64 // the values in the plan are backed by the arena
+
 	// operational stuff
 	bindNodesDV Nodes // nodes that require binding of DV
 	watchNodes  Nodes
@@ -68,6 +98,17 @@ func NewTapeMachine(g *ExprGraph, opts ...VMOpt) *tapeMachine {
 		m.cpumem = make([]Value, prog.cpulocs)
 		m.gpumem = make([]Value, prog.gpulocs)
 	}
+	m.ctrlStates = newCtrlFlowStates(m.p)
+	if m.planMem {
+		if err := m.planMemory(); err != nil {
+			panic(err)
+		}
+	}
+	for n := range m.locMap {
+		if n.isInput() && hasDynDims(n.shape) {
+			m.dynInputs = append(m.dynInputs, n)
+		}
+	}
 	m.init()
 
 	return m
@@ -114,6 +155,9 @@ func (m *tapeMachine) Reset() {
 // Prog returns the compiled program. This would mainly be used in debugging functions
 func (m *tapeMachine) Prog() *program { return m.p }
 
+// Profile returns the costs of the instructions executed so far, if the machine was created with WithProfiling. It returns nil otherwise.
+func (m *tapeMachine) Profile() *Profile { return m.prof }
+
 // LocMap returns the location where the Node's execution results are stored. This would mainly be used in debugging functions.
 func (m *tapeMachine) LocMap() map[*Node]register { return m.locMap }
 
@@ -151,8 +195,8 @@ func (m *tapeMachine) Set(a, b *Node) (err error) {
 	return a.bind(v)
 }
 
-// Run runs a fragment (a subset of a program).
-func (m *tapeMachine) Run(frag fragment) (err error) {
+// RunFragment runs a fragment (a subset of a program).
+func (m *tapeMachine) RunFragment(frag fragment) (err error) {
 	defer func() {
 		if err == nil {
 			m.dontAlloc()
@@ -185,16 +229,154 @@ func (m *tapeMachine) Run(frag fragment) (err error) {
 	return
 }
 
-func (m *tapeMachine) RunAll() (err error) {
+// Run binds the feeds to their input nodes, executes only the instructions of the program that are needed to compute the fetches,
+// and returns copies of the values of the fetches. The values returned are owned by the caller: they are not reused by later runs.
+//
+// The feeds must have the dtypes and shapes of their nodes. The input nodes that are not fed keep their values. The feeds of a replica
+// (see Replica) are not bound to the nodes: they are only used by the run.
+func (m *tapeMachine) Run(feeds map[*Node]Value, fetches ...*Node) (retVal []Value, err error) {
+	if m.p.gpulocs > 0 {
+		return nil, errors.Errorf(nyiFail, "Run", "programs that use devices other than the CPU")
+	}
+
+	for n, v := range feeds {
+		if !m.p.g.Has(n) {
+			return nil, errors.Errorf("Node %v does not exist in this graph", n)
+		}
+		if !n.isInput() {
+			return nil, errors.Errorf("Cannot feed %v: it is not an input node", n)
+		}
+		dt, err := dtypeOf(n.t)
+		if err != nil {
+			return nil, errors.Wrap(err, dtypeOfFail)
+		}
+		if v.Dtype() != dt {
+			return nil, errors.Errorf("Cannot feed %v: expected a value of %v. Got %v instead", n, dt, v.Dtype())
+		}
+		if err = unifyDims(n.shape, v.Shape(), make(map[int]int)); err != nil {
+			return nil, errors.Wrapf(err, "Cannot feed %v", n)
+		}
+	}
+	if m.replica {
+		m.feeds = feeds
+		defer func() { m.feeds = nil }()
+	} else {
+		for n, v := range feeds {
+			if err = UnsafeLet(n, v); err != nil {
+				return nil, errors.Wrapf(err, "Cannot feed %v", n)
+			}
+		}
+	}
+
+	// find the instructions that compute the fetches
+	fetchAt := make(map[int][]int) // instruction → positions in fetches
+	retVal = make([]Value, len(fetches))
+	for i, n := range fetches {
+		reg, ok := m.locMap[n]
+		if !ok {
+			if v := n.Value(); v != nil && n.isConstant() {
+				if retVal[i], err = CloneValue(v); err != nil {
+					return nil, errors.Wrap(err, cloneFail)
+				}
+				continue
+			}
+			return nil, errors.Errorf("Node %v is not in the program", n)
+		}
+
+		id := n.ID()
+		if m.p.df != nil {
+			if r, ok := m.p.df.replacements[n]; ok {
+				id = r.ID()
+			}
+		}
+		at := -1
+		for pc, instr := range m.p.instructions {
+			if instr.ID() == id && instr.writes() == reg {
+				at = pc
+			}
+		}
+		if at < 0 {
+			return nil, errors.Errorf("Unable to find the instruction that computes %v", n)
+		}
+		fetchAt[at] = append(fetchAt[at], i)
+	}
+
+	// walk the program backwards from the fetches, collecting the instructions that write the registers that are read
+	needed := make([]bool, len(m.p.instructions))
+	want := make(map[register]bool)
+	for pc := len(m.p.instructions) - 1; pc >= 0; pc-- {
+		instr := m.p.instructions[pc]
+		w := instr.writes()
+		_, fetched := fetchAt[pc]
+		switch instr.(type) {
+		case flushInstr:
+			needed[pc] = true
+			continue
+		case free, *readInstr:
+			continue
+		}
+		if !fetched && (w.id < 0 || !want[w]) {
+			continue
+		}
+
+		needed[pc] = true
+		delete(want, w)
+		for _, r := range instr.reads() {
+			want[r] = true
+		}
+		if op, ok := instr.(*execOp); ok && op.preAllocated && op.arena == nil {
+			want[w] = true // the preallocated value is written by an alloc
+		}
+	}
+
+	if err = m.resolveDims(); err != nil {
+		return nil, err
+	}
+	for pc, instr := range m.p.instructions {
+		if !needed[pc] {
+			continue
+		}
+		if err = m.exec(pc, instr); err != nil {
+			return nil, m.execError(pc, instr, err)
+		}
+		if err = m.watchValue(instr); err != nil {
+			return nil, err
+		}
+
+		// the registers may be reused by the instructions that follow, so the values are copied now
+		for _, i := range fetchAt[pc] {
+			if retVal[i], err = CloneValue(m.getValue(instr.writes())); err != nil {
+				return nil, errors.Wrap(err, cloneFail)
+			}
+		}
+	}
+	return retVal, nil
+}
+
+// RunAll executes the program.
+func (m *tapeMachine) RunAll() (err error) { return m.RunAllContext(context.Background()) }
+
+// RunAllContext executes the program, checking ctx before every instruction. If ctx is done, the execution stops, and a *CanceledError
+// with the node of the instruction that would have been executed next is returned. Call Reset() before running the machine again.
+//
+// If a breakpoint stops the execution, a *BreakpointError is returned, and calling RunAll again continues the run.
+func (m *tapeMachine) RunAllContext(ctx context.Context) (err error) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
+	if m.pc == 0 {
+		m.accumulateGrads()
+		if err = m.resolveDims(); err != nil {
+			return
+		}
+	}
+
 	workAvailable := m.ExternMetadata.WorkAvailable()
 	syncChan := m.ExternMetadata.Sync()
 	errChan := make(chan error)
 	doneChan := make(chan struct{})
 
-	go m.runall(errChan, doneChan)
+	go m.runall(ctx, errChan, doneChan)
 	for {
 		select {
 		case sychronous := <-workAvailable:
@@ -206,6 +388,10 @@ func (m *tapeMachine) RunAll() (err error) {
 				syncChan <- struct{}{}
 			}
 		case err := <-errChan:
+			switch err.(type) {
+			case *CanceledError, *BreakpointError, *ExecError:
+				return err
+			}
 			return errors.Wrapf(err, "PC: %d", m.pc)
 		case <-doneChan:
 			err := m.ExternMetadata.DoWork()
@@ -219,58 +405,177 @@ func (m *tapeMachine) RunAll() (err error) {
 	return
 }
 
-func (m *tapeMachine) runall(errChan chan error, doneChan chan struct{}) {
+func (m *tapeMachine) runall(ctx context.Context, errChan chan error, doneChan chan struct{}) {
+	if m.canRunParallel() {
+		m.runParallel(ctx, errChan, doneChan)
+		return
+	}
+
 	for ; m.pc < len(m.p.instructions); m.pc++ {
+		if err := ctx.Err(); err != nil {
+			errChan <- m.canceled(m.pc, err)
+			return
+		}
+
 		instr := m.p.instructions[m.pc]
 		m.logf("PC %d", m.pc)
-		if err := instr.exec(m); err != nil {
-			err = errors.Wrapf(err, "PC %d. Failed to execute instruction %v", m.pc, instr)
+		if err := m.exec(m.pc, instr); err != nil {
+			errChan <- m.execError(m.pc, instr, err)
+			return
+		}
+
+		if err := m.watchValue(instr); err != nil {
 			errChan <- err
 			return
 		}
 
-		if m.watchNaN() {
-			writeTo := instr.writes().id
-			id := instr.ID()
-			if writeTo > 0 && id > 0 {
-				v := m.getValue(instr.writes())
-				if v == nil {
-					err := errors.Errorf(nyiFail, "converting Memory to Value", "watchNaN")
-					errChan <- err
-					return
-				}
+		if brk := m.checkBreakpoints(m.pc, instr); brk != nil {
+			m.pc++
+			errChan <- brk
+			return
+		}
+	}
 
-				if hasNaN(v) {
-					n := m.p.g.Node(id).(*Node)
-					err := errors.Errorf("NaN found in value. Node: %v(%x)", n, n.ID())
-					errChan <- err
-					return
-				}
+	doneChan <- struct{}{}
+}
+
+// exec executes the instruction at pc, calling the hooks if the instruction executes an op, and recording its cost if the machine
+// is profiled
+func (m *tapeMachine) exec(pc int, instr tapeInstr) (err error) {
+	op, hooked := instr.(*execOp)
+	hooked = hooked && m.hooks != nil
+	var n *Node
+	if hooked {
+		n = m.p.g.Node(op.id).(*Node)
+		if m.hooks.BeforeOp != nil {
+			inputs := make([]Value, len(op.readFrom))
+			for i, r := range op.readFrom {
+				inputs[i] = m.getValue(r)
 			}
+			m.hooks.BeforeOp(n, inputs)
 		}
+	}
 
-		if m.watchInf() {
-			writeTo := instr.writes().id
-			id := instr.ID()
-			if writeTo > 0 && id > 0 {
-				v := m.getValue(instr.writes())
-				if v == nil {
-					err := errors.Errorf(nyiFail, "converting Memory to Value", "watchInf")
-					errChan <- err
-					return
-				}
+	if m.prof != nil {
+		err = m.prof.exec(m, pc, instr)
+	} else {
+		err = instr.exec(m)
+	}
+
+	if err == nil && hooked && m.hooks.AfterOp != nil {
+		m.hooks.AfterOp(n, m.getValue(op.writeTo))
+	}
+	return err
+}
 
-				if hasInf(v) {
-					n := m.p.g.Node(id).(*Node)
-					err := errors.Errorf("Inf found in value. Node: %v(%x)", n, n.ID())
-					errChan <- err
-					return
+// canceled creates the error for a run that was stopped before the ith instruction
+func (m *tapeMachine) canceled(i int, err error) error {
+	var n *Node
+	if id := m.p.instructions[i].ID(); id >= 0 {
+		n, _ = m.p.g.Node(id).(*Node)
+	}
+	return &CanceledError{
+		Node:  n,
+		Instr: i,
+		Err:   err,
+	}
+}
+
+// execError creates the error for a failure to execute the ith instruction. The failures of the instructions that execute ops are
+// *ExecErrors.
+func (m *tapeMachine) execError(i int, instr tapeInstr, err error) error {
+	op, ok := instr.(*execOp)
+	if !ok {
+		return errors.Wrapf(err, "PC %d. Failed to execute instruction %v", i, instr)
+	}
+	inputs := make([]Value, len(op.readFrom))
+	for j, r := range op.readFrom {
+		inputs[j] = m.getValue(r)
+	}
+	var children Nodes
+	n, _ := m.p.g.Node(op.id).(*Node)
+	if n != nil {
+		children = n.children
+	}
+	return newExecError(n, op.op, children, inputs, i, false, errors.Wrapf(err, "Failed to execute instruction %v", instr))
+}
+
+// watchValue checks the value written by the instruction for NaNs and Infs, if the machine is watching for them
+func (m *tapeMachine) watchValue(instr tapeInstr) error {
+	if m.watchNaN() {
+		writeTo := instr.writes().id
+		id := instr.ID()
+		if writeTo > 0 && id > 0 {
+			v := m.getValue(instr.writes())
+			if v == nil {
+				return errors.Errorf(nyiFail, "converting Memory to Value", "watchNaN")
+			}
+
+			if hasNaN(v) {
+				n := m.p.g.Node(id).(*Node)
+				return errors.Errorf("NaN found in value. Node: %v(%x)", n, n.ID())
+			}
+		}
+	}
+
+	if m.watchInf() {
+		writeTo := instr.writes().id
+		id := instr.ID()
+		if writeTo > 0 && id > 0 {
+			v := m.getValue(instr.writes())
+			if v == nil {
+				return errors.Errorf(nyiFail, "converting Memory to Value", "watchInf")
+			}
+
+			if hasInf(v) {
+				n := m.p.g.Node(id).(*Node)
+				return errors.Errorf("Inf found in value. Node: %v(%x)", n, n.ID())
+			}
+		}
+	}
+	return nil
+}
+
+// accumulateGrads is called at the start of every RunAll(). When a new window of gradient accumulation begins, the derivatives of the input nodes are cleared.
+func (m *tapeMachine) accumulateGrads() {
+	if m.gradAccum == 0 {
+		return
+	}
+
+	if m.runs > 0 && m.runs%m.gradAccum == 0 {
+		nodes := m.bindNodesDV
+		if len(nodes) == 0 {
+			for n := range m.locMap {
+				if n.isInput() {
+					nodes = append(nodes, n)
 				}
 			}
 		}
+		zeroGrads(nodes)
+	}
+	m.runs++
+}
+
+// resolveDims resolves the sizes of the symbolic dimensions from the values bound to the input nodes.
+func (m *tapeMachine) resolveDims() (err error) {
+	if len(m.dynInputs) == 0 {
+		return nil
 	}
 
-	doneChan <- struct{}{}
+	m.dims = make(map[int]int)
+	for _, n := range m.dynInputs {
+		v, ok := m.feeds[n]
+		if !ok {
+			v = n.Value()
+		}
+		if v == nil {
+			return errors.Errorf("Unable to resolve the symbolic dimensions of %v: no value bound", n)
+		}
+		if err = unifyDims(n.shape, v.Shape(), m.dims); err != nil {
+			return errors.Wrapf(err, "Unable to resolve the symbolic dimensions of %v", n)
+		}
+	}
+	return nil
 }
 
 func (m *tapeMachine) getValue(r register) Value {
@@ -292,6 +597,10 @@ func (m *tapeMachine) writeValue(r register, v Value) {
 }
 
 func (m *tapeMachine) watchedLogf(format string, attrs ...interface{}) {
+	if m.logger == nil && !machineDev {
+		return
+	}
+
 	instr := m.p.instructions[m.pc]
 	reads := instr.reads()
 	writes := instr.writes()
@@ -351,6 +660,9 @@ func (m *tapeMachine) enterLoggingContext() {
 	if DEBUG && machineDev {
 		enterLoggingContext()
 	}
+	if m.logger == nil {
+		return // the instructions may be executing in parallel
+	}
 	m.tabcount++
 	if m.logger != nil {
 		reps := strings.Repeat("\t", m.tabcount)
@@ -365,6 +677,9 @@ func (m *tapeMachine) leaveLoggingContext() {
 	if DEBUG && machineDev {
 		leaveLoggingContext()
 	}
+	if m.logger == nil {
+		return
+	}
 	m.tabcount--
 	if m.tabcount < 0 {
 		m.tabcount = 0
@@ -458,6 +773,8 @@ type alloc struct {
 
 	readFrom []register
 	writeTo  register
+
+	arena Value // the value in the arena of the machine, if the memory is planned
 }
 
 func newAlloc(n *Node, writeTo register) alloc {
@@ -476,23 +793,34 @@ func (instr alloc) writes() register  { return instr.writeTo }
 func (instr alloc) exec(m *tapeMachine) (err error) {
 	m.logf("Executing %v", instr)
 
+	if instr.arena != nil {
+		m.writeValue(instr.writeTo, ZeroValue(instr.arena))
+		return nil
+	}
+
 	var dt tensor.Dtype
 	if dt, err = dtypeOf(instr.t); err != nil {
 		return errors.Wrapf(err, dtypeExtractionFail, instr.t)
 	}
 
+	// symbolic dimensions get their sizes from the current run, so the register is reallocated with the right size
+	var s tensor.Shape
+	if s, err = resolveShape(instr.s, m.dims); err != nil {
+		return
+	}
+
 	dev := instr.writeTo.device
 	var v Value
 	switch dev {
 	case CPU:
-		v, err = makeValue(instr.t, instr.s)
+		v, err = makeValue(instr.t, s)
 	default:
 		var mem Memory
-		memsize := calcMemSize(dt, instr.s)
+		memsize := calcMemSize(dt, s)
 		if mem, err = m.ExternMetadata.Get(dev, memsize); err != nil {
 			return errors.Wrapf(err, "Unable to allocate %v bytes from %v", memsize, dev)
 		}
-		v, err = makeValueFromMem(instr.t, instr.s, mem)
+		v, err = makeValueFromMem(instr.t, s, mem)
 	}
 	if err != nil {
 		return
@@ -545,6 +873,10 @@ func (instr loadArg) exec(m *tapeMachine) error {
 	defer m.leaveLoggingContext()
 
 	node := m.p.g.Node(instr.index).(*Node)
+	if v, ok := m.feeds[node]; ok {
+		m.writeValue(instr.writeTo, v)
+		return nil
+	}
 
 	if node.boundTo == nil {
 		return errors.Errorf("No value bound to node %v (%x)", node, node.ID())
@@ -579,6 +911,8 @@ type execOp struct {
 	preAllocated bool
 	useUnsafe    bool
 	useGPU       bool
+
+	arena Value // the preallocated value in the arena of the machine, if the memory is planned
 }
 
 func (instr *execOp) ID() int           { return instr.id }
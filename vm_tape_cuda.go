@@ -161,6 +161,10 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 	toDev := instr.writeTo.device
 	var v Value
 	switch op := instr.op.(type) {
+	case ctrlFlowExecer:
+		if v, err = op.doState(m.ctrlStates[op.definition()], inputs...); err != nil {
+			return errors.Wrap(err, opDoFail)
+		}
 	case CUDADoer:
 		prealloc := m.getValue(instr.writeTo)
 		if v, err = op.CUDADo(m, toDev, prealloc, inputs...); err != nil {
@@ -172,6 +176,9 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 		case instr.preAllocated:
 			if pd, ok := instr.op.(UsePreallocDoer); ok {
 				p := m.cpumem[instr.writeTo.id]
+				if instr.arena != nil {
+					p = instr.arena
+				}
 				if v, err = pd.UsePreallocDo(p, inputs...); err != nil {
 					return errors.Wrapf(err, "Happened while attempting to execute %v. Node is %x. Register was: %v ", instr, instr.id, instr.writeTo.id)
 				}
@@ -207,6 +214,11 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 
 	// Write
 	m.writeValue(instr.writeTo, v)
+
+	// the nodes of a replica are shared with other machines, so the values stay in the registers
+	if m.replica {
+		return nil
+	}
 	node := m.p.g.Node(instr.id).(*Node)
 
 	if m.trace() && (len(m.watchNodes) == 0 || m.watchNodes.Contains(node)) {
@@ -227,6 +239,7 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 
 			if src.boundTo != nil {
 				dv := dvUnit(src.boundTo)
+				densifyGrad(dv) // see SetRowSparseGrad
 				cudaLogf("dv.d 0x%x v 0x%x | writeTo: %v", dv.d.Uintptr(), v.Uintptr(), instr.writeTo)
 				dev := instr.writeTo.device
 				add := newEBOByType(addOpType, TypeOf(dv.d), TypeOf(v))
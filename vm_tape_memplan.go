@@ -0,0 +1,283 @@
+package gorgonia
+
+import (
+	"unsafe"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/chewxy/hm"
+	"github.com/pkg/errors"
+)
+
+// planAlignment is the alignment of the values in the arena
+const planAlignment = 64
+
+// MemoryPlan describes how the values computed by a program are laid out in the arena of a *tapeMachine created with WithMemoryPlanning.
+type MemoryPlan struct {
+	ArenaSize int64 // the size of the arena in bytes - the peak memory used by the planned values
+	Total     int64 // the total size of the planned values, if no memory were reused
+	Planned   int   // the number of instructions that write their results into the arena
+	Unplanned int   // the number of instructions that allocate the values of their results
+}
+
+// memPlan is the offset of each value in the arena, keyed by the instruction that writes it
+type memPlan struct {
+	MemoryPlan
+	offsets map[int]int64
+}
+
+// newMemPlan plans the memory of the values written by the instructions of a program.
+//
+// A value is live from the instruction that writes it to the last instruction that reads it, or reads a value that may share its memory.
+// The values of the roots of the graph and of the gradients are live until the end of the program, so that they may be read after a run. The offsets of the live values are allocated from a bfc, in program order. If reuse is false, no two values share memory.
+func newMemPlan(p *program, reuse bool) (*memPlan, error) {
+	plan := &memPlan{offsets: make(map[int]int64)}
+	sizes := make(map[int]int64)
+	lastUse := make(map[int]int)
+	aliases := make(map[register][]int) // the planned values that the value in a register may share memory with
+	pinned := make(map[int]struct{})    // the planned values that are live until the end of the program
+
+	use := func(pc int, regs ...register) (retVal []int) {
+		for _, r := range regs {
+			for _, v := range aliases[r] {
+				lastUse[v] = pc
+				retVal = append(retVal, v)
+			}
+		}
+		return
+	}
+
+	for pc, instr := range p.instructions {
+		reads := use(pc, instr.reads()...)
+		w := instr.writes()
+		if w.id < 0 {
+			continue
+		}
+
+		size, planned := plannable(p, instr)
+		switch it := instr.(type) {
+		case *execOp:
+			if planned {
+				break
+			}
+			// the result of an op may be one of its inputs, or a view of one of them
+			if overwritesFirstInput(p, it) {
+				reads = aliases[it.readFrom[0]]
+			}
+			var prealloc []int
+			if it.preAllocated {
+				prealloc = use(pc, w)
+			}
+			if len(prealloc) == 0 {
+				plan.Unplanned++
+			}
+			reads = append(reads, prealloc...)
+		case letInstr:
+		default:
+			reads = nil
+		}
+
+		if planned {
+			plan.Planned++
+			plan.Total += size
+			sizes[pc] = size
+			lastUse[pc] = pc
+			reads = []int{pc}
+		}
+		aliases[w] = reads
+
+		// the values of the roots and of the gradients are read after the run
+		if id := instr.ID(); id >= 0 {
+			if n, ok := p.g.Node(id).(*Node); ok && (n.isRoot() || len(n.derivOf) > 0) {
+				for _, v := range reads {
+					pinned[v] = struct{}{}
+				}
+			}
+		}
+	}
+	for v := range pinned {
+		lastUse[v] = len(p.instructions)
+	}
+
+	b := newBFC(planAlignment)
+	b.reserve(0, plan.Total+planAlignment)
+	var live []int
+	for pc := range p.instructions {
+		size, ok := sizes[pc]
+		if !ok {
+			continue
+		}
+		if reuse {
+			var stillLive []int
+			for _, v := range live {
+				if lastUse[v] < pc {
+					b.free(uintptr(plan.offsets[v]))
+					continue
+				}
+				stillLive = append(stillLive, v)
+			}
+			live = stillLive
+		}
+
+		addr, err := b.alloc(size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to plan the memory of instruction %d", pc)
+		}
+		plan.offsets[pc] = int64(addr)
+		live = append(live, pc)
+		if end := int64(addr) + size; end > plan.ArenaSize {
+			plan.ArenaSize = end
+		}
+	}
+	return plan, nil
+}
+
+// plannable returns the aligned size of the value written by the instruction, and whether the value may be placed in the arena.
+// The values of preallocated instructions and the results of ops that fully overwrite a preallocated value are plannable, if their shapes
+// are known at compile time.
+func plannable(p *program, instr tapeInstr) (int64, bool) {
+	var t hm.Type
+	var s tensor.Shape
+	switch it := instr.(type) {
+	case alloc:
+		if it.writeTo.device != CPU {
+			return 0, false
+		}
+		t, s = it.t, it.s
+	case *execOp:
+		if it.preAllocated || it.useUnsafe || it.useGPU || it.writeTo.device != CPU || !overwritesPrealloc(it.op) {
+			return 0, false
+		}
+		n, ok := p.g.Node(it.id).(*Node)
+		if !ok {
+			return 0, false
+		}
+		t, s = n.t, n.shape
+	default:
+		return 0, false
+	}
+
+	if _, ok := t.(TensorType); !ok || s.IsScalar() || hasDynDims(s) {
+		return 0, false
+	}
+	dt, err := dtypeOf(t)
+	if err != nil || (dt != Float64 && dt != Float32) {
+		return 0, false
+	}
+	size := calcMemSize(dt, s)
+	if rem := size % planAlignment; rem != 0 {
+		size += planAlignment - rem
+	}
+	return size, true
+}
+
+// overwritesPrealloc returns true if the UsePreallocDo method of the op overwrites the preallocated value with its result
+func overwritesPrealloc(op Op) bool {
+	switch o := op.(type) {
+	case elemBinOp:
+		tb, ok := o.ʘBinaryOperator.(tBinOp)
+		return ok && tb.isArith()
+	case linAlgBinOp:
+		return o.āBinaryOperator == matMulOperator || o.āBinaryOperator == matVecMulOperator
+	}
+	return false
+}
+
+// overwritesFirstInput returns true if the instruction writes its result into the value of its first input, and nowhere else. An arithmetic op
+// on two tensors that is executed unsafely does.
+func overwritesFirstInput(p *program, instr *execOp) bool {
+	if !instr.useUnsafe || len(instr.readFrom) != 2 {
+		return false
+	}
+	o, ok := instr.op.(elemBinOp)
+	if !ok {
+		return false
+	}
+	if tb, ok := o.ʘBinaryOperator.(tBinOp); !ok || !tb.isArith() {
+		return false
+	}
+	n, ok := p.g.Node(instr.id).(*Node)
+	if !ok || len(n.children) != 2 {
+		return false
+	}
+	return !n.children[0].IsScalar() && !n.children[1].IsScalar()
+}
+
+// planMemory lays the values computed by the program out in a single arena, and replaces the program of the machine with a copy whose
+// instructions write into the arena.
+func (m *tapeMachine) planMemory() error {
+	if m.p.gpulocs > 0 {
+		return errors.Errorf(nyiFail, "WithMemoryPlanning", "programs that use devices other than the CPU")
+	}
+	plan, err := newMemPlan(m.p, m.workers <= 1)
+	if err != nil {
+		return err
+	}
+	return m.useMemPlan(plan)
+}
+
+// useMemPlan allocates an arena for the plan, and replaces the program of the machine with a copy whose instructions write into it.
+// The program may already use another arena, as the programs of replicas do.
+func (m *tapeMachine) useMemPlan(plan *memPlan) (err error) {
+	// the arena is a []float64 so that it is aligned for every dtype
+	arena := make([]float64, plan.ArenaSize/8)
+
+	instrs := make(fragment, len(m.p.instructions))
+	copy(instrs, m.p.instructions)
+	for pc, off := range plan.offsets {
+		switch it := instrs[pc].(type) {
+		case alloc:
+			if it.arena, err = arenaValue(arena, off, it.t, it.s); err != nil {
+				return err
+			}
+			instrs[pc] = it
+		case *execOp:
+			n := m.p.g.Node(it.id).(*Node)
+			op := *it
+			if op.arena, err = arenaValue(arena, off, n.t, n.shape); err != nil {
+				return err
+			}
+			op.preAllocated = true
+			instrs[pc] = &op
+		}
+	}
+
+	p := *m.p
+	p.instructions = instrs
+	m.p = &p
+	m.memPlan = plan
+	m.arena = arena
+	return nil
+}
+
+// arenaValue creates a value of the given type and shape backed by the arena, starting at the offset (in bytes)
+func arenaValue(arena []float64, offset int64, t hm.Type, s tensor.Shape) (Value, error) {
+	dt, err := dtypeOf(t)
+	if err != nil {
+		return nil, errors.Wrapf(err, dtypeExtractionFail, t)
+	}
+	size := s.TotalSize()
+	start := int(offset / 8)
+	var backing interface{}
+	switch dt {
+	case Float64:
+		backing = arena[start : start+size : start+size]
+	case Float32:
+		backing = (*[1 << 30]float32)(unsafe.Pointer(&arena[start]))[:size:size]
+	default:
+		return nil, errors.Errorf(nyiFail, "arenaValue", dt)
+	}
+	return tensor.New(tensor.WithShape(s.Clone()...), tensor.WithBacking(backing)), nil
+}
+
+// MemoryPlan returns the memory plan of the machine's program. If the machine was not created with WithMemoryPlanning, the plan is computed
+// but not used, so that the peak memory of a program may be known before it is run.
+func (m *tapeMachine) MemoryPlan() (MemoryPlan, error) {
+	if m.memPlan != nil {
+		return m.memPlan.MemoryPlan, nil
+	}
+	plan, err := newMemPlan(m.p, m.workers <= 1)
+	if err != nil {
+		return MemoryPlan{}, err
+	}
+	return plan.MemoryPlan, nil
+}
@@ -0,0 +1,97 @@
+package gorgonia
+
+import (
+	"testing"
+)
+
+// chainGraph is a chain of elementwise operations, where every intermediate value is dead once the next one is computed
+func chainGraph() (g *ExprGraph, x, cost *Node) {
+	g = NewGraph()
+	x = NewMatrix(g, Float64, WithShape(16, 16), WithName("x"), WithInit(RangedFrom(0)))
+	y := NewMatrix(g, Float64, WithShape(16, 16), WithName("y"), WithInit(RangedFrom(1)))
+	h := Must(Add(x, y))
+	for i := 0; i < 4; i++ {
+		// h is read twice, so neither op may overwrite it
+		h = Must(HadamardProd(Must(Sub(h, x)), Must(Add(h, y))))
+	}
+	cost = Must(Sum(h))
+	return
+}
+
+func TestWithMemoryPlanning(t *testing.T) {
+	g, _, cost := chainGraph()
+	m := NewTapeMachine(g, WithMemoryPlanning())
+	plan, err := m.MemoryPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Planned == 0 {
+		t.Fatalf("Expected some values to be planned. Got %+v", plan)
+	}
+	if plan.ArenaSize >= plan.Total {
+		t.Errorf("Expected the values to share memory. Got %+v", plan)
+	}
+	if len(m.arena)*8 != int(plan.ArenaSize) {
+		t.Errorf("Expected an arena of %d bytes. Got %d instead", plan.ArenaSize, len(m.arena)*8)
+	}
+
+	g2, _, cost2 := chainGraph()
+	m2 := NewTapeMachine(g2)
+	for i := 0; i < 2; i++ {
+		m.Reset()
+		m2.Reset()
+		if err = m.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+		if err = m2.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+		if !ValueEq(cost.Value(), cost2.Value()) {
+			t.Errorf("Run %d: Expected the cost to be %v. Got %v instead", i, cost2.Value(), cost.Value())
+		}
+	}
+
+	planned := testing.AllocsPerRun(10, func() { m.Reset(); m.RunAll() })
+	unplanned := testing.AllocsPerRun(10, func() { m2.Reset(); m2.RunAll() })
+	if planned >= unplanned {
+		t.Errorf("Expected fewer allocations with a memory plan. Got %v with the plan and %v without", planned, unplanned)
+	}
+
+	// gradients, and the parallel execution
+	for _, opts := range [][]VMOpt{nil, {WithParallelExecution(4)}} {
+		g, _, ws, cost := wideGraph(3, true)
+		m := NewTapeMachine(g, append(opts, WithMemoryPlanning(), BindDualValues(ws...))...)
+		g2, _, ws2, cost2 := wideGraph(3, true)
+		m2 := NewTapeMachine(g2, BindDualValues(ws2...))
+		for i := 0; i < 2; i++ {
+			zeroGrads(ws)
+			zeroGrads(ws2)
+			m.Reset()
+			m2.Reset()
+			if err = m.RunAll(); err != nil {
+				t.Fatal(err)
+			}
+			if err = m2.RunAll(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !ValueEq(cost.Value(), cost2.Value()) {
+			t.Errorf("Expected the cost to be %v. Got %v instead", cost2.Value(), cost.Value())
+		}
+		for i := range ws {
+			grad, _ := ws[i].Grad()
+			grad2, _ := ws2[i].Grad()
+			if !ValueEq(grad, grad2) {
+				t.Errorf("Expected the gradient of %v to be the same with a memory plan", ws[i])
+			}
+		}
+
+		plan, err := m.MemoryPlan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts != nil && plan.ArenaSize != plan.Total {
+			t.Errorf("Expected the values not to share memory when the instructions are executed in parallel. Got %+v", plan)
+		}
+	}
+}
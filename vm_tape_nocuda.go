@@ -29,10 +29,18 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 
 	// Execute
 	var v Value
+	cf, isCtrlFlow := instr.op.(ctrlFlowExecer)
 	switch {
+	case isCtrlFlow:
+		if v, err = cf.doState(m.ctrlStates[cf.definition()], inputs...); err != nil {
+			return errors.Wrap(err, opDoFail)
+		}
 	case instr.preAllocated:
 		if pd, ok := instr.op.(UsePreallocDoer); ok {
 			p := m.cpumem[instr.writeTo.id]
+			if instr.arena != nil {
+				p = instr.arena
+			}
 			if v, err = pd.UsePreallocDo(p, inputs...); err != nil {
 				return errors.Wrapf(err, "Happened while attempting to execute %v. Node is %x. Register was: %v ", instr, instr.id, instr.writeTo.id)
 			}
@@ -68,6 +76,11 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 	// Write
 	dest := instr.writeTo.id
 	m.cpumem[dest] = v
+
+	// the nodes of a replica are shared with other machines, so the values stay in the registers
+	if m.replica {
+		return nil
+	}
 	node := m.p.g.Node(instr.id).(*Node)
 
 	if m.trace() && (len(m.watchNodes) == 0 || m.watchNodes.Contains(node)) {
@@ -87,6 +100,7 @@ func (instr *execOp) exec(m *tapeMachine) (err error) {
 
 			if src.boundTo != nil {
 				dv := dvUnit(src.boundTo)
+				densifyGrad(dv) // see SetRowSparseGrad
 
 				add := newEBOByType(addOpType, TypeOf(dv.d), TypeOf(v))
 
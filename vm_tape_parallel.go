@@ -0,0 +1,251 @@
+package gorgonia
+
+import (
+	"context"
+)
+
+// schedule is the dependency DAG of the instructions of a program. It's used by the *tapeMachine to execute independent instructions in parallel.
+//
+// The program is split into segments. A segment is either a single barrier instruction, which is executed on its own after all the previous
+// instructions are done, or a set of instructions that are ordered only by the registers they read and write:
+//
+//	read after write	- an instruction that reads a register waits for the last instruction that wrote it
+//	write after read	- an instruction that writes a register waits for every instruction that read its previous value
+//	write after write	- an instruction that writes a register waits for the last instruction that wrote it
+type schedule struct {
+	segments []segment
+}
+
+type segment struct {
+	barrier bool
+	instrs  []int   // indices into the program's instructions
+	succs   [][]int // succs[i] are the positions in instrs of the instructions that wait for instrs[i]
+	npreds  []int   // number of instructions that instrs[i] waits for
+}
+
+func newSchedule(m *tapeMachine) *schedule {
+	s := new(schedule)
+	var cur *segment
+	var lastWriter map[register]int
+	var readers map[register][]int
+
+	for i, instr := range m.p.instructions {
+		if m.isBarrier(instr) {
+			s.segments = append(s.segments, segment{barrier: true, instrs: []int{i}})
+			cur = nil
+			continue
+		}
+
+		if cur == nil {
+			s.segments = append(s.segments, segment{})
+			cur = &s.segments[len(s.segments)-1]
+			lastWriter = make(map[register]int)
+			readers = make(map[register][]int)
+		}
+
+		pos := len(cur.instrs)
+		cur.instrs = append(cur.instrs, i)
+		cur.succs = append(cur.succs, nil)
+		cur.npreds = append(cur.npreds, 0)
+
+		preds := make(map[int]struct{})
+		reads := instr.reads()
+		for _, r := range reads {
+			if w, ok := lastWriter[r]; ok {
+				preds[w] = struct{}{}
+			}
+		}
+
+		writes := instrWrites(instr)
+		for _, w := range writes {
+			if lw, ok := lastWriter[w]; ok {
+				preds[lw] = struct{}{}
+			}
+			for _, r := range readers[w] {
+				preds[r] = struct{}{}
+			}
+			readers[w] = nil
+			lastWriter[w] = pos
+		}
+	reads:
+		for _, r := range reads {
+			for _, w := range writes {
+				if r == w {
+					continue reads
+				}
+			}
+			readers[r] = append(readers[r], pos)
+		}
+
+		delete(preds, pos)
+		for p := range preds {
+			cur.succs[p] = append(cur.succs[p], pos)
+			cur.npreds[pos]++
+		}
+	}
+	return s
+}
+
+// instrWrites returns the registers that an instruction changes. Freeing a register changes it, and so does transposing the
+// value in it, even if the transpose is undone before the instruction is done.
+func instrWrites(instr tapeInstr) (retVal []register) {
+	switch it := instr.(type) {
+	case free:
+		return []register{it.readsFrom}
+	case *execOp:
+		var op linAlgBinOp
+		switch o := it.op.(type) {
+		case linAlgBinOp:
+			op = o
+		case *linAlgBinOp:
+			op = *o
+		}
+		if op.transA {
+			retVal = append(retVal, it.readFrom[0])
+		}
+		if op.transB {
+			retVal = append(retVal, it.readFrom[1])
+		}
+	}
+	if w := instr.writes(); w.id >= 0 {
+		retVal = append(retVal, w)
+	}
+	return
+}
+
+// isBarrier returns true if the instruction has to be executed on its own.
+// Instructions that overwrite their inputs may overwrite values that are shared with other registers, and instructions that accumulate
+// gradients into a *dualValue (see BindDualValues) read and write the values of other nodes, so they are barriers too. So are the
+// control flow ops, which keep the state of their execution for their gradients, and run whole subprograms of their own.
+func (m *tapeMachine) isBarrier(instr tapeInstr) bool {
+	switch it := instr.(type) {
+	case *execOp:
+		switch it.op.(type) {
+		case *ctrlFlowOp, *ctrlFlowGradOp:
+			return true
+		}
+		return it.useUnsafe || m.accumulatesGrads(it)
+	case alloc, loadArg, free, letInstr:
+		return false
+	}
+	return true
+}
+
+// accumulatesGrads returns true if the instruction adds its result into the *dualValue of another node
+func (m *tapeMachine) accumulatesGrads(instr tapeInstr) bool {
+	op, ok := instr.(*execOp)
+	if !ok || !m.bindDV() {
+		return false
+	}
+	n := m.p.g.Node(op.id).(*Node)
+	return len(n.derivOf) > 0
+}
+
+// runParallel is the parallel version of runall. The instructions are dispatched to a pool of m.workers goroutines.
+func (m *tapeMachine) runParallel(ctx context.Context, errChan chan error, doneChan chan struct{}) {
+	if m.sched == nil {
+		m.sched = newSchedule(m)
+	}
+
+	type result struct {
+		pc  int
+		err error
+	}
+	jobs := make(chan int)
+	results := make(chan result)
+	defer close(jobs)
+	for w := 0; w < m.workers; w++ {
+		go func() {
+			for i := range jobs {
+				results <- result{i, m.execInstr(i)}
+			}
+		}()
+	}
+
+	fail := func(pc int, err error) {
+		m.pc = pc
+		errChan <- err
+	}
+
+	for _, seg := range m.sched.segments {
+		if seg.barrier {
+			if err := ctx.Err(); err != nil {
+				fail(seg.instrs[0], m.canceled(seg.instrs[0], err))
+				return
+			}
+			if err := m.execInstr(seg.instrs[0]); err != nil {
+				fail(seg.instrs[0], err)
+				return
+			}
+			continue
+		}
+
+		npreds := make([]int, len(seg.npreds))
+		copy(npreds, seg.npreds)
+		var ready []int
+		for pos, n := range npreds {
+			if n == 0 {
+				ready = append(ready, pos)
+			}
+		}
+
+		var inflight, done int
+		var firstErr error
+		failedAt := -1
+		for done < len(seg.instrs) {
+			for firstErr == nil && len(ready) > 0 && inflight < m.workers {
+				if err := ctx.Err(); err != nil {
+					firstErr, failedAt = m.canceled(seg.instrs[ready[0]], err), seg.instrs[ready[0]]
+					break
+				}
+				jobs <- seg.instrs[ready[0]]
+				ready = ready[1:]
+				inflight++
+			}
+			if inflight == 0 {
+				break
+			}
+
+			res := <-results
+			inflight--
+			done++
+			if res.err != nil {
+				if firstErr == nil || res.pc < failedAt {
+					firstErr, failedAt = res.err, res.pc
+				}
+				continue
+			}
+
+			pos := res.pc - seg.instrs[0] // segments are contiguous
+			for _, succ := range seg.succs[pos] {
+				if npreds[succ]--; npreds[succ] == 0 {
+					ready = append(ready, succ)
+				}
+			}
+		}
+		if firstErr != nil {
+			fail(failedAt, firstErr)
+			return
+		}
+	}
+
+	m.pc = len(m.p.instructions)
+	doneChan <- struct{}{}
+}
+
+// execInstr executes the ith instruction of the program, and checks its result for NaNs and Infs if the machine watches for them
+func (m *tapeMachine) execInstr(i int) error {
+	instr := m.p.instructions[i]
+	if err := m.exec(i, instr); err != nil {
+		return m.execError(i, instr, err)
+	}
+	return m.watchValue(instr)
+}
+
+// canRunParallel returns true if the program can be executed in parallel. Logging, devices other than the CPU, and batched BLAS calls
+// all depend on the order of the instructions, so the machine falls back to executing the instructions sequentially. So does profiling,
+// so that the allocations are attributed to the right instructions, and so do breakpoints, so that a run may be stopped and continued.
+func (m *tapeMachine) canRunParallel() bool {
+	return m.workers > 1 && m.pc == 0 && m.logger == nil && !machineDev && m.p.gpulocs == 0 && m.b == nil && m.prof == nil &&
+		len(m.breakpoints) == 0
+}
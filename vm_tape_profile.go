@@ -0,0 +1,363 @@
+package gorgonia
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileStat is the cost of executing one or more instructions.
+type ProfileStat struct {
+	Calls      int
+	Time       time.Duration // wall time
+	Allocs     uint64        // number of heap allocations
+	AllocBytes uint64        // bytes allocated on the heap
+}
+
+func (s *ProfileStat) add(o ProfileStat) {
+	s.Calls += o.Calls
+	s.Time += o.Time
+	s.Allocs += o.Allocs
+	s.AllocBytes += o.AllocBytes
+}
+
+// InstrStat is the cost of executing an instruction of the program of a *tapeMachine.
+type InstrStat struct {
+	PC    int
+	Instr string
+	Op    string // the type of the Op for instructions that execute an Op, and the kind of instruction for the others
+	Node  *Node  // nil if the instruction is not tied to a node
+	ProfileStat
+}
+
+type traceEvent struct {
+	pc    int
+	start time.Duration // since the profile was started
+	stat  ProfileStat
+}
+
+// maxTraceEvents is the number of executions a Profile keeps for WriteChromeTrace. A long training loop would otherwise grow the trace
+// without bound.
+const maxTraceEvents = 1 << 16
+
+// Profile records the cost of every instruction executed by a *tapeMachine created with WithProfiling.
+//
+// The costs of the instructions are totals, but only the last executions are kept for the trace (see WriteChromeTrace).
+//
+// Reading the allocation counts stops the world briefly, so the instructions are a little slower when they are profiled. The time taken to
+// read the counts is not included in the wall times.
+type Profile struct {
+	instrs []InstrStat
+	events []traceEvent // a ring buffer of the last maxEvents executions
+	next   int          // the index of the oldest event once events is full
+	start  time.Time
+
+	maxEvents int
+}
+
+func newProfile() *Profile { return &Profile{start: time.Now(), maxEvents: maxTraceEvents} }
+
+// init describes the instructions of the program, if it hasn't been done yet
+func (p *Profile) init(m *tapeMachine) {
+	if len(p.instrs) == len(m.p.instructions) {
+		return
+	}
+	p.instrs = make([]InstrStat, len(m.p.instructions))
+	for pc, instr := range m.p.instructions {
+		is := InstrStat{PC: pc, Instr: instr.String(), Op: instrKind(instr)}
+		if id := instr.ID(); id >= 0 {
+			is.Node, _ = m.p.g.Node(id).(*Node)
+		}
+		p.instrs[pc] = is
+	}
+}
+
+// exec executes the instruction at pc, recording its cost
+func (p *Profile) exec(m *tapeMachine, pc int, instr tapeInstr) error {
+	p.init(m)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	err := instr.exec(m)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	stat := ProfileStat{
+		Calls:      1,
+		Time:       elapsed,
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+	}
+	p.instrs[pc].add(stat)
+	p.record(traceEvent{pc: pc, start: start.Sub(p.start), stat: stat})
+	return err
+}
+
+// record adds an event to the trace, overwriting the oldest one if the trace is full
+func (p *Profile) record(e traceEvent) {
+	if len(p.events) < p.maxEvents {
+		p.events = append(p.events, e)
+		return
+	}
+	p.events[p.next] = e
+	p.next = (p.next + 1) % len(p.events)
+}
+
+// Instructions returns the cost of each instruction, in program order.
+func (p *Profile) Instructions() []InstrStat {
+	retVal := make([]InstrStat, len(p.instrs))
+	copy(retVal, p.instrs)
+	return retVal
+}
+
+// ByOp returns the cost of the instructions, grouped by the type of their Op. The instructions that do not execute an Op (allocations,
+// loading of arguments and so on) are grouped by their kind.
+func (p *Profile) ByOp() map[string]ProfileStat {
+	retVal := make(map[string]ProfileStat)
+	for _, is := range p.instrs {
+		s := retVal[is.Op]
+		s.add(is.ProfileStat)
+		retVal[is.Op] = s
+	}
+	return retVal
+}
+
+// ByNode returns the cost of the instructions, grouped by the node they are tied to.
+func (p *Profile) ByNode() map[*Node]ProfileStat {
+	retVal := make(map[*Node]ProfileStat)
+	for _, is := range p.instrs {
+		if is.Node == nil {
+			continue
+		}
+		s := retVal[is.Node]
+		s.add(is.ProfileStat)
+		retVal[is.Node] = s
+	}
+	return retVal
+}
+
+// Reset clears the recorded costs.
+func (p *Profile) Reset() {
+	for i := range p.instrs {
+		p.instrs[i].ProfileStat = ProfileStat{}
+	}
+	p.events = nil
+	p.next = 0
+	p.start = time.Now()
+}
+
+// label is the name of an instruction in the exported profiles
+func (is InstrStat) label() string {
+	if is.Node != nil {
+		return fmt.Sprintf("%d: %s", is.PC, is.Node.Name())
+	}
+	return fmt.Sprintf("%d: %s", is.PC, is.Instr)
+}
+
+// WriteChromeTrace writes the executions of the instructions in the Chrome trace event format, which may be viewed in chrome://tracing
+// or Perfetto. Only the last 65536 executions are kept, so the trace of a long run shows its end.
+func (p *Profile) WriteChromeTrace(w io.Writer) error {
+	type event struct {
+		Name string                 `json:"name"`
+		Cat  string                 `json:"cat"`
+		Ph   string                 `json:"ph"`
+		Ts   float64                `json:"ts"`  // µs
+		Dur  float64                `json:"dur"` // µs
+		Pid  int                    `json:"pid"`
+		Tid  int                    `json:"tid"`
+		Args map[string]interface{} `json:"args"`
+	}
+
+	events := make([]event, 0, len(p.events))
+	for i := range p.events {
+		e := p.events[(p.next+i)%len(p.events)]
+		is := p.instrs[e.pc]
+		events = append(events, event{
+			Name: is.label(),
+			Cat:  is.Op,
+			Ph:   "X",
+			Ts:   float64(e.start) / float64(time.Microsecond),
+			Dur:  float64(e.stat.Time) / float64(time.Microsecond),
+			Args: map[string]interface{}{
+				"instr":      is.Instr,
+				"allocs":     e.stat.Allocs,
+				"allocBytes": e.stat.AllocBytes,
+			},
+		})
+	}
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []event `json:"traceEvents"`
+		DisplayTimeUnit string  `json:"displayTimeUnit"`
+	}{events, "ns"})
+}
+
+// WritePprof writes the profile as a gzipped profile.proto, which may be read with `go tool pprof`. Every instruction is a sample, whose
+// stack is the instruction called from the type of its Op. The sample values are the calls, the wall time, and the allocations.
+func (p *Profile) WritePprof(w io.Writer) error {
+	var b protoBuf
+	strs := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		if i, ok := strs[s]; ok {
+			return i
+		}
+		strs[s] = int64(len(table))
+		table = append(table, s)
+		return strs[s]
+	}
+
+	// sample types
+	for _, st := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}, {"alloc_objects", "count"}, {"alloc_space", "bytes"}} {
+		var vt protoBuf
+		vt.int64(1, str(st[0]))
+		vt.int64(2, str(st[1]))
+		b.message(1, vt)
+	}
+
+	// functions and their locations share IDs
+	funcs := make(map[string]uint64)
+	var names []string
+	fn := func(name string) uint64 {
+		if id, ok := funcs[name]; ok {
+			return id
+		}
+		funcs[name] = uint64(len(funcs) + 1)
+		names = append(names, name)
+		return funcs[name]
+	}
+
+	for _, is := range p.instrs {
+		if is.Calls == 0 {
+			continue
+		}
+		var s protoBuf
+		s.packedUint64(1, []uint64{fn(is.label()), fn(is.Op)})
+		s.packedInt64(2, []int64{int64(is.Calls), int64(is.Time), int64(is.Allocs), int64(is.AllocBytes)})
+		b.message(2, s)
+	}
+
+	for i, name := range names {
+		id := uint64(i + 1)
+		var line, loc, f protoBuf
+		line.uint64(1, id)
+		loc.uint64(1, id)
+		loc.message(4, line)
+		b.message(4, loc)
+
+		f.uint64(1, id)
+		f.int64(2, str(name))
+		f.int64(3, str(name))
+		b.message(5, f)
+	}
+
+	timeIdx := str("time")
+	var period protoBuf
+	period.int64(1, timeIdx)
+	period.int64(2, str("nanoseconds"))
+
+	for _, s := range table {
+		b.bytes(6, []byte(s))
+	}
+	b.int64(9, p.start.UnixNano())
+	b.int64(10, int64(time.Since(p.start)))
+	b.message(11, period)
+	b.int64(12, 1)
+	b.int64(14, timeIdx)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// String returns the costs grouped by op, most expensive first.
+func (p *Profile) String() string {
+	byOp := p.ByOp()
+	ops := make([]string, 0, len(byOp))
+	for op := range byOp {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return byOp[ops[i]].Time > byOp[ops[j]].Time })
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%-20s\t%8s\t%12s\t%10s\t%12s\n", "Op", "Calls", "Time", "Allocs", "Bytes")
+	for _, op := range ops {
+		s := byOp[op]
+		fmt.Fprintf(&buf, "%-20s\t%8d\t%12v\t%10d\t%12d\n", op, s.Calls, s.Time, s.Allocs, s.AllocBytes)
+	}
+	return buf.String()
+}
+
+// instrKind is the type of the Op of an instruction that executes an Op, and the kind of instruction otherwise
+func instrKind(instr tapeInstr) string {
+	switch it := instr.(type) {
+	case *execOp:
+		return typeName(it.op)
+	case alloc:
+		return "alloc"
+	case free:
+		return "free"
+	case loadArg:
+		return "loadArg"
+	case flushInstr:
+		return "flush"
+	case letInstr:
+		return "let"
+	case *readInstr:
+		return "read"
+	case deviceTransport:
+		return "deviceTransport"
+	}
+	return fmt.Sprintf("%T", instr)
+}
+
+// protoBuf is a minimal protocol buffer encoder, enough to write a profile.proto
+type protoBuf []byte
+
+func (b *protoBuf) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *protoBuf) key(field, wireType int) { b.varint(uint64(field)<<3 | uint64(wireType)) }
+
+func (b *protoBuf) uint64(field int, x uint64) {
+	b.key(field, 0)
+	b.varint(x)
+}
+
+func (b *protoBuf) int64(field int, x int64) { b.uint64(field, uint64(x)) }
+
+func (b *protoBuf) bytes(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *protoBuf) message(field int, m protoBuf) { b.bytes(field, m) }
+
+func (b *protoBuf) packedUint64(field int, xs []uint64) {
+	var p protoBuf
+	for _, x := range xs {
+		p.varint(x)
+	}
+	b.bytes(field, p)
+}
+
+func (b *protoBuf) packedInt64(field int, xs []int64) {
+	var p protoBuf
+	for _, x := range xs {
+		p.varint(uint64(x))
+	}
+	b.bytes(field, p)
+}
@@ -0,0 +1,133 @@
+package gorgonia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestWithProfiling(t *testing.T) {
+	g, _, _, cost := wideGraph(2, true)
+	m := NewTapeMachine(g, WithProfiling(), WithParallelExecution(2))
+	const runs = 3
+	for i := 0; i < runs; i++ {
+		m.Reset()
+		if err := m.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prof := m.Profile()
+	instrs := prof.Instructions()
+	if len(instrs) != len(m.p.instructions) {
+		t.Fatalf("Expected %d instructions. Got %d instead", len(m.p.instructions), len(instrs))
+	}
+	for _, is := range instrs {
+		if is.Calls != runs {
+			t.Errorf("Expected %v to be called %d times. Got %d instead", is.Instr, runs, is.Calls)
+		}
+	}
+
+	byOp := prof.ByOp()
+	mul := byOp["linAlgBinOp"]
+	if mul.Calls < 2*runs || mul.Time <= 0 || mul.Allocs == 0 || mul.AllocBytes == 0 {
+		t.Errorf("Expected the matrix multiplications to be recorded. Got %+v", mul)
+	}
+	if s, ok := prof.ByNode()[cost]; !ok || s.Calls != runs {
+		t.Errorf("Expected the cost to be computed %d times. Got %+v", runs, s)
+	}
+
+	var trace bytes.Buffer
+	if err := prof.WriteChromeTrace(&trace); err != nil {
+		t.Fatal(err)
+	}
+	var events struct {
+		TraceEvents []struct {
+			Name string
+			Ph   string
+		}
+	}
+	if err := json.Unmarshal(trace.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events.TraceEvents) != runs*len(instrs) {
+		t.Errorf("Expected %d trace events. Got %d instead", runs*len(instrs), len(events.TraceEvents))
+	}
+
+	var pprof bytes.Buffer
+	if err := prof.WritePprof(&pprof); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&pprof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"alloc_space", "linAlgBinOp", "loadArg"} {
+		if !bytes.Contains(raw, []byte(s)) {
+			t.Errorf("Expected %q in the string table of the profile", s)
+		}
+	}
+
+	prof.Reset()
+	if s := prof.ByOp()["linAlgBinOp"]; s.Calls != 0 {
+		t.Errorf("Expected Reset to clear the profile. Got %+v", s)
+	}
+}
+
+func TestProfileTraceBound(t *testing.T) {
+	g, _, _, _ := wideGraph(2, true)
+	m := NewTapeMachine(g, WithProfiling())
+	prof := m.Profile()
+	n := len(m.p.instructions)
+	prof.maxEvents = n + 1
+
+	const runs = 4
+	for i := 0; i < runs; i++ {
+		m.Reset()
+		if err := m.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(prof.events) != prof.maxEvents {
+		t.Fatalf("Expected the trace to keep %d events. Got %d instead", prof.maxEvents, len(prof.events))
+	}
+	for _, is := range prof.Instructions() {
+		if is.Calls != runs {
+			t.Errorf("Expected %v to be called %d times. Got %d instead", is.Instr, runs, is.Calls)
+		}
+	}
+
+	var trace bytes.Buffer
+	if err := prof.WriteChromeTrace(&trace); err != nil {
+		t.Fatal(err)
+	}
+	var events struct {
+		TraceEvents []struct {
+			Name string
+			Ts   float64
+		}
+	}
+	if err := json.Unmarshal(trace.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	evs := events.TraceEvents
+	if len(evs) != n+1 {
+		t.Fatalf("Expected %d trace events. Got %d instead", n+1, len(evs))
+	}
+	// the last executions, oldest first: the last instruction of the third run, then the whole of the fourth run
+	instrs := prof.Instructions()
+	if evs[0].Name != instrs[n-1].label() {
+		t.Errorf("Expected the trace to start with %q. Got %q instead", instrs[n-1].label(), evs[0].Name)
+	}
+	for i := 1; i < len(evs); i++ {
+		if evs[i].Ts < evs[i-1].Ts {
+			t.Errorf("Expected the trace events to be in order. Event %d starts at %v, before %v", i, evs[i].Ts, evs[i-1].Ts)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gorgonia
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// Replica creates a machine that executes the same program, without compiling it again. The replica has its own registers (and arena,
+// if the machine plans its memory), but shares the nodes of the graph, and the values of the input nodes, with the machine. Replicas do
+// not bind the values they compute to the nodes, so several replicas may be run concurrently - one per goroutine - to serve concurrent
+// requests from one model:
+//
+//	r, err := m.Replica()
+//	...
+//	out, err := r.Run(map[*Node]Value{x: input}, y)
+//
+// The feeds of Run are only used by the replica that is run. The values of the input nodes that are not fed, typically the parameters
+// of the model, are read by every replica, so they must not be changed while the replicas run. Use Inspect to read the values computed
+// by RunAll.
+//
+// Replicas do not compute gradients into *dualValues, and are not profiled, logged or stopped by breakpoints. They do call the hooks of
+// the machine (see WithHooks), concurrently. Programs with devices other than the CPU, or with Read statements (which write into the
+// same Value for every machine), cannot be replicated.
+func (m *tapeMachine) Replica() (*tapeMachine, error) {
+	if m.p.gpulocs > 0 || m.b != nil {
+		return nil, errors.Errorf(nyiFail, "Replica", "programs that use devices other than the CPU")
+	}
+	for _, instr := range m.p.instructions {
+		if _, ok := instr.(*readInstr); ok {
+			return nil, errors.Errorf("Cannot replicate a program with Read statements. Fetch the values with Run instead")
+		}
+	}
+
+	r := &tapeMachine{
+		ExternMetadata: new(ExternMetadata),
+		p:              m.p,
+		locMap:         m.locMap,
+		cpumem:         make([]Value, m.p.cpulocs),
+		ctrlStates:     newCtrlFlowStates(m.p),
+		dynInputs:      m.dynInputs,
+		workers:        m.workers,
+		planMem:        m.planMem,
+		valueFmt:       m.valueFmt,
+		runFlags:       m.runFlags,
+		hooks:          m.hooks,
+		replica:        true,
+	}
+	r.dontBindDV()
+	r.dontTrace()
+	r.doAlloc()
+	if m.memPlan != nil {
+		if err := r.useMemPlan(m.memPlan); err != nil {
+			return nil, err
+		}
+	}
+
+	runtime.SetFinalizer(r, finalizeTapeMachine)
+	r.init()
+	return r, nil
+}
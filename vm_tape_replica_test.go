@@ -0,0 +1,142 @@
+package gorgonia
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestTapeMachineReplica(t *testing.T) {
+	r := rand.New(rand.NewSource(1337))
+	feeds := make([]Value, 8)
+	for i := range feeds {
+		data := make([]float64, 8*16)
+		for j := range data {
+			data[j] = r.NormFloat64()
+		}
+		feeds[i] = tensor.New(tensor.WithShape(8, 16), tensor.WithBacking(data))
+	}
+
+	// the costs and gradients computed by a machine of its own
+	g2, x2, ws2, cost2 := wideGraph(2, true)
+	m2 := NewTapeMachine(g2)
+	want := make([][]Value, len(feeds))
+	for i, feed := range feeds {
+		var err error
+		if want[i], err = m2.Run(map[*Node]Value{x2: feed}, cost2, ws2[0].deriv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	machines := []struct {
+		name string
+		opts []VMOpt
+	}{
+		{"sequential", nil},
+		{"memory planning", []VMOpt{WithMemoryPlanning()}},
+		{"parallel", []VMOpt{WithParallelExecution(2)}},
+	}
+	for _, mc := range machines {
+		g, x, ws, cost := wideGraph(2, true)
+		xv := x.Value()
+		m := NewTapeMachine(g, mc.opts...)
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			replica, err := m.Replica()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(feeds); i += 2 {
+					got, err := replica.Run(map[*Node]Value{x: feeds[i]}, cost, ws[0].deriv)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					for j := range got {
+						if !ValueClose(got[j], want[i][j]) {
+							t.Errorf("%s: feed %d: expected %v. Got %v instead", mc.name, i, want[i][j], got[j])
+						}
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		if x.Value() != xv || cost.Value() != nil {
+			t.Errorf("%s: Expected the replicas not to bind values to the nodes", mc.name)
+		}
+	}
+
+	// each replica keeps the state of the control flow ops it executes
+	scanGraph := func() (g *ExprGraph, xs, ret, grad *Node) {
+		g = NewGraph()
+		h := NewScalar(g, Float64, WithName("h"), WithValue(2.0))
+		xs = NewVector(g, Float64, WithName("xs"), WithShape(4), WithValue(tensor.New(tensor.WithShape(4), tensor.WithBacking(make([]float64, 4)))))
+		fn := func(carry, x *Node, _ Nodes) (*Node, error) { return Add(Must(HadamardProd(carry, x)), x) }
+		rets, err := Scan(fn, h, xs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret = rets[0]
+		grads, err := Grad(ret, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g, xs, ret, grads[0]
+	}
+	xsFeeds := make([]Value, 8)
+	for i := range xsFeeds {
+		xsFeeds[i] = tensor.New(tensor.WithShape(4), tensor.WithBacking([]float64{float64(i), 1, 2, float64(i + 1)}))
+	}
+	g2, xs2, ret2, grad2 := scanGraph()
+	m2 = NewTapeMachine(g2)
+	for i, feed := range xsFeeds {
+		var err error
+		if want[i], err = m2.Run(map[*Node]Value{xs2: feed}, ret2, grad2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sg, xs, ret, grad := scanGraph()
+	sm := NewTapeMachine(sg)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		replica, err := sm.Replica()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < 10; k++ {
+				i := (w + 2*k) % len(xsFeeds)
+				got, err := replica.Run(map[*Node]Value{xs: xsFeeds[i]}, ret, grad)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for j := range got {
+					if !ValueClose(got[j], want[i][j]) {
+						t.Errorf("Scan: replica %d, feed %d: expected %v. Got %v instead", w, i, want[i][j], got[j])
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Read statements write into values that would be shared by the replicas
+	g := NewGraph()
+	x := NewScalar(g, Float64, WithName("x"), WithValue(2.0))
+	var v Value
+	Read(Must(Square(x)), &v)
+	if _, err := NewTapeMachine(g).Replica(); err == nil {
+		t.Error("Expected programs with Read statements not to be replicated")
+	}
+}
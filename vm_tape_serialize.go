@@ -0,0 +1,645 @@
+package gorgonia
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"io"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/chewxy/hm"
+	"github.com/pkg/errors"
+)
+
+/*
+A saved program is laid out like so:
+
+	magic	8 bytes	"GORGPROG"
+	version	uint32, little endian
+	body	the gob encoded savedProgram
+
+The body holds the nodes of the program's graph (type, shape, op descriptor and the values of constants), the instructions and the
+register counts. Loading a program rebuilds a graph with only the nodes that the program needs, so that a *tapeMachine may run it without
+the graph building code.
+*/
+
+const (
+	programMagic   = "GORGPROG"
+	programVersion = 1
+)
+
+func init() {
+	gob.Register(savedElemBinOp{})
+	gob.Register(savedElemUnaryOp{})
+	gob.Register(savedLinAlgBinOp{})
+	gob.Register(savedConstant{})
+	gob.Register(savedSumOp{})
+	gob.Register(savedMaxOp{})
+	gob.Register(savedSizeOp{})
+	gob.Register(savedRepeatOp{})
+	gob.Register(savedReshapeOp{})
+	gob.Register(savedTransposeOp{})
+	gob.Register(savedSliceOp{})
+	gob.Register(savedLetOp{})
+}
+
+type savedProgram struct {
+	Args    int
+	CPULocs int
+	GPULocs int
+	CPUMem  int64
+
+	Nodes        []savedNode // children before parents
+	Instructions []savedInstr
+	Replacements map[int]int // node → the node that replaced it in common subexpression elimination
+}
+
+type savedNode struct {
+	Name     string
+	Type     savedType
+	Shape    []int
+	Op       savedOp // nil for inputs
+	Children []int
+	DerivOf  []int
+	Deriv    int // -1 if none
+	Loc      *savedRegister
+}
+
+// savedType is a tensor.Dtype if Dims is 0, and a TensorType otherwise
+type savedType struct {
+	Dtype string
+	Dims  int
+}
+
+type savedRegister struct {
+	ID     int
+	Device int
+}
+
+const (
+	allocInstr byte = iota
+	freeInstr
+	loadArgInstr
+	execOpInstr
+	flushInstrKind
+	letInstrKind
+)
+
+type savedInstr struct {
+	Kind  byte
+	Node  int // -1 if the instruction is not tied to a node
+	Reads []savedRegister
+	Write savedRegister
+
+	// alloc
+	Type  savedType
+	Shape []int
+
+	// execOp
+	Size         int64
+	PreAllocated bool
+	UseUnsafe    bool
+	UseGPU       bool
+}
+
+// savedOp describes an Op. children are the children of the node that the Op is rebuilt for.
+type savedOp interface {
+	op(children Nodes) (Op, error)
+}
+
+type savedElemBinOp struct {
+	Operator   byte
+	Arg0, Arg1 savedType
+	RetSame    bool
+}
+
+type savedElemUnaryOp struct {
+	Operator      byte
+	Dtype         string
+	ArgTensor     bool
+	NumericResult bool
+}
+
+type savedLinAlgBinOp struct {
+	Operator       byte
+	TransA, TransB bool
+}
+
+type savedConstant struct{ Value savedValue }
+
+type savedSumOp struct {
+	Along      []int
+	D          int
+	InputShape []int
+}
+
+type savedMaxOp struct {
+	Along []int
+	D     int
+}
+
+type savedSizeOp struct{ Axis, D, Val int }
+
+type savedRepeatOp struct {
+	Along              []int
+	InputShape         []int
+	D, Arg0Dim, NChild int
+}
+
+type savedReshapeOp struct{ From, To []int }
+
+type savedTransposeOp struct {
+	Pattern []int
+	D       int
+}
+
+type savedSliceOp struct {
+	All               bool // a nil slice, that is, ":"
+	Start, End, Step  int
+	Along, Axis, Dims int
+}
+
+type savedLetOp struct{}
+
+// SaveProgram writes a program compiled by Compile or CompileFunction to w, so that it may be loaded with LoadTapeMachine by another process.
+//
+// Only programs that run on the CPU are supported, and only the common ops (elementwise, linear algebra, reductions, and reshaping,
+// transposing, slicing and repeating of tensors) may be saved. The values of constants are saved; the values of the inputs are not,
+// and have to be bound to the nodes of the loaded graph before the program is run.
+func SaveProgram(w io.Writer, prog *program, locMap map[*Node]register) (err error) {
+	if prog.gpulocs > 0 {
+		return errors.Errorf(nyiFail, "SaveProgram", "programs that use devices other than the CPU")
+	}
+
+	sp := savedProgram{
+		Args:    prog.args,
+		CPULocs: prog.cpulocs,
+		GPULocs: prog.gpulocs,
+		CPUMem:  prog.cpumem,
+	}
+
+	index := make(map[*Node]int)
+	byID := make(map[int]int)
+	for _, n := range prog.sorted {
+		if _, ok := index[n]; ok {
+			continue
+		}
+		index[n] = len(sp.Nodes)
+		byID[n.ID()] = len(sp.Nodes)
+
+		var sn savedNode
+		if sn, err = saveNode(n, index); err != nil {
+			return errors.Wrapf(err, "Unable to save %v", n)
+		}
+		if reg, ok := locMap[n]; ok {
+			sn.Loc = &savedRegister{reg.id, int(reg.device)}
+		}
+		sp.Nodes = append(sp.Nodes, sn)
+	}
+
+	// the derivatives may come after the nodes they are derivatives of
+	for n, i := range index {
+		sn := &sp.Nodes[i]
+		sn.Deriv = -1
+		if d, ok := index[n.deriv]; ok && n.deriv != nil {
+			sn.Deriv = d
+		}
+		for _, of := range n.derivOf {
+			if j, ok := index[of]; ok {
+				sn.DerivOf = append(sn.DerivOf, j)
+			}
+		}
+	}
+
+	if prog.df != nil && len(prog.df.replacements) > 0 {
+		sp.Replacements = make(map[int]int)
+		for n, r := range prog.df.replacements {
+			i, ok1 := index[n]
+			j, ok2 := index[r]
+			if ok1 && ok2 && i != j {
+				sp.Replacements[i] = j
+			}
+		}
+	}
+
+	for pc, instr := range prog.instructions {
+		var si savedInstr
+		if si, err = saveInstr(instr, byID); err != nil {
+			return errors.Wrapf(err, "Unable to save instruction %d (%v)", pc, instr)
+		}
+		sp.Instructions = append(sp.Instructions, si)
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err = bw.WriteString(programMagic); err != nil {
+		return
+	}
+	if err = binary.Write(bw, binary.LittleEndian, uint32(programVersion)); err != nil {
+		return
+	}
+	if err = gob.NewEncoder(bw).Encode(sp); err != nil {
+		return errors.Wrap(err, "Unable to encode the program")
+	}
+	return bw.Flush()
+}
+
+// LoadTapeMachine reads a program saved by SaveProgram, and creates a *tapeMachine that runs it. The graph that is returned holds the nodes
+// of the program. Use its ByName method to find the input nodes to bind values to, and the nodes to read the results from - name the output nodes
+// with WithName before the program is saved. The gradients of the inputs are found with the Grad method of the input nodes.
+func LoadTapeMachine(r io.Reader, opts ...VMOpt) (m *tapeMachine, g *ExprGraph, err error) {
+	prog, locMap, err := loadProgram(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	m = NewTapeMachine(prog.g, append(opts, WithPrecompiled(prog, locMap))...)
+	return m, prog.g, nil
+}
+
+func loadProgram(r io.Reader) (prog *program, locMap map[*Node]register, err error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(programMagic))
+	if _, err = io.ReadFull(br, magic); err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to read the header of the program")
+	}
+	if string(magic) != programMagic {
+		return nil, nil, errors.Errorf("Not a saved program")
+	}
+	var version uint32
+	if err = binary.Read(br, binary.LittleEndian, &version); err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to read the header of the program")
+	}
+	if version != programVersion {
+		return nil, nil, errors.Errorf("Unsupported program version %d. Expected version %d", version, programVersion)
+	}
+
+	var sp savedProgram
+	if err = gob.NewDecoder(br).Decode(&sp); err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to decode the program")
+	}
+
+	g := NewGraph()
+	nodes := make(Nodes, len(sp.Nodes))
+	locMap = make(map[*Node]register)
+	for i, sn := range sp.Nodes {
+		if nodes[i], err = sn.node(g, nodes); err != nil {
+			return nil, nil, errors.Wrapf(err, "Unable to load node %d (%q)", i, sn.Name)
+		}
+		if sn.Loc != nil {
+			locMap[nodes[i]] = register{sn.Loc.ID, Device(sn.Loc.Device)}
+		}
+	}
+	for i, sn := range sp.Nodes {
+		if sn.Deriv >= 0 {
+			nodes[i].deriv = nodes[sn.Deriv]
+		}
+		for _, j := range sn.DerivOf {
+			nodes[i].derivOf = append(nodes[i].derivOf, nodes[j])
+		}
+	}
+
+	df := newdataflow()
+	df.replacements = make(map[*Node]*Node)
+	for i, j := range sp.Replacements {
+		df.replacements[nodes[i]] = nodes[j]
+	}
+
+	instrs := make(fragment, len(sp.Instructions))
+	for pc, si := range sp.Instructions {
+		if instrs[pc], err = si.instr(nodes); err != nil {
+			return nil, nil, errors.Wrapf(err, "Unable to load instruction %d", pc)
+		}
+	}
+
+	prog = &program{
+		instructions: instrs,
+		args:         sp.Args,
+		cpulocs:      sp.CPULocs,
+		gpulocs:      sp.GPULocs,
+		cpumem:       sp.CPUMem,
+		g:            g,
+		df:           df,
+		sorted:       nodes,
+	}
+	return prog, locMap, nil
+}
+
+func saveNode(n *Node, index map[*Node]int) (retVal savedNode, err error) {
+	retVal.Name = n.name
+	if retVal.Type, err = saveType(n.t); err != nil {
+		return
+	}
+	retVal.Shape = n.shape.Clone()
+	for _, child := range n.children {
+		i, ok := index[child]
+		if !ok {
+			return retVal, errors.Errorf("Child %v comes after its parent", child)
+		}
+		retVal.Children = append(retVal.Children, i)
+	}
+	if n.op != nil {
+		retVal.Op, err = saveOp(n.op)
+	}
+	return
+}
+
+func (sn savedNode) node(g *ExprGraph, nodes Nodes) (*Node, error) {
+	if c, ok := sn.Op.(savedConstant); ok {
+		v, err := c.Value.value()
+		if err != nil {
+			return nil, err
+		}
+		return g.AddNode(NewConstant(v, WithName(sn.Name))), nil
+	}
+
+	t, err := sn.Type.hmType()
+	if err != nil {
+		return nil, err
+	}
+	children := make(Nodes, len(sn.Children))
+	for i, c := range sn.Children {
+		children[i] = nodes[c]
+	}
+
+	opts := []NodeConsOpt{In(g), WithType(t), WithShape(sn.Shape...), WithName(sn.Name), WithChildren(children)}
+	if sn.Op != nil {
+		var op Op
+		if op, err = sn.Op.op(children); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithOp(op))
+	}
+	return NewUniqueNode(opts...), nil
+}
+
+func saveType(t hm.Type) (retVal savedType, err error) {
+	switch tt := t.(type) {
+	case tensor.Dtype:
+		return savedType{Dtype: tt.String()}, nil
+	case TensorType:
+		retVal, err = saveType(tt.Of)
+		retVal.Dims = tt.Dims
+		return
+	case *TensorType:
+		retVal, err = saveType(tt.Of)
+		retVal.Dims = tt.Dims
+		return
+	}
+	return retVal, errors.Errorf(nyiTypeFail, "saveType", t)
+}
+
+func (st savedType) dtype() (tensor.Dtype, error) {
+	for _, dt := range []tensor.Dtype{Float64, Float32, Int, Int64, Int32, Byte, Bool} {
+		if dt.String() == st.Dtype {
+			return dt, nil
+		}
+	}
+	return tensor.Dtype{}, errors.Errorf("Unknown Dtype %q", st.Dtype)
+}
+
+func (st savedType) hmType() (hm.Type, error) {
+	dt, err := st.dtype()
+	if err != nil {
+		return nil, err
+	}
+	if st.Dims == 0 {
+		return dt, nil
+	}
+	return newTensorType(st.Dims, dt), nil
+}
+
+func saveOp(op Op) (savedOp, error) {
+	switch o := op.(type) {
+	case elemBinOp:
+		at, err := saveType(o.arg0)
+		if err != nil {
+			return nil, err
+		}
+		bt, err := saveType(o.arg1)
+		if err != nil {
+			return nil, err
+		}
+		return savedElemBinOp{byte(o.binOpType()), at, bt, o.retSame}, nil
+	case elemUnaryOp:
+		var dt tensor.Dtype
+		switch o.ʘUnaryOperator.(type) {
+		case *sf64UnaryOperator:
+			dt = Float64
+		case *sf32UnaryOperator:
+			dt = Float32
+		default:
+			return nil, errors.Errorf(nyiTypeFail, "saveOp", o.ʘUnaryOperator)
+		}
+		return savedElemUnaryOp{byte(o.unaryOpType()), dt.String(), o.argTensor, o.numericResult}, nil
+	case linAlgBinOp:
+		return savedLinAlgBinOp{byte(o.āBinaryOperator), o.transA, o.transB}, nil
+	case constantScalar:
+		sv, err := saveValue(o.v)
+		return savedConstant{sv}, err
+	case constantTensor:
+		sv, err := saveValue(o.v)
+		return savedConstant{sv}, err
+	case sumOp:
+		return savedSumOp{o.along, o.d, o.inputShape}, nil
+	case maxOp:
+		return savedMaxOp{o.along, o.d}, nil
+	case *maxOp:
+		return savedMaxOp{o.along, o.d}, nil
+	case sizeOp:
+		return savedSizeOp{o.axis, o.d, o.val}, nil
+	case *repeatOp:
+		return savedRepeatOp{o.along, o.inputShape, o.d, o.arg0Dim, o.children}, nil
+	case reshapeOp:
+		return savedReshapeOp{o.from, o.to}, nil
+	case transposeOp:
+		return savedTransposeOp{o.pattern, o.d}, nil
+	case *sliceOp:
+		so := savedSliceOp{All: o.Slice == nil, Along: o.along, Axis: o.a, Dims: o.d}
+		if o.Slice != nil {
+			so.Start, so.End, so.Step = o.Start(), o.End(), o.Step()
+		}
+		return so, nil
+	case letOp:
+		return savedLetOp{}, nil
+	}
+	return nil, errors.Errorf(nyiFail, "SaveProgram", op)
+}
+
+func (so savedElemBinOp) op(Nodes) (Op, error) {
+	at, err := so.Arg0.hmType()
+	if err != nil {
+		return nil, err
+	}
+	bt, err := so.Arg1.hmType()
+	if err != nil {
+		return nil, err
+	}
+	op := newEBOByType(ʘBinaryOperatorType(so.Operator), at, bt)
+	op.retSame = so.RetSame
+	return op, nil
+}
+
+func (so savedElemUnaryOp) op(Nodes) (Op, error) {
+	if so.Operator >= byte(maxʘUnaryOperator) {
+		return nil, errors.Errorf("Unknown unary operator %d", so.Operator)
+	}
+	op := elemUnaryOp{argTensor: so.ArgTensor, numericResult: so.NumericResult}
+	switch so.Dtype {
+	case Float64.String():
+		op.ʘUnaryOperator = sf64UnaryOperators[so.Operator]
+	case Float32.String():
+		op.ʘUnaryOperator = sf32UnaryOperators[so.Operator]
+	default:
+		return nil, errors.Errorf(nyiFail, "elemUnaryOp", so.Dtype)
+	}
+	return op, nil
+}
+
+func (so savedLinAlgBinOp) op(Nodes) (Op, error) {
+	if so.Operator >= byte(maxĀBinaryOperator) {
+		return nil, errors.Errorf("Unknown linear algebra operator %d", so.Operator)
+	}
+	return linAlgBinOp{āBinaryOperator: āBinaryOperator(so.Operator), transA: so.TransA, transB: so.TransB}, nil
+}
+
+func (so savedConstant) op(Nodes) (Op, error) {
+	return nil, errors.New("Constants are rebuilt with NewConstant")
+}
+
+func (so savedSumOp) op(Nodes) (Op, error) {
+	return newSumOp(axes(so.Along), tensor.Shape(so.InputShape), so.D), nil
+}
+
+func (so savedMaxOp) op(Nodes) (Op, error) { return newMaxOp(axes(so.Along), so.D), nil }
+
+func (so savedSizeOp) op(Nodes) (Op, error) { return sizeOp{axis: so.Axis, d: so.D, val: so.Val}, nil }
+
+func (so savedRepeatOp) op(Nodes) (Op, error) {
+	return &repeatOp{
+		along:      axes(so.Along),
+		inputShape: tensor.Shape(so.InputShape),
+		d:          so.D,
+		arg0Dim:    so.Arg0Dim,
+		children:   so.NChild,
+	}, nil
+}
+
+func (so savedReshapeOp) op(Nodes) (Op, error) {
+	return reshapeOp{from: tensor.Shape(so.From), to: tensor.Shape(so.To)}, nil
+}
+
+func (so savedTransposeOp) op(Nodes) (Op, error) {
+	return transposeOp{pattern: so.Pattern, d: so.D}, nil
+}
+
+func (so savedSliceOp) op(Nodes) (Op, error) {
+	op := &sliceOp{along: so.Along, a: so.Axis, d: so.Dims}
+	if !so.All {
+		op.Slice = sli{start: so.Start, end: so.End, step: so.Step}
+	}
+	return op, nil
+}
+
+func (so savedLetOp) op(Nodes) (Op, error) { return letOp{}, nil }
+
+func saveRegisters(regs []register) (retVal []savedRegister) {
+	for _, r := range regs {
+		retVal = append(retVal, savedRegister{r.id, int(r.device)})
+	}
+	return
+}
+
+func (sr savedRegister) register() register { return register{sr.ID, Device(sr.Device)} }
+
+func saveInstr(instr tapeInstr, byID map[int]int) (retVal savedInstr, err error) {
+	retVal.Node = -1
+	if id := instr.ID(); id >= 0 {
+		i, ok := byID[id]
+		if !ok {
+			return retVal, errors.Errorf("Node %x is not in the program", id)
+		}
+		retVal.Node = i
+	}
+	retVal.Reads = saveRegisters(instr.reads())
+	w := instr.writes()
+	retVal.Write = savedRegister{w.id, int(w.device)}
+
+	switch it := instr.(type) {
+	case alloc:
+		retVal.Kind = allocInstr
+		if retVal.Type, err = saveType(it.t); err != nil {
+			return
+		}
+		retVal.Shape = it.s.Clone()
+	case free:
+		retVal.Kind = freeInstr
+	case loadArg:
+		retVal.Kind = loadArgInstr
+	case *execOp:
+		retVal.Kind = execOpInstr
+		retVal.Size = it.size
+		retVal.PreAllocated = it.preAllocated && it.arena == nil
+		retVal.UseUnsafe = it.useUnsafe
+		retVal.UseGPU = it.useGPU
+	case flushInstr:
+		retVal.Kind = flushInstrKind
+	case letInstr:
+		retVal.Kind = letInstrKind
+	default:
+		return retVal, errors.Errorf(nyiTypeFail, "SaveProgram", instr)
+	}
+	return
+}
+
+func (si savedInstr) instr(nodes Nodes) (tapeInstr, error) {
+	if si.Node >= len(nodes) {
+		return nil, errors.Errorf("Node %d is out of range", si.Node)
+	}
+	var n *Node
+	if si.Node >= 0 {
+		n = nodes[si.Node]
+	}
+	if n == nil && (si.Kind == allocInstr || si.Kind == loadArgInstr || si.Kind == execOpInstr) {
+		return nil, errors.Errorf("Instruction of kind %d requires a node", si.Kind)
+	}
+
+	var reads []register
+	for _, r := range si.Reads {
+		reads = append(reads, r.register())
+	}
+	write := si.Write.register()
+
+	switch si.Kind {
+	case allocInstr:
+		t, err := si.Type.hmType()
+		if err != nil {
+			return nil, err
+		}
+		return alloc{id: n.ID(), t: t, s: tensor.Shape(si.Shape), readFrom: reads, writeTo: write}, nil
+	case freeInstr:
+		if len(reads) != 1 {
+			return nil, errors.Errorf("Expected free to read 1 register. Got %d instead", len(reads))
+		}
+		return free{readsFrom: reads[0]}, nil
+	case loadArgInstr:
+		return loadArg{index: n.ID(), writeTo: write}, nil
+	case execOpInstr:
+		return &execOp{
+			op:           n.op,
+			id:           n.ID(),
+			readFrom:     reads,
+			writeTo:      write,
+			size:         si.Size,
+			preAllocated: si.PreAllocated,
+			useUnsafe:    si.UseUnsafe,
+			useGPU:       si.UseGPU,
+		}, nil
+	case flushInstrKind:
+		return flushInstr{}, nil
+	case letInstrKind:
+		if len(reads) != 1 {
+			return nil, errors.Errorf("Expected let to read 1 register. Got %d instead", len(reads))
+		}
+		return letInstr{readFrom: reads[0], writeTo: write}, nil
+	}
+	return nil, errors.Errorf("Unknown instruction kind %d", si.Kind)
+}
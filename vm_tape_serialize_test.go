@@ -0,0 +1,88 @@
+package gorgonia
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestSaveProgram(t *testing.T) {
+	g := NewGraph(WithRNG(rand.New(rand.NewSource(1337))))
+	x := NewMatrix(g, Float64, WithShape(4, 3), WithName("x"), WithRandInit(RandGaussian(0, 1)))
+	w := NewMatrix(g, Float64, WithShape(3, 2), WithName("w"), WithRandInit(RandGlorotN(1)))
+	h := Must(Sigmoid(Must(Mul(x, w))))
+	h = Must(Tanh(Must(Add(h, NewConstant(1.0)))))
+	cost := Must(Mean(Must(Square(Must(Transpose(h))))))
+	WithName("cost")(cost)
+	if _, err := Grad(cost, w); err != nil {
+		t.Fatal(err)
+	}
+
+	prog, locMap, err := Compile(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewTapeMachine(g, WithPrecompiled(prog, locMap))
+	if err = m.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+	dw, err := w.Grad()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = SaveProgram(&buf, prog, locMap); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.Bytes()
+
+	m2, g2, err := LoadTapeMachine(bytes.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x2, w2, cost2 := g2.ByName("x"), g2.ByName("w"), g2.ByName("cost")
+	if len(x2) != 1 || len(w2) != 1 || len(cost2) != 1 {
+		t.Fatalf("Expected to find x, w and cost in the loaded graph. Got %v %v %v", x2, w2, cost2)
+	}
+	Let(x2[0], x.Value())
+	Let(w2[0], w.Value())
+	if err = m2.RunAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ValueEq(cost.Value(), cost2[0].Value()) {
+		t.Errorf("Expected the loaded program to compute the cost %v. Got %v instead", cost.Value(), cost2[0].Value())
+	}
+	dw2, err := w2[0].Grad()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ValueEq(dw, dw2) {
+		t.Errorf("Expected the loaded program to compute the gradient\n%v\nGot\n%v", dw, dw2)
+	}
+
+	// bad headers
+	if _, _, err = LoadTapeMachine(bytes.NewReader([]byte("NOTAPROGRAM"))); err == nil {
+		t.Error("Expected an error when loading something that is not a program")
+	}
+	future := append([]byte(nil), saved...)
+	future[len(programMagic)] = programVersion + 1
+	if _, _, err = LoadTapeMachine(bytes.NewReader(future)); err == nil {
+		t.Error("Expected an error when loading a program of another version")
+	}
+
+	// unsupported ops
+	g = NewGraph()
+	x = NewVector(g, Float64, WithShape(3), WithName("x"), WithValue(tensor.New(tensor.WithBacking([]float64{1, 2, 3}))))
+	var v Value
+	Read(Must(Sum(x)), &v)
+	if prog, locMap, err = Compile(g); err != nil {
+		t.Fatal(err)
+	}
+	if err = SaveProgram(&buf, prog, locMap); err == nil {
+		t.Error("Expected an error when saving a program that reads into a Value")
+	}
+}
@@ -0,0 +1,276 @@
+package gorgonia
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chewxy/gorgonia/tensor"
+	"github.com/pkg/errors"
+)
+
+// wideGraph is an inception-like graph: several independent heads reading the same input. If symbolic is true, the gradients of the
+// weights are added to the graph.
+func wideGraph(heads int, symbolic bool) (g *ExprGraph, x *Node, ws Nodes, cost *Node) {
+	g = NewGraph(WithRNG(rand.New(rand.NewSource(1337))))
+	x = NewMatrix(g, Float64, WithShape(8, 16), WithName("x"), WithRandInit(RandGaussian(0, 1)))
+
+	var outs Nodes
+	for i := 0; i < heads; i++ {
+		w := NewMatrix(g, Float64, WithShape(16, 16), WithName("w"+string('a'+rune(i))), WithRandInit(RandGlorotN(1)))
+		ws = append(ws, w)
+		h := Must(Tanh(Must(Mul(x, w))))
+		outs = append(outs, Must(Sum(Must(Square(h)))))
+	}
+
+	cost = outs[0]
+	for _, o := range outs[1:] {
+		cost = Must(Add(cost, o))
+	}
+	if !symbolic {
+		return
+	}
+	if _, err := Grad(cost, ws...); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func TestParallelExecution(t *testing.T) {
+	run := func(opts ...VMOpt) (float64, []Value) {
+		g, _, ws, cost := wideGraph(6, true)
+		m := NewTapeMachine(g, append(opts, BindDualValues(ws...))...)
+		for i := 0; i < 3; i++ {
+			zeroGrads(ws)
+			m.Reset()
+			if err := m.RunAll(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var grads []Value
+		for _, w := range ws {
+			grad, err := w.Grad()
+			if err != nil {
+				t.Fatal(err)
+			}
+			grads = append(grads, grad)
+		}
+		return extractF64(cost.Value()), grads
+	}
+
+	expectedCost, expectedGrads := run()
+	for _, workers := range []int{2, 4, 0} {
+		cost, grads := run(WithParallelExecution(workers))
+		if cost != expectedCost {
+			t.Errorf("%d workers: Expected the cost to be %v. Got %v instead", workers, expectedCost, cost)
+		}
+		for i := range grads {
+			if !ValueEq(expectedGrads[i], grads[i]) {
+				t.Errorf("%d workers: Expected the gradient %d to be the same as the sequential gradient", workers, i)
+			}
+		}
+	}
+
+	// the independent heads are in the same segment
+	g, _, _, _ := wideGraph(4, true)
+	m := NewTapeMachine(g, WithParallelExecution(4))
+	sched := newSchedule(m)
+	var largest int
+	var roots int
+	for _, seg := range sched.segments {
+		if len(seg.instrs) > largest {
+			largest = len(seg.instrs)
+			roots = 0
+			for _, n := range seg.npreds {
+				if n == 0 {
+					roots++
+				}
+			}
+		}
+	}
+	if largest < 4 || roots < 2 {
+		t.Errorf("Expected a segment with independent instructions. The largest segment has %d instructions and %d roots", largest, roots)
+	}
+}
+
+func TestParallelExecutionError(t *testing.T) {
+	g := NewGraph()
+	x := NewMatrix(g, Float64, WithShape(2, 2), WithName("x"), WithValue(tensor.New(tensor.WithShape(2, 2), tensor.WithBacking([]float64{1, 0, 0, 1}))))
+	y := Must(Log(Must(Sub(x, x))))
+	z := Must(Exp(x))
+	Must(Add(y, z))
+
+	m := NewTapeMachine(g, WithParallelExecution(2), WithInfWatch())
+	if err := m.RunAll(); err == nil {
+		t.Error("Expected the Inf to be found")
+	}
+}
+
+// the calls of a Func share its body
+func TestParallelExecutionCall(t *testing.T) {
+	fg := NewGraph()
+	fx := NewVector(fg, Float64, WithName("x"), WithShape(3))
+	fw := NewMatrix(fg, Float64, WithName("W"), WithShape(3, 3))
+	f, err := DefineFunc(Nodes{fx, fw}, Nodes{Must(residualUnit(fx, fw))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(opts ...VMOpt) (*tapeMachine, []Value) {
+		g := NewGraph(WithRNG(rand.New(rand.NewSource(1337))))
+		x := NewVector(g, Float64, WithName("x"), WithShape(3), WithRandInit(RandGaussian(0, 1)))
+		w1 := NewMatrix(g, Float64, WithName("W1"), WithShape(3, 3), WithRandInit(RandGlorotN(1)))
+		w2 := NewMatrix(g, Float64, WithName("W2"), WithShape(3, 3), WithRandInit(RandGlorotN(1)))
+		y1, err := Call(f, x, w1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y2, err := Call(f, x, w2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cost := Must(Add(Must(Sum(y1[0])), Must(Sum(y2[0]))))
+		grads, err := Grad(cost, w1, w2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := NewTapeMachine(g, opts...)
+		if err = m.RunAll(); err != nil {
+			t.Fatal(err)
+		}
+		return m, []Value{cost.Value(), grads[0].Value(), grads[1].Value()}
+	}
+
+	_, want := run()
+	m, got := run(WithParallelExecution(4))
+	for i := range want {
+		if !ValueClose(want[i], got[i]) {
+			t.Errorf("Expected %v. Got %v instead", want[i], got[i])
+		}
+	}
+
+	// the calls, and their gradients, are executed on their own
+	var calls int
+	for _, seg := range m.sched.segments {
+		for _, pc := range seg.instrs {
+			op, ok := m.p.instructions[pc].(*execOp)
+			if !ok {
+				continue
+			}
+			switch op.op.(type) {
+			case *ctrlFlowOp, *ctrlFlowGradOp:
+				calls++
+				if !seg.barrier {
+					t.Errorf("Expected %v to be a barrier", op)
+				}
+			}
+		}
+	}
+	if calls < 4 {
+		t.Errorf("Expected the calls and their gradients to be scheduled. Got %d", calls)
+	}
+}
+
+// countdownCtx is a context that is canceled after its Err method is called n times
+type countdownCtx struct {
+	context.Context
+	n int32
+}
+
+func (ctx *countdownCtx) Err() error {
+	if atomic.AddInt32(&ctx.n, -1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestTapeMachineRunAllContext(t *testing.T) {
+	for _, opts := range [][]VMOpt{nil, {WithParallelExecution(4)}} {
+		g, _, _, cost := wideGraph(3, true)
+		m := NewTapeMachine(g, opts...)
+
+		// already canceled
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := m.RunAllContext(ctx)
+		cerr, ok := err.(*CanceledError)
+		if !ok {
+			t.Fatalf("Expected a *CanceledError. Got %v instead", err)
+		}
+		if cerr.Instr != 0 || errors.Cause(err) != context.Canceled {
+			t.Errorf("Expected the execution to stop before the first instruction. Got %v", cerr)
+		}
+
+		// canceled midway
+		m.Reset()
+		err = m.RunAllContext(&countdownCtx{Context: context.Background(), n: 5})
+		if cerr, ok = err.(*CanceledError); !ok {
+			t.Fatalf("Expected a *CanceledError. Got %v instead", err)
+		}
+		if cerr.Instr == 0 || cerr.Instr >= len(m.Prog().instructions) {
+			t.Errorf("Expected the execution to stop midway. Stopped at %d", cerr.Instr)
+		}
+
+		// deadlines that are not exceeded
+		m.Reset()
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		if err = m.RunAllContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if cost.Value() == nil {
+			t.Error("Expected the cost to be computed")
+		}
+	}
+}
+
+func TestTapeMachineRun(t *testing.T) {
+	g := NewGraph()
+	x := NewVector(g, Float64, WithShape(3), WithName("x"))
+	y := NewVector(g, Float64, WithShape(3), WithName("y")) // never fed
+	xx := Must(HadamardProd(x, NewConstant(2.0)))
+	sum := Must(Sum(xx))
+	Must(Add(y, NewConstant(1.0)))
+
+	m := NewTapeMachine(g)
+	feeds := map[*Node]Value{x: tensor.New(tensor.WithBacking([]float64{1, 2, 3}))}
+	vals, err := m.Run(feeds, sum, xx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extractF64(vals[0]); got != 12 {
+		t.Errorf("Expected the sum to be 12. Got %v instead", got)
+	}
+	if got := extractF64s(vals[1]); !floatsEqual64([]float64{2, 4, 6}, got) {
+		t.Errorf("Expected xx to be [2 4 6]. Got %v instead", got)
+	}
+
+	// the values returned are copies
+	feeds[x] = tensor.New(tensor.WithBacking([]float64{0, 0, 1}))
+	vals2, err := m.Run(feeds, xx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extractF64s(vals[1]); !floatsEqual64([]float64{2, 4, 6}, got) {
+		t.Errorf("Expected the value of the previous run to be unchanged. Got %v instead", got)
+	}
+	if got := extractF64s(vals2[0]); !floatsEqual64([]float64{0, 0, 2}, got) {
+		t.Errorf("Expected xx to be [0 0 2]. Got %v instead", got)
+	}
+
+	// bad feeds
+	bad := []map[*Node]Value{
+		{x: tensor.New(tensor.WithBacking([]float32{1, 2, 3}))},
+		{x: tensor.New(tensor.WithBacking([]float64{1, 2, 3, 4}))},
+		{xx: tensor.New(tensor.WithBacking([]float64{1, 2, 3}))},
+	}
+	for i, feeds := range bad {
+		if _, err = m.Run(feeds, sum); err == nil {
+			t.Errorf("Test %d: Expected an error", i)
+		}
+	}
+}
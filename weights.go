@@ -2,6 +2,7 @@ package gorgonia
 
 import (
 	"math"
+	"math/rand"
 	"time"
 
 	"github.com/chewxy/gorgonia/tensor"
@@ -12,12 +13,31 @@ import (
 // This file provides several weight initialization utility functions.
 // It uses the rng package by leesper
 
+// randSeed returns the seed of a generator of the rng package. It's drawn from r, or from the time if r is nil.
+func randSeed(r *rand.Rand) int64 {
+	if r != nil {
+		return r.Int63()
+	}
+	return time.Now().UnixNano()
+}
+
 // InitWFn is a type of helper function to help initialize weights vector/matrices.
 // It generates the backing required for the tensors.
 //
 // It's typically used in closures
 type InitWFn func(dt tensor.Dtype, s ...int) interface{}
 
+// RandInitWFn is a type of helper function that initializes weights with random numbers drawn from r.
+// If r is nil, the numbers are drawn from generators seeded with the time.
+//
+// Use WithRandInit to draw the numbers from the RNG of the graph (see WithRNG).
+type RandInitWFn func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{}
+
+// unseeded returns the InitWFn that draws its numbers from generators seeded with the time.
+func (fn RandInitWFn) unseeded() InitWFn {
+	return func(dt tensor.Dtype, s ...int) interface{} { return fn(nil, dt, s...) }
+}
+
 // Zeroes creates an InitWfn that populates a Value with... zeroes. I don't know what you expected.
 func Zeroes() InitWFn {
 	f := func(dt tensor.Dtype, s ...int) interface{} {
@@ -84,13 +104,16 @@ func ValuesOf(val interface{}) InitWFn {
 // Example Usage:
 //		w := NewMatrix(g, Float64, WithName("w"), WithShape(2,2), WithInit(Gaussian(0, 1)))
 // This will create a backing slice of []float64, with the length of 4, and its values are drawn from a gaussian distro
-func Gaussian(mean, stdev float64) InitWFn {
-	f := func(dt tensor.Dtype, s ...int) interface{} {
+func Gaussian(mean, stdev float64) InitWFn { return RandGaussian(mean, stdev).unseeded() }
+
+// RandGaussian creates a RandInitWFn that draws the values from a gaussian distribution. See Gaussian.
+func RandGaussian(mean, stdev float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
 		switch dt {
 		case tensor.Float64:
-			return Gaussian64(mean, stdev, s...)
+			return gaussian64(r, mean, stdev, s...)
 		case tensor.Float32:
-			return Gaussian32(mean, stdev, s...)
+			return gaussian32(r, mean, stdev, s...)
 		default:
 			err := errors.Errorf(nyiTypeFail, "Gaussian init", dt)
 			panic(err)
@@ -103,13 +126,16 @@ func Gaussian(mean, stdev float64) InitWFn {
 // Example Usage:
 //		w := NewMatrix(g, Float64, WithName("w"), WithShape(2,2), WithInit(Uniform(-1, 1)))
 // This will create a backing slice of []float64, with the length of 4, and its values are drawn from a uniform distro
-func Uniform(low, high float64) InitWFn {
-	f := func(dt tensor.Dtype, s ...int) interface{} {
+func Uniform(low, high float64) InitWFn { return RandUniform(low, high).unseeded() }
+
+// RandUniform creates a RandInitWFn that draws the values from a uniform distribution. See Uniform.
+func RandUniform(low, high float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
 		switch dt {
 		case tensor.Float64:
-			return Uniform64(low, high, s...)
+			return uniform64(r, low, high, s...)
 		case tensor.Float32:
-			return Uniform32(low, high, s...)
+			return uniform32(r, low, high, s...)
 		default:
 			err := errors.Errorf(nyiTypeFail, "Uniform init", dt)
 			panic(err)
@@ -119,13 +145,16 @@ func Uniform(low, high float64) InitWFn {
 }
 
 // GlorotN creates a InitWFn that populates a Value with weights normally sampled using Glorot et al.'s algorithm
-func GlorotN(gain float64) InitWFn {
-	f := func(dt tensor.Dtype, s ...int) interface{} {
+func GlorotN(gain float64) InitWFn { return RandGlorotN(gain).unseeded() }
+
+// RandGlorotN creates a RandInitWFn that samples the weights from a normal distribution using Glorot et al.'s algorithm. See GlorotN.
+func RandGlorotN(gain float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
 		switch dt {
 		case tensor.Float64:
-			return GlorotEtAlN64(gain, s...)
+			return glorotEtAlN64(r, gain, s...)
 		case tensor.Float32:
-			return GlorotEtAlN32(gain, s...)
+			return glorotEtAlN32(r, gain, s...)
 		default:
 			err := errors.Errorf(nyiTypeFail, "GlorotN", dt)
 			panic(err)
@@ -135,13 +164,16 @@ func GlorotN(gain float64) InitWFn {
 }
 
 // GlorotU creates a InitWFn that populates a Value with weights uniformly sampled using Glorot et al.'s algorithm
-func GlorotU(gain float64) InitWFn {
-	f := func(dt tensor.Dtype, s ...int) interface{} {
+func GlorotU(gain float64) InitWFn { return RandGlorotU(gain).unseeded() }
+
+// RandGlorotU creates a RandInitWFn that samples the weights from a uniform distribution using Glorot et al.'s algorithm. See GlorotU.
+func RandGlorotU(gain float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
 		switch dt {
 		case tensor.Float64:
-			return GlorotEtAlU64(gain, s...)
+			return glorotEtAlU64(r, gain, s...)
 		case tensor.Float32:
-			return GlorotEtAlU32(gain, s...)
+			return glorotEtAlU32(r, gain, s...)
 		default:
 			err := errors.Errorf(nyiTypeFail, "GlorotU", dt)
 			panic(err)
@@ -150,11 +182,136 @@ func GlorotU(gain float64) InitWFn {
 	return f
 }
 
+// HeN creates a InitWFn that populates a Value with weights normally sampled using He et al.'s algorithm
+func HeN(gain float64) InitWFn { return RandHeN(gain).unseeded() }
+
+// RandHeN creates a RandInitWFn that samples the weights from a normal distribution using He et al.'s algorithm. See HeN.
+func RandHeN(gain float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
+		switch dt {
+		case tensor.Float64:
+			return heEtAlN64(r, gain, s...)
+		case tensor.Float32:
+			return heEtAlN32(r, gain, s...)
+		default:
+			err := errors.Errorf(nyiTypeFail, "HeN", dt)
+			panic(err)
+		}
+	}
+	return f
+}
+
+// HeU creates a InitWFn that populates a Value with weights uniformly sampled using He et al.'s algorithm
+func HeU(gain float64) InitWFn { return RandHeU(gain).unseeded() }
+
+// RandHeU creates a RandInitWFn that samples the weights from a uniform distribution using He et al.'s algorithm. See HeU.
+func RandHeU(gain float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
+		switch dt {
+		case tensor.Float64:
+			return heEtAlU64(r, gain, s...)
+		case tensor.Float32:
+			return heEtAlU32(r, gain, s...)
+		default:
+			err := errors.Errorf(nyiTypeFail, "HeU", dt)
+			panic(err)
+		}
+	}
+	return f
+}
+
+// LeCunN creates a InitWFn that populates a Value with weights normally sampled using LeCun et al.'s algorithm:
+// the standard deviation is sqrt(1/fanIn). This is the same as HeN(1).
+func LeCunN() InitWFn { return HeN(1) }
+
+// LeCunU creates a InitWFn that populates a Value with weights uniformly sampled using LeCun et al.'s algorithm:
+// the weights are drawn from [-sqrt(3/fanIn), sqrt(3/fanIn)). This is the same as HeU(1).
+func LeCunU() InitWFn { return HeU(1) }
+
+// RandLeCunN creates a RandInitWFn that samples the weights from a normal distribution using LeCun et al.'s algorithm. See LeCunN.
+func RandLeCunN() RandInitWFn { return RandHeN(1) }
+
+// RandLeCunU creates a RandInitWFn that samples the weights from a uniform distribution using LeCun et al.'s algorithm. See LeCunU.
+func RandLeCunU() RandInitWFn { return RandHeU(1) }
+
+// TruncatedNormal creates a InitWFn that populates a Value with values drawn from a gaussian distribution, where the values that are more than
+// two standard deviations away from the mean are drawn again.
+func TruncatedNormal(mean, stdev float64) InitWFn { return RandTruncatedNormal(mean, stdev).unseeded() }
+
+// RandTruncatedNormal creates a RandInitWFn that draws the values from a truncated gaussian distribution. See TruncatedNormal.
+func RandTruncatedNormal(mean, stdev float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
+		switch dt {
+		case tensor.Float64:
+			return truncatedNormal64(r, mean, stdev, s...)
+		case tensor.Float32:
+			return truncatedNormal32(r, mean, stdev, s...)
+		default:
+			err := errors.Errorf(nyiTypeFail, "TruncatedNormal", dt)
+			panic(err)
+		}
+	}
+	return f
+}
+
+// Orthogonal creates a InitWFn that populates a Value with a (semi) orthogonal matrix, scaled by gain.
+// Tensors with more than two dimensions are treated as a matrix of shape (s[0], the product of the rest).
+// See also: https://arxiv.org/abs/1312.6120
+func Orthogonal(gain float64) InitWFn { return RandOrthogonal(gain).unseeded() }
+
+// RandOrthogonal creates a RandInitWFn that populates a Value with a (semi) orthogonal matrix, scaled by gain. See Orthogonal.
+func RandOrthogonal(gain float64) RandInitWFn {
+	f := func(r *rand.Rand, dt tensor.Dtype, s ...int) interface{} {
+		switch dt {
+		case tensor.Float64:
+			return orthogonal64(r, gain, s...)
+		case tensor.Float32:
+			return orthogonal32(r, gain, s...)
+		default:
+			err := errors.Errorf(nyiTypeFail, "Orthogonal", dt)
+			panic(err)
+		}
+	}
+	return f
+}
+
+// Identity creates a InitWFn that populates a matrix with the identity matrix scaled by gain.
+// Non-square matrices have ones on the main diagonal.
+func Identity(gain float64) InitWFn {
+	f := func(dt tensor.Dtype, s ...int) interface{} {
+		if len(s) != 2 {
+			panic("Identity only works with matrices")
+		}
+		switch dt {
+		case tensor.Float64:
+			retVal := make([]float64, s[0]*s[1])
+			for i := 0; i < s[0] && i < s[1]; i++ {
+				retVal[i*s[1]+i] = gain
+			}
+			return retVal
+		case tensor.Float32:
+			retVal := make([]float32, s[0]*s[1])
+			for i := 0; i < s[0] && i < s[1]; i++ {
+				retVal[i*s[1]+i] = float32(gain)
+			}
+			return retVal
+		default:
+			err := errors.Errorf(nyiTypeFail, "Identity", dt)
+			panic(err)
+		}
+	}
+	return f
+}
+
 // Gaussian64 returns a []float64 drawn from a gaussian distribution as defined by the mean and stdev
 func Gaussian64(mean, stdev float64, s ...int) []float64 {
+	return gaussian64(nil, mean, stdev, s...)
+}
+
+func gaussian64(r *rand.Rand, mean, stdev float64, s ...int) []float64 {
 	size := tensor.Shape(s).TotalSize()
 
-	rand := rng.NewGaussianGenerator(time.Now().UnixNano())
+	rand := rng.NewGaussianGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = rand.Gaussian(mean, stdev)
@@ -164,9 +321,13 @@ func Gaussian64(mean, stdev float64, s ...int) []float64 {
 
 // Gaussian32 returns a []float32 drawn from a gaussian distribution as defined by the mean and stdev
 func Gaussian32(mean, stdev float64, s ...int) []float32 {
+	return gaussian32(nil, mean, stdev, s...)
+}
+
+func gaussian32(r *rand.Rand, mean, stdev float64, s ...int) []float32 {
 	size := tensor.Shape(s).TotalSize()
 
-	rand := rng.NewGaussianGenerator(time.Now().UnixNano())
+	rand := rng.NewGaussianGenerator(randSeed(r))
 	retVal := make([]float32, size)
 	for i := range retVal {
 		retVal[i] = float32(rand.Gaussian(mean, stdev))
@@ -174,11 +335,51 @@ func Gaussian32(mean, stdev float64, s ...int) []float32 {
 	return retVal
 }
 
+// TruncatedNormal64 returns a []float64 drawn from a gaussian distribution as defined by the mean and stdev,
+// with every value within two standard deviations of the mean.
+func TruncatedNormal64(mean, stdev float64, s ...int) []float64 {
+	return truncatedNormal64(nil, mean, stdev, s...)
+}
+
+func truncatedNormal64(r *rand.Rand, mean, stdev float64, s ...int) []float64 {
+	size := tensor.Shape(s).TotalSize()
+
+	rand := rng.NewGaussianGenerator(randSeed(r))
+	retVal := make([]float64, size)
+	for i := range retVal {
+		v := rand.Gaussian(mean, stdev)
+		for math.Abs(v-mean) > 2*stdev {
+			v = rand.Gaussian(mean, stdev)
+		}
+		retVal[i] = v
+	}
+	return retVal
+}
+
+// TruncatedNormal32 returns a []float32 drawn from a gaussian distribution as defined by the mean and stdev,
+// with every value within two standard deviations of the mean.
+func TruncatedNormal32(mean, stdev float64, s ...int) []float32 {
+	return truncatedNormal32(nil, mean, stdev, s...)
+}
+
+func truncatedNormal32(r *rand.Rand, mean, stdev float64, s ...int) []float32 {
+	f64 := truncatedNormal64(r, mean, stdev, s...)
+	retVal := make([]float32, len(f64))
+	for i, v := range f64 {
+		retVal[i] = float32(v)
+	}
+	return retVal
+}
+
 // Uniform64 returns a []float64 drawn from a uniform distribution between [low, high) that is provided
 func Uniform64(low, high float64, s ...int) []float64 {
+	return uniform64(nil, low, high, s...)
+}
+
+func uniform64(r *rand.Rand, low, high float64, s ...int) []float64 {
 	size := tensor.Shape(s).TotalSize()
 
-	rand := rng.NewUniformGenerator(time.Now().UnixNano())
+	rand := rng.NewUniformGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = rand.Float64Range(low, high)
@@ -188,11 +389,15 @@ func Uniform64(low, high float64, s ...int) []float64 {
 
 // Uniform32 returns a []float64 drawn from a uniform distribution between [low, high) that is provided
 func Uniform32(low, high float64, s ...int) []float32 {
+	return uniform32(nil, low, high, s...)
+}
+
+func uniform32(r *rand.Rand, low, high float64, s ...int) []float32 {
 	size := tensor.Shape(s).TotalSize()
 	l := float32(low)
 	h := float32(high)
 
-	rand := rng.NewUniformGenerator(time.Now().UnixNano())
+	rand := rng.NewUniformGenerator(randSeed(r))
 	retVal := make([]float32, size)
 	for i := range retVal {
 		retVal[i] = rand.Float32Range(l, h)
@@ -202,10 +407,14 @@ func Uniform32(low, high float64, s ...int) []float32 {
 
 // Binomial64 returns a []float64 drawn from a binomial distribution given the trial and probability parameters.
 func Binomial64(trials, prob float64, s ...int) []float64 {
+	return binomial64(nil, trials, prob, s...)
+}
+
+func binomial64(r *rand.Rand, trials, prob float64, s ...int) []float64 {
 	size := tensor.Shape(s).TotalSize()
 	t := int64(trials)
 
-	rand := rng.NewBinomialGenerator(time.Now().UnixNano())
+	rand := rng.NewBinomialGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = float64(rand.Binomial(t, prob))
@@ -215,10 +424,14 @@ func Binomial64(trials, prob float64, s ...int) []float64 {
 
 // Binomial32 returns a []float32 drawn from a binomial distribution given the trial and probability parameters.
 func Binomial32(trials, prob float64, s ...int) []float32 {
+	return binomial32(nil, trials, prob, s...)
+}
+
+func binomial32(r *rand.Rand, trials, prob float64, s ...int) []float32 {
 	size := tensor.Shape(s).TotalSize()
 	t := int64(trials)
 
-	rand := rng.NewBinomialGenerator(time.Now().UnixNano())
+	rand := rng.NewBinomialGenerator(randSeed(r))
 	retVal := make([]float32, size)
 	for i := range retVal {
 		retVal[i] = float32(rand.Binomial(t, prob))
@@ -232,6 +445,10 @@ func Binomial32(trials, prob float64, s ...int) []float32 {
 // using the methods specified in Glorot et. al (2010).
 // See also: http://jmlr.org/proceedings/papers/v9/glorot10a/glorot10a.pdf
 func GlorotEtAlN64(gain float64, s ...int) []float64 {
+	return glorotEtAlN64(nil, gain, s...)
+}
+
+func glorotEtAlN64(r *rand.Rand, gain float64, s ...int) []float64 {
 	var n1, n2 int
 	fieldSize := 1
 	switch len(s) {
@@ -253,7 +470,7 @@ func GlorotEtAlN64(gain float64, s ...int) []float64 {
 
 	stdev := gain * math.Sqrt(2.0/fanIn)
 
-	rand := rng.NewGaussianGenerator(time.Now().UnixNano())
+	rand := rng.NewGaussianGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = rand.Gaussian(0.0, stdev)
@@ -265,7 +482,11 @@ func GlorotEtAlN64(gain float64, s ...int) []float64 {
 // using the methods specified in Glorot et. al (2010).
 // See also: http://jmlr.org/proceedings/papers/v9/glorot10a/glorot10a.pdf
 func GlorotEtAlN32(gain float64, s ...int) []float32 {
-	f64 := GlorotEtAlN64(gain, s...)
+	return glorotEtAlN32(nil, gain, s...)
+}
+
+func glorotEtAlN32(r *rand.Rand, gain float64, s ...int) []float32 {
+	f64 := glorotEtAlN64(r, gain, s...)
 	retVal := make([]float32, len(f64))
 	for i, v := range f64 {
 		retVal[i] = float32(v)
@@ -282,6 +503,10 @@ func GlorotEtAlN32(gain float64, s ...int) []float32 {
 //		math.Sqrt(2.0) for gain for weights that will be used in ReLU units
 //		math.Sqrt(2.0 / (1+alpha*alpha)) for ReLU that are leaky with alpha
 func GlorotEtAlU64(gain float64, s ...int) []float64 {
+	return glorotEtAlU64(nil, gain, s...)
+}
+
+func glorotEtAlU64(r *rand.Rand, gain float64, s ...int) []float64 {
 	var n1, n2 int
 	fieldSize := 1
 	switch len(s) {
@@ -305,7 +530,7 @@ func GlorotEtAlU64(gain float64, s ...int) []float64 {
 	lo := 0.0 - math.Sqrt(3.0)*stdev
 	hi := 0.0 + math.Sqrt(3.0)*stdev
 
-	rand := rng.NewUniformGenerator(time.Now().UnixNano())
+	rand := rng.NewUniformGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = rand.Float64Range(lo, hi)
@@ -322,7 +547,11 @@ func GlorotEtAlU64(gain float64, s ...int) []float64 {
 //		math.Sqrt(2.0) for gain for weights that will be used in ReLU units
 //		math.Sqrt(2.0 / (1+alpha*alpha)) for ReLU that are leaky with alpha
 func GlorotEtAlU32(gain float64, s ...int) []float32 {
-	f64 := GlorotEtAlN64(gain, s...)
+	return glorotEtAlU32(nil, gain, s...)
+}
+
+func glorotEtAlU32(r *rand.Rand, gain float64, s ...int) []float32 {
+	f64 := glorotEtAlU64(r, gain, s...)
 	retVal := make([]float32, len(f64))
 	for i, v := range f64 {
 		retVal[i] = float32(v)
@@ -340,6 +569,10 @@ func GlorotEtAlU32(gain float64, s ...int) []float32 {
 //		math.Sqrt(2.0) for gain for weights that will be used in ReLU units
 //		math.Sqrt(2.0 / (1+alpha*alpha)) for ReLU that are leaky with alpha
 func HeEtAlN64(gain float64, s ...int) []float64 {
+	return heEtAlN64(nil, gain, s...)
+}
+
+func heEtAlN64(r *rand.Rand, gain float64, s ...int) []float64 {
 	var fanIn float64
 
 	switch len(s) {
@@ -357,7 +590,7 @@ func HeEtAlN64(gain float64, s ...int) []float64 {
 	size := tensor.Shape(s).TotalSize()
 	stdev := gain * math.Sqrt(1.0/fanIn)
 
-	rand := rng.NewGaussianGenerator(time.Now().UnixNano())
+	rand := rng.NewGaussianGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = rand.Gaussian(0.0, stdev)
@@ -375,6 +608,10 @@ func HeEtAlN64(gain float64, s ...int) []float64 {
 //		math.Sqrt(2.0) for gain for weights that will be used in ReLU units
 //		math.Sqrt(2.0 / (1+alpha*alpha)) for ReLU that are leaky with alpha
 func HeEtAlU64(gain float64, s ...int) []float64 {
+	return heEtAlU64(nil, gain, s...)
+}
+
+func heEtAlU64(r *rand.Rand, gain float64, s ...int) []float64 {
 	var fanIn float64
 
 	switch len(s) {
@@ -395,10 +632,118 @@ func HeEtAlU64(gain float64, s ...int) []float64 {
 	lo := 0.0 - math.Sqrt(3.0)*stdev
 	hi := 0.0 + math.Sqrt(3.0)*stdev
 
-	rand := rng.NewUniformGenerator(time.Now().UnixNano())
+	rand := rng.NewUniformGenerator(randSeed(r))
 	retVal := make([]float64, size)
 	for i := range retVal {
 		retVal[i] = rand.Float64Range(lo, hi)
 	}
 	return retVal
 }
+
+// HeEtAlN32 returns float32 weights sampled from a normal distro, using the methods
+// described in He et al (2015). See HeEtAlN64 for details.
+func HeEtAlN32(gain float64, s ...int) []float32 {
+	return heEtAlN32(nil, gain, s...)
+}
+
+func heEtAlN32(r *rand.Rand, gain float64, s ...int) []float32 {
+	f64 := heEtAlN64(r, gain, s...)
+	retVal := make([]float32, len(f64))
+	for i, v := range f64 {
+		retVal[i] = float32(v)
+	}
+	return retVal
+}
+
+// HeEtAlU32 returns float32 weights sampled from a uniform distro, using the methods
+// described in He et al (2015). See HeEtAlU64 for details.
+func HeEtAlU32(gain float64, s ...int) []float32 {
+	return heEtAlU32(nil, gain, s...)
+}
+
+func heEtAlU32(r *rand.Rand, gain float64, s ...int) []float32 {
+	f64 := heEtAlU64(r, gain, s...)
+	retVal := make([]float32, len(f64))
+	for i, v := range f64 {
+		retVal[i] = float32(v)
+	}
+	return retVal
+}
+
+// Orthogonal64 returns a float64 (semi) orthogonal matrix scaled by gain, using the method described in Saxe et al (2013):
+// the rows (or the columns, if there are more rows than columns) of a gaussian matrix are orthonormalized.
+// See also: https://arxiv.org/abs/1312.6120
+func Orthogonal64(gain float64, s ...int) []float64 {
+	return orthogonal64(nil, gain, s...)
+}
+
+func orthogonal64(r *rand.Rand, gain float64, s ...int) []float64 {
+	if len(s) < 2 {
+		panic("Orthogonal only works with Tensors of dimensions >= 2")
+	}
+
+	rows := s[0]
+	cols := tensor.Shape(s).TotalSize() / rows
+
+	// orthonormalize n vectors of length m, where n <= m
+	n, m := rows, cols
+	if rows > cols {
+		n, m = cols, rows
+	}
+
+	rand := rng.NewGaussianGenerator(randSeed(r))
+	a := make([]float64, n*m)
+	for i := range a {
+		a[i] = rand.Gaussian(0, 1)
+	}
+
+	// modified Gram-Schmidt
+	for i := 0; i < n; i++ {
+		vi := a[i*m : (i+1)*m]
+		for j := 0; j < i; j++ {
+			vj := a[j*m : (j+1)*m]
+			var proj float64
+			for k := range vi {
+				proj += vi[k] * vj[k]
+			}
+			for k := range vi {
+				vi[k] -= proj * vj[k]
+			}
+		}
+
+		var norm float64
+		for _, v := range vi {
+			norm += v * v
+		}
+		norm = math.Sqrt(norm)
+		for k := range vi {
+			vi[k] /= norm
+		}
+	}
+
+	retVal := make([]float64, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if rows > cols {
+				retVal[r*cols+c] = gain * a[c*m+r]
+			} else {
+				retVal[r*cols+c] = gain * a[r*m+c]
+			}
+		}
+	}
+	return retVal
+}
+
+// Orthogonal32 returns a float32 (semi) orthogonal matrix scaled by gain. See Orthogonal64 for details.
+func Orthogonal32(gain float64, s ...int) []float32 {
+	return orthogonal32(nil, gain, s...)
+}
+
+func orthogonal32(r *rand.Rand, gain float64, s ...int) []float32 {
+	f64 := orthogonal64(r, gain, s...)
+	retVal := make([]float32, len(f64))
+	for i, v := range f64 {
+		retVal[i] = float32(v)
+	}
+	return retVal
+}
@@ -0,0 +1,106 @@
+package gorgonia
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/chewxy/gorgonia/tensor"
+)
+
+func TestWithRNG(t *testing.T) {
+	inits := map[string]RandInitWFn{
+		"Gaussian":        RandGaussian(0, 1),
+		"Uniform":         RandUniform(-1, 1),
+		"GlorotN":         RandGlorotN(1),
+		"HeN":             RandHeN(math.Sqrt2),
+		"HeU":             RandHeU(math.Sqrt2),
+		"LeCunN":          RandLeCunN(),
+		"TruncatedNormal": RandTruncatedNormal(0, 1),
+		"Orthogonal":      RandOrthogonal(1),
+	}
+
+	for name, init := range inits {
+		for _, dt := range []tensor.Dtype{Float64, Float32} {
+			mk := func(seed int64) Value {
+				g := NewGraph(WithRNG(rand.New(rand.NewSource(seed))))
+				NewMatrix(g, dt, WithShape(3, 4), WithName("w0"), WithRandInit(init))
+				w := NewMatrix(g, dt, WithShape(3, 4), WithName("w1"), WithRandInit(init))
+				return w.Value()
+			}
+
+			a, b, c := mk(1337), mk(1337), mk(42)
+			if !ValueEq(a, b) {
+				t.Errorf("%s %v: Expected the same seed to give the same values. Got\n%v\n%v", name, dt, a, b)
+			}
+			if ValueEq(a, c) {
+				t.Errorf("%s %v: Expected different seeds to give different values", name, dt)
+			}
+
+			// without an RNG, the values are drawn from generators seeded with the time
+			w := NewMatrix(NewGraph(), dt, WithShape(3, 4), WithName("w"), WithRandInit(init))
+			if w.Value() == nil {
+				t.Errorf("%s %v: Expected a value without an RNG", name, dt)
+			}
+		}
+	}
+}
+
+func TestInitializers(t *testing.T) {
+	// orthogonal: the rows of a wide matrix, and the columns of a tall one, are orthonormal
+	for _, s := range []tensor.Shape{{3, 5}, {5, 3}, {4, 2, 3}} {
+		w := Orthogonal64(2, s...)
+		rows := s[0]
+		cols := s.TotalSize() / rows
+		n, m, at := rows, cols, func(i, k int) float64 { return w[i*cols+k] }
+		if rows > cols {
+			n, m, at = cols, rows, func(i, k int) float64 { return w[k*cols+i] }
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				var dot float64
+				for k := 0; k < m; k++ {
+					dot += at(i, k) * at(j, k)
+				}
+				expected := 0.0
+				if i == j {
+					expected = 4
+				}
+				if math.Abs(dot-expected) > 1e-10 {
+					t.Errorf("Orthogonal %v: Expected the dot product of %d and %d to be %v. Got %v instead", s, i, j, expected, dot)
+				}
+			}
+		}
+	}
+
+	// truncated normal
+	for _, v := range TruncatedNormal64(1, 0.5, 100, 100) {
+		if v < 0 || v > 2 {
+			t.Fatalf("Expected the truncated normal values to be within [0, 2]. Got %v", v)
+		}
+	}
+
+	// identity
+	id := Identity(3)(Float32, 2, 3).([]float32)
+	if expected := []float32{3, 0, 0, 0, 3, 0}; !floatsEqual32(expected, id) {
+		t.Errorf("Expected the identity to be %v. Got %v instead", expected, id)
+	}
+
+	// Glorot et al float32 are uniformly distributed
+	glorot := GlorotEtAlU32(1, 100, 50)
+	limit32 := float32(math.Sqrt(3.0 * 2.0 / 150))
+	for _, v := range glorot {
+		if v < -limit32 || v > limit32 {
+			t.Fatalf("Expected GlorotEtAlU32 to be within ±%v. Got %v", limit32, v)
+		}
+	}
+
+	// He et al float32
+	he := HeEtAlU32(1, 100, 50)
+	limit := float32(math.Sqrt(3.0 / 100))
+	for _, v := range he {
+		if v < -limit || v > limit {
+			t.Fatalf("Expected HeEtAlU32 to be within ±%v. Got %v", limit, v)
+		}
+	}
+}